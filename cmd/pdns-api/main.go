@@ -28,6 +28,7 @@ import (
 	"github.com/mixanemca/pdns-api/internal/app/worker"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
@@ -53,6 +54,11 @@ func main() {
 	logger.Infof("Version: %s; Build: %s", cfg.Version, cfg.Build)
 	logger.Infof("Server start as a role %s", cfg.Role)
 
+	shutdownTracing, err := tracing.Init(*cfg)
+	if err != nil {
+		logger.Fatalf("error occurred while initializing tracing: %s\n", err.Error())
+	}
+
 	stats := initStats()
 
 	quit := make(chan os.Signal, 1)
@@ -77,7 +83,7 @@ func main() {
 		workerApp := worker.NewApp(*cfg, logger)
 		workerApp.Run(stats, withHealth)
 		apiApp := api.NewApp(*cfg, logger)
-		apiApp.Run(stats)
+		apiApp.Run(stats, workerApp.InternalChecks())
 
 		<-quit
 
@@ -96,6 +102,13 @@ func main() {
 		}
 	}
 
+	ctxTracing, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTracing()
+	// Flush remaining spans
+	if err := shutdownTracing(ctxTracing); err != nil {
+		logger.Error(err)
+	}
+
 	logger.Info("Server successfully stopped")
 }
 
@@ -163,5 +176,106 @@ func initStats() *stats.PrometheusStats {
 	prometheus.MustRegister(pdnsErrorsCounter)
 	prometheus.MustRegister(pdnsResponseTimeHistogram)
 
-	return stats.NewPrometheusStats(pdnsUp, pdnsCounter, pdnsErrorsCounter, pdnsResponseTimeHistogram)
+	return stats.NewPrometheusStats(pdnsUp, pdnsCounter, pdnsErrorsCounter, pdnsResponseTimeHistogram, initLDAPStats())
+}
+
+func initLDAPStats() *stats.LDAPStats {
+	// pdns_api_ldap_up{environment="dev",node="pdns-dev01"} 1
+	ldapUp := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pdns_api_ldap_up",
+			Help: "Whether the LDAP server is reachable",
+		},
+		[]string{
+			"environment",
+			"node",
+		},
+	)
+
+	// pdns_api_ldap_pool_connections{environment="dev",node="pdns-dev01",state="idle"} 3
+	ldapPool := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pdns_api_ldap_pool_connections",
+			Help: "Number of LDAP connections in the pool by state",
+		},
+		[]string{
+			"environment",
+			"node",
+			"state",
+		},
+	)
+
+	// pdns_api_ldap_breaker_state{environment="dev",node="pdns-dev01"} 0
+	ldapBreaker := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pdns_api_ldap_breaker_state",
+			Help: "LDAP circuit breaker state: 0 closed, 1 half-open, 2 open",
+		},
+		[]string{
+			"environment",
+			"node",
+		},
+	)
+
+	// pdns_api_ldap_requests_total{environment="dev",node="pdns-dev01",operation="search",result="ok"} 42
+	ldapRequests := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pdns_api_ldap_requests_total",
+			Help: "Statistics of LDAP operations by result",
+		},
+		[]string{
+			"environment",
+			"node",
+			"operation",
+			"result",
+		},
+	)
+
+	ldapDuration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "pdns_api_ldap_request_duration_s",
+			Help:    "Histogram of LDAP operations duration in seconds",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{
+			"environment",
+			"node",
+			"operation",
+		},
+	)
+
+	// pdns_api_auth_cache_total{environment="dev",node="pdns-dev01",result="hit"} 1024
+	authCacheCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pdns_api_auth_cache_total",
+			Help: "Statistics of authorization cache lookups by result",
+		},
+		[]string{
+			"environment",
+			"node",
+			"result",
+		},
+	)
+
+	// pdns_api_auth_cache_entries{environment="dev",node="pdns-dev01"} 12
+	authCacheEntries := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pdns_api_auth_cache_entries",
+			Help: "Number of cached authorization decisions",
+		},
+		[]string{
+			"environment",
+			"node",
+		},
+	)
+
+	prometheus.MustRegister(ldapUp)
+	prometheus.MustRegister(ldapPool)
+	prometheus.MustRegister(ldapBreaker)
+	prometheus.MustRegister(ldapRequests)
+	prometheus.MustRegister(ldapDuration)
+	prometheus.MustRegister(authCacheCounter)
+	prometheus.MustRegister(authCacheEntries)
+
+	return stats.NewLDAPStats(ldapUp, ldapPool, ldapBreaker, ldapRequests, ldapDuration, authCacheCounter, authCacheEntries)
 }
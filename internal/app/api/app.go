@@ -20,22 +20,34 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/connect"
+	"github.com/miekg/dns"
 	apiV1 "github.com/mixanemca/pdns-api/internal/app/api/handler/v1"
+	"github.com/mixanemca/pdns-api/internal/app/api/openapi"
 	commonV1 "github.com/mixanemca/pdns-api/internal/app/common/handler/v1"
 	"github.com/mixanemca/pdns-api/internal/app/middleware"
 	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/client"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/ddns"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/doh"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/health"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/idempotency"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/pdns"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 
 	pdnsApi "github.com/mittwald/go-powerdns"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/consul"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/mixanemca/pdns-api/internal/app/config"
@@ -47,7 +59,21 @@ type app struct {
 	config           config.Config
 	consul           *api.Client
 	logger           *logrus.Logger
+	ldap             closer
+	auditor          closer
 	publicHTTPServer *http.Server
+	// stopDoHZones stops reloading of zones served by DoH
+	stopDoHZones context.CancelFunc
+	// stopDDNSKeys stops reloading of TSIG keys of DNS UPDATE listener
+	stopDDNSKeys context.CancelFunc
+	// ddnsMu protects ddnsServers, they are restarted when TSIG keys are changed
+	ddnsMu sync.Mutex
+	// ddnsServers are UDP and TCP servers of DNS UPDATE listener
+	ddnsServers []*dns.Server
+}
+
+type closer interface {
+	Close()
 }
 
 func NewApp(cfg config.Config, logger *logrus.Logger) *app {
@@ -64,12 +90,14 @@ func NewApp(cfg config.Config, logger *logrus.Logger) *app {
 }
 
 //The entry point of pdns-api
-func (a *app) Run(prometheusStats *stats.PrometheusStats) {
+// internalChecks are readiness checks of internal API of the worker running in the same process.
+func (a *app) Run(prometheusStats *stats.PrometheusStats, internalChecks []health.Check) {
 	a.logger.Debug("Run API app")
 
 	authPowerDNSClient, err := pdnsApi.New(
 		pdnsApi.WithBaseURL(a.config.PDNS.AuthConfig.BaseURL),
 		pdnsApi.WithAPIKeyAuthentication(a.config.PDNS.AuthConfig.ApiKey),
+		pdnsApi.WithHTTPClient(tracing.NewHTTPClient()),
 	)
 	if err != nil {
 		a.logger.WithFields(logrus.Fields{
@@ -86,25 +114,77 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats) {
 
 	errorWriter := network.NewErrorWriter(a.config, a.logger, prometheusStats)
 
-	healthHandler := commonV1.NewHealthHandler(a.config)
+	ldapService, err := ldap.NewLDAPService(a.logger, a.config, prometheusStats)
+	if err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"action": log.ActionSystem,
+		}).Fatalf("Cannot create a ldap auth client: %v", err)
+	}
+	a.ldap = ldapService
+
+	auditor, err := audit.NewAuditor(a.config, a.logger)
+	if err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"action": log.ActionSystem,
+		}).Fatalf("Cannot create an audit log: %v", err)
+	}
+	a.auditor = auditor
+
+	var idempotencyStore idempotency.Store
+	if a.config.Idempotency.Enabled {
+		idempotencyStore, err = idempotency.NewStore(a.config, a.consul)
+		if err != nil {
+			a.logger.WithFields(logrus.Fields{
+				"action": log.ActionSystem,
+			}).Fatalf("Cannot create an idempotency store: %v", err)
+		}
+	}
+
+	spec, err := openapi.Load()
+	if err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"action": log.ActionSystem,
+		}).Fatalf("Cannot load OpenAPI specification: %v", err)
+	}
+	validationMiddleware, err := middleware.NewValidationMiddleware(errorWriter, spec)
+	if err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"action": log.ActionSystem,
+		}).Fatalf("Cannot create a request validation middleware: %v", err)
+	}
+
+	// API role depends on PowerDNS Authoritative, Consul for internal requests and LDAP.
+	// Reading requests don't need LDAP, so its outage degrades the node instead of removing it from Consul.
+	checks := []health.Check{
+		{Name: "pdns-auth", Fn: health.PowerDNS(authPowerDNSClient)},
+		{Name: "consul", Fn: health.Consul(a.consul)},
+	}
+	if a.config.LDAP.Enabled {
+		checks = append(checks, health.Check{Name: "ldap", Fn: health.LDAP(ldapService), Optional: true})
+	}
+	healthHandler := commonV1.NewHealthHandler(a.config, checks...)
+	internalHealthHandler := commonV1.NewHealthHandler(a.config, internalChecks...)
 	listServersHandler := apiV1.NewListServersHandler(a.config, errorWriter, prometheusStats, a.logger, authPowerDNSClient)
-	listServerHandler := apiV1.NewListServerHandler(a.config, prometheusStats, authPowerDNSClient)
-	searchDataHandler := apiV1.NewListServerHandler(a.config, prometheusStats, authPowerDNSClient)
-	forwardZonesHandler := apiV1.NewForwardZonesHandler(a.config, prometheusStats, authPowerDNSClient)
-	zonesHandler := apiV1.NewZonesHandler(a.config, prometheusStats, authPowerDNSClient)
-	versionHandler := apiV1.NewVersionHandler(a.config, prometheusStats)
+	listServerHandler := apiV1.NewListServerHandler(a.config, errorWriter, prometheusStats, authPowerDNSClient)
+	searchDataHandler := apiV1.NewSearchDataHandler(a.config, errorWriter, prometheusStats, authPowerDNSClient)
+	forwardZonesHandler := apiV1.NewForwardZonesHandler(a.config, errorWriter, prometheusStats, authPowerDNSClient)
+	zonesHandler := apiV1.NewZonesHandler(a.config, errorWriter, prometheusStats, authPowerDNSClient)
+	versionHandler := apiV1.NewVersionHandler(a.config, errorWriter, prometheusStats)
+	auditHandler := apiV1.NewAuditHandler(a.config, errorWriter, prometheusStats, auditor)
+	openAPIHandler := apiV1.NewOpenAPIHandler(a.config, errorWriter, prometheusStats, spec)
 
 	publicRouter := mux.NewRouter()
-	// HTTP public Handlers
-	publicRouter.HandleFunc("/api/v1/health", healthHandler.Health).Methods(http.MethodGet)
-	publicRouter.HandleFunc("/api/v1/servers", listServersHandler.ListServers).Methods(http.MethodGet)
-	publicRouter.HandleFunc("/api/v1/servers/{serverID}", listServerHandler.ListServer).Methods(http.MethodGet)
-	publicRouter.HandleFunc("/api/v1/servers/{serverID}/search-data", searchDataHandler.SearchData).Methods(http.MethodGet)
-	publicRouter.HandleFunc("/api/v1/servers/{serverID}/forward-zones", forwardZonesHandler.ListForwardZones).Methods(http.MethodGet)
-	publicRouter.HandleFunc("/api/v1/servers/{serverID}/forward-zones/{zoneID}", forwardZonesHandler.ListForwardZone).Methods(http.MethodGet)
-	publicRouter.HandleFunc("/api/v1/servers/{serverID}/zones", zonesHandler.ListZones).Methods(http.MethodGet)
-	publicRouter.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}", zonesHandler.ListZone).Methods(http.MethodGet)
-	publicRouter.HandleFunc("/api/v1/version", versionHandler.Get).Methods(http.MethodGet)
+	publicRouter.Use(otelmux.Middleware(tracing.ServiceName))
+	// Request ID must be set before other middlewares use it
+	publicRouter.Use(middleware.RequestIDMiddleware)
+	// Audit all mutating requests, including rejected by authorization
+	publicRouter.Use(middleware.NewAuditMiddleware(auditor).AuditMiddleware)
+	// Reject requests not matching OpenAPI specification before authorization and handlers
+	publicRouter.Use(validationMiddleware.ValidationMiddleware)
+	if a.config.Idempotency.Enabled {
+		// Replay retried requests before they reach authorization and handlers
+		publicRouter.Use(middleware.NewIdempotencyMiddleware(errorWriter, a.logger, idempotencyStore).IdempotencyMiddleware)
+	}
 
 	// Prometheus metrics
 	publicRouter.Handle("/metrics", promhttp.Handler())
@@ -117,13 +197,6 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats) {
 		}).Fatalf("Cannot create a Consul Connect service %s: %v", client.PDNSInternalServiceName, err)
 	}
 
-	ldapService, err := ldap.NewLDAPService(a.logger, a.config)
-	if err != nil {
-		a.logger.WithFields(logrus.Fields{
-			"action": log.ActionSystem,
-		}).Fatalf("Cannot create a ldap auth client: %v", err)
-	}
-
 	ptrRecorder := zone.NewPTR(a.logger, authPowerDNSClient)
 	internalClient := client.NewClient(
 		a.config,
@@ -139,6 +212,7 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats) {
 		ldapService,
 	)
 
+	pdnsClient := pdns.NewClient(a.config.PDNS.AuthConfig, tracing.NewHTTPClient())
 	addZoneHanler := apiV1.NewAddZone(
 		a.config,
 		ldapService,
@@ -146,6 +220,8 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats) {
 		prometheusStats,
 		a.logger,
 		authPowerDNSClient,
+		zone.NewTemplateStore(a.config.ZoneTemplates, a.consul),
+		pdnsClient,
 	)
 
 	deleteZoneHanler := apiV1.NewDeleteZone(
@@ -163,9 +239,65 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats) {
 		prometheusStats,
 		a.logger,
 		authPowerDNSClient,
+		pdnsClient,
 		ptrRecorder,
 		internalClient,
 	)
+	rrsetHandler := apiV1.NewRRSetHandler(patchZoneHanler)
+	acmeHandler := apiV1.NewACMEHandler(patchZoneHanler, ldapService)
+	zoneActionsHandler := apiV1.NewZoneActionsHandler(
+		a.config,
+		errorWriter,
+		prometheusStats,
+		a.logger,
+		authPowerDNSClient,
+	)
+	zoneTSIGKeysHandler := apiV1.NewZoneTSIGKeysHandler(
+		a.config,
+		errorWriter,
+		prometheusStats,
+		a.logger,
+		authPowerDNSClient,
+		pdnsClient,
+	)
+	zoneTransferHandler := apiV1.NewZoneTransferHandler(
+		a.config,
+		errorWriter,
+		prometheusStats,
+		a.logger,
+		authPowerDNSClient,
+		pdnsClient,
+		ldapService,
+	)
+	zoneConsistencyHandler := apiV1.NewZoneConsistencyHandler(
+		a.config,
+		errorWriter,
+		prometheusStats,
+		a.logger,
+		internalClient,
+	)
+	zoneVerifyHandler := apiV1.NewZoneVerifyHandler(
+		a.config,
+		errorWriter,
+		prometheusStats,
+		a.logger,
+		internalClient,
+	)
+	lookupHandler := apiV1.NewLookupHandler(
+		a.config,
+		errorWriter,
+		prometheusStats,
+		a.logger,
+	)
+	ddnsKeys := ddns.NewKeys(pdnsClient, a.logger)
+	tsigKeysHandler := apiV1.NewTSIGKeysHandler(
+		a.config,
+		errorWriter,
+		prometheusStats,
+		a.logger,
+		pdnsClient,
+		ddnsKeys,
+	)
 	publicAddForwardZonesHandler := apiV1.NewAddForwardZonesHandler(
 		a.config,
 		ldapService,
@@ -196,29 +328,77 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats) {
 		prometheusStats,
 		internalClient,
 	)
+	flushCacheHandler := apiV1.NewFlushCacheHandler(
+		a.config,
+		errorWriter,
+		prometheusStats,
+		a.logger,
+		internalClient,
+	)
+	authCacheHandler := apiV1.NewAuthCacheHandler(
+		a.config,
+		prometheusStats,
+		a.logger,
+		ldapService,
+	)
 
+	var auth mux.MiddlewareFunc
 	if viper.GetBool("ldap.enabled") {
-		authRouter := publicRouter.Methods(http.MethodDelete, http.MethodPatch, http.MethodPost).Subrouter()
-		authRouter.Use(authMiddleware.AuthMiddleware)
-		// HTTP Handlers with Authorization
-		authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}", publicAddForwardZonesHandler.AddForwardZones).Methods(http.MethodPost)
-		authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}", publicDelForwardZonesHandler.DelForwardZones).Methods(http.MethodDelete)
-		authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}/{zoneID}", publicPatchForwardZoneHandler.PatchForwardZone).Methods(http.MethodPatch)
-		authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}/{zoneID}", publicDelForwardZoneHandler.DelForwardZone).Methods(http.MethodDelete)
-		authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}", addZoneHanler.AddZone).Methods(http.MethodPost)
-		authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}", patchZoneHanler.PatchZone).Methods(http.MethodPatch)
-		authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}", deleteZoneHanler.DeleteZone).Methods(http.MethodDelete)
-	} else {
-		publicRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}", publicAddForwardZonesHandler.AddForwardZones).Methods(http.MethodPost)
-		publicRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}", publicDelForwardZonesHandler.DelForwardZones).Methods(http.MethodDelete)
-		publicRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}/{zoneID}", publicPatchForwardZoneHandler.PatchForwardZone).Methods(http.MethodPatch)
-		publicRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}/{zoneID}", publicDelForwardZoneHandler.DelForwardZone).Methods(http.MethodDelete)
-		publicRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}", addZoneHanler.AddZone).Methods(http.MethodPost)
-		publicRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}", patchZoneHanler.PatchZone).Methods(http.MethodPatch)
-		publicRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}", deleteZoneHanler.DeleteZone).Methods(http.MethodDelete)
+		auth = authMiddleware.AuthMiddleware
 	}
+	registerPublicRoutes(publicRouter, publicRoutes{
+		Health:        healthHandler.Health,
+		Live:          healthHandler.Live,
+		Ready:         healthHandler.Ready,
+		InternalReady: internalHealthHandler.Ready,
+		ListServers:   listServersHandler.ListServers,
+		ListServer:    listServerHandler.ListServer,
+		SearchData:    searchDataHandler.SearchData,
+		Version:       versionHandler.Get,
+		OpenAPI:       openAPIHandler.Get,
+		Lookup:        lookupHandler.Lookup,
+		ListEvents:    auditHandler.ListEvents,
+
+		ListForwardZones: forwardZonesHandler.ListForwardZones,
+		ListForwardZone:  forwardZonesHandler.ListForwardZone,
+		AddForwardZones:  publicAddForwardZonesHandler.AddForwardZones,
+		DelForwardZones:  publicDelForwardZonesHandler.DelForwardZones,
+		PatchForwardZone: publicPatchForwardZoneHandler.PatchForwardZone,
+		DelForwardZone:   publicDelForwardZoneHandler.DelForwardZone,
+
+		ListZones:          zonesHandler.ListZones,
+		ListZone:           zonesHandler.ListZone,
+		AddZone:            addZoneHanler.AddZone,
+		PatchZone:          patchZoneHanler.PatchZone,
+		DeleteZone:         deleteZoneHanler.DeleteZone,
+		GetRRSet:           rrsetHandler.GetRRSet,
+		PutRRSet:           rrsetHandler.PutRRSet,
+		DeleteRRSet:        rrsetHandler.DeleteRRSet,
+		GetZoneTSIGKeys:    zoneTSIGKeysHandler.GetZoneTSIGKeys,
+		PutZoneTSIGKeys:    zoneTSIGKeysHandler.PutZoneTSIGKeys,
+		TransferZone:       zoneTransferHandler.TransferZone,
+		GetZoneConsistency: zoneConsistencyHandler.GetZoneConsistency,
+		AxfrRetrieve:       zoneActionsHandler.AxfrRetrieve,
+		Notify:             zoneActionsHandler.Notify,
+		Rectify:            zoneActionsHandler.Rectify,
+		VerifyZone:         zoneVerifyHandler.VerifyZone,
+
+		ListTSIGKeys:  tsigKeysHandler.ListTSIGKeys,
+		GetTSIGKey:    tsigKeysHandler.GetTSIGKey,
+		AddTSIGKey:    tsigKeysHandler.AddTSIGKey,
+		UpdateTSIGKey: tsigKeysHandler.UpdateTSIGKey,
+		DeleteTSIGKey: tsigKeysHandler.DeleteTSIGKey,
+
+		AddChallenge:    acmeHandler.AddChallenge,
+		DeleteChallenge: acmeHandler.DeleteChallenge,
+		FlushCache:      flushCacheHandler.FlushCache,
+		FlushAuthCache:  authCacheHandler.FlushAuthCache,
+	}, auth)
 
 	a.publicHTTPServer.Handler = publicRouter
+	if a.config.DoH.Enabled {
+		a.publicHTTPServer.Handler = a.mountDoH(publicRouter, prometheusStats, authPowerDNSClient)
+	}
 
 	go func() {
 		if err := a.publicHTTPServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -229,6 +409,108 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats) {
 	}()
 
 	a.logger.Infof("Public HTTP server started and listen on %s", net.JoinHostPort(a.config.PublicHTTP.Address, a.config.PublicHTTP.Port))
+
+	if a.config.DDNS.Enabled {
+		a.runDDNS(authPowerDNSClient, pdnsClient, patchZoneHanler, auditor, ddnsKeys)
+	}
+}
+
+// mountDoH serves DNS-over-HTTPS on the public listener. DoH path has own router without audit,
+// validation and authorization of REST API, DNS queries are answered like by a public resolver.
+func (a *app) mountDoH(publicRouter *mux.Router, prometheusStats *stats.PrometheusStats, authPowerDNSClient pdnsApi.Client) http.Handler {
+	zones := doh.NewZones(authPowerDNSClient, a.logger)
+	var ctx context.Context
+	ctx, a.stopDoHZones = context.WithCancel(context.Background())
+	go zones.Run(ctx, "localhost", time.Duration(a.config.DoH.ZonesRefresh)*time.Second)
+
+	dohRouter := mux.NewRouter()
+	dohRouter.Use(otelmux.Middleware(tracing.ServiceName))
+	dohRouter.Use(middleware.RequestIDMiddleware)
+	dohRouter.Handle(a.config.DoH.Path, doh.NewHandler(a.config, prometheusStats, a.logger, zones)).Methods(http.MethodGet, http.MethodPost)
+
+	rootRouter := mux.NewRouter()
+	rootRouter.Handle(a.config.DoH.Path, dohRouter)
+	rootRouter.PathPrefix("/").Handler(publicRouter)
+
+	// Public listener has no TLS, while DoH clients require HTTPS
+	a.logger.WithFields(logrus.Fields{
+		"action": log.ActionSystem,
+	}).Warnf("DoH is served on %s of public listener without TLS, it must be behind TLS terminating proxy", a.config.DoH.Path)
+	return rootRouter
+}
+
+// runDDNS starts DNS UPDATE listener over UDP and TCP. dns.Server doesn't allow to change TSIG keys
+// while running, so the servers are restarted when keys reloaded from PowerDNS are changed.
+func (a *app) runDDNS(authPowerDNSClient pdnsApi.Client, pdnsClient *pdns.Client, patchZone *apiV1.PatchZone, auditor *audit.Auditor, keys *ddns.Keys) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(a.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+	_, err := keys.Load(ctx)
+	if err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"action": log.ActionSystem,
+		}).Fatalf("Cannot load TSIG keys for DNS UPDATE: %v", err)
+	}
+
+	handler := ddns.NewHandler(a.config, a.logger, authPowerDNSClient, pdnsClient, patchZone, auditor, keys)
+	a.ddnsMu.Lock()
+	a.startDDNS(handler, keys.Secrets())
+	a.ddnsMu.Unlock()
+
+	var keysCtx context.Context
+	keysCtx, a.stopDDNSKeys = context.WithCancel(context.Background())
+	go keys.Run(keysCtx, time.Duration(a.config.DDNS.KeysRefresh)*time.Second, func() {
+		a.restartDDNS(handler, keys)
+	})
+
+	a.logger.Infof("DNS UPDATE server started and listen on %s with %d TSIG keys", net.JoinHostPort(a.config.DDNS.Address, a.config.DDNS.Port), keys.Len())
+}
+
+// startDDNS starts UDP and TCP servers of DNS UPDATE listener with the TSIG secrets and waits until
+// they listen. a.ddnsMu must be held.
+func (a *app) startDDNS(handler dns.Handler, secrets map[string]string) {
+	a.ddnsServers = nil
+	for _, proto := range []string{"udp", "tcp"} {
+		started := make(chan struct{})
+		srv := &dns.Server{
+			Addr:              net.JoinHostPort(a.config.DDNS.Address, a.config.DDNS.Port),
+			Net:               proto,
+			Handler:           handler,
+			TsigSecret:        secrets,
+			MsgAcceptFunc:     ddns.AcceptUpdate,
+			NotifyStartedFunc: func() { close(started) },
+		}
+		a.ddnsServers = append(a.ddnsServers, srv)
+		go func() {
+			if err := srv.ListenAndServe(); err != nil {
+				a.logger.WithFields(logrus.Fields{
+					"action": log.ActionSystem,
+				}).Fatalf("error occurred while running DNS UPDATE server: %s\n", err.Error())
+			}
+		}()
+		<-started
+	}
+}
+
+// restartDDNS restarts DNS UPDATE listener with the current TSIG keys
+func (a *app) restartDDNS(handler dns.Handler, keys *ddns.Keys) {
+	a.ddnsMu.Lock()
+	defer a.ddnsMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(a.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+	for _, srv := range a.ddnsServers {
+		// Listener is closed even if waiting for active connections fails
+		if err := srv.ShutdownContext(ctx); err != nil {
+			a.logger.WithFields(logrus.Fields{
+				"action": log.ActionSystem,
+			}).Errorf("Stopping DNS UPDATE server: %v", err)
+		}
+	}
+	a.startDDNS(handler, keys.Secrets())
+
+	a.logger.WithFields(logrus.Fields{
+		"action": log.ActionSystem,
+	}).Infof("DNS UPDATE server restarted with %d TSIG keys", keys.Len())
 }
 
 // Shutdown Shutdown gracefully shuts down the server without interrupting any active connections.
@@ -246,5 +528,34 @@ func (a *app) Shutdown(ctx context.Context) error {
 	}
 	a.logger.Info("Public HTTP server successfully stopped")
 
+	if a.stopDoHZones != nil {
+		a.stopDoHZones()
+	}
+
+	if a.stopDDNSKeys != nil {
+		a.stopDDNSKeys()
+	}
+	a.ddnsMu.Lock()
+	defer a.ddnsMu.Unlock()
+	for _, srv := range a.ddnsServers {
+		if err := srv.ShutdownContext(ctx); err != nil {
+			a.logger.Errorf("Stopping DNS UPDATE server: %v", err)
+			return err
+		}
+	}
+	if len(a.ddnsServers) > 0 {
+		a.logger.Info("DNS UPDATE server successfully stopped")
+	}
+
+	if a.ldap != nil {
+		a.ldap.Close()
+		a.logger.Debug("LDAP connections successfully closed")
+	}
+
+	if a.auditor != nil {
+		a.auditor.Close()
+		a.logger.Debug("Audit log successfully closed")
+	}
+
 	return nil
 }
@@ -0,0 +1,303 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// ACME challenge records are managed in zones of the local PowerDNS Authoritative
+const (
+	acmeServerID = "localhost"
+	// DefaultACMETimeout is a number of seconds to wait for challenge records on all nodes if timeout is omitted
+	DefaultACMETimeout = 60
+	// acmeChangeAttempts is a number of times the challenge RRset is changed if changes
+	// of other nodes overwrite it
+	acmeChangeAttempts = 3
+)
+
+// ACMEChallengeRequest is a DNS-01 challenge record to add
+type ACMEChallengeRequest struct {
+	// Name is the validated domain, it may have a wildcard prefix or start with _acme-challenge label
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Timeout is a number of seconds to wait until all nodes serve the records,
+	// DefaultACMETimeout if omitted, checks are done once if 0
+	Timeout *int `json:"timeout,omitempty"`
+}
+
+// ACMEChallenge is a state of challenge TXT records after a change
+type ACMEChallenge struct {
+	Name string `json:"name"`
+	Zone string `json:"zone"`
+	// Values are values of enabled records of the TXT RRset
+	Values []string `json:"values"`
+	// Visible is true if all servers of all nodes answer with the values
+	Visible bool `json:"visible"`
+	// Attempts is a number of times the checks were done
+	Attempts int                `json:"attempts"`
+	Nodes    []NodeVerification `json:"nodes"`
+}
+
+type ldapAuthorizer interface {
+	AuthorizeViaLDAP(ctx context.Context, cnType, zoneType, zone, username string) (bool, error)
+}
+
+// ACMEHandler manages ACME DNS-01 challenge TXT records in the closest authoritative zone of a domain.
+// It shares validation, PTR handling and cache flushing with PatchZone.
+// The zone is known only after lookup, so LDAP authorization is done here instead of AuthMiddleware.
+type ACMEHandler struct {
+	*PatchZone
+	ldapAuth ldapAuthorizer
+}
+
+func NewACMEHandler(patchZone *PatchZone, ldapAuth ldapAuthorizer) *ACMEHandler {
+	return &ACMEHandler{PatchZone: patchZone, ldapAuth: ldapAuth}
+}
+
+// AddChallenge adds the value to challenge records of the domain and waits until all nodes serve it
+func (s *ACMEHandler) AddChallenge(w http.ResponseWriter, r *http.Request) {
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionACMEChallengeAdd)
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	var req ACMEChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionACMEChallengeAdd, errors.BadRequest.Wrap(err, "decoding challenge"))
+		return
+	}
+	timeout := DefaultACMETimeout
+	if req.Timeout != nil {
+		timeout = *req.Timeout
+	}
+	s.changeChallenge(w, r, log.ActionACMEChallengeAdd, req.Name, req.Value, timeout, false)
+}
+
+// DeleteChallenge removes the value, or all values if it is omitted, from challenge records
+// of the domain and waits until all nodes stop serving it. Removing absent values is not an error,
+// so clients can retry.
+func (s *ACMEHandler) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionACMEChallengeDel)
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	query := r.URL.Query()
+	timeout := DefaultACMETimeout
+	if t := query.Get("timeout"); t != "" {
+		var err error
+		timeout, err = strconv.Atoi(t)
+		if err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionACMEChallengeDel, errors.AddFieldError(
+				errors.BadRequest.Newf("invalid timeout %q", t), "timeout", "must be an integer"))
+			return
+		}
+	}
+	s.changeChallenge(w, r, log.ActionACMEChallengeDel, query.Get("name"), query.Get("value"), timeout, true)
+}
+
+// changeChallenge adds or removes the value of challenge records of the domain,
+// flushes cache of the name on all nodes and waits until all of them serve the result
+func (s *ACMEHandler) changeChallenge(w http.ResponseWriter, r *http.Request, action, domain, value string, timeout int, remove bool) {
+	name, err := zone.ACMEChallengeName(domain)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, action, err)
+		return
+	}
+	if value != "" || !remove {
+		if err := zone.ValidateACMEValue(value); err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, action, err)
+			return
+		}
+	}
+	if timeout < 0 || timeout > MaxVerifyTimeout {
+		s.errorWriter.WriteError(w, r.URL.Path, action, errors.AddFieldError(
+			errors.BadRequest.Newf("invalid timeout %d", timeout), "timeout", fmt.Sprintf("must be between 0 and %d", MaxVerifyTimeout)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	z, err := s.findZone(ctx, name)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, action, err)
+		return
+	}
+	audit.FromContext(r.Context()).SetZone(acmeServerID, z.Name)
+
+	cnType := ldap.CNTypeReplace
+	if remove {
+		cnType = ldap.CNTypeDelete
+	}
+	err = s.authorize(ctx, r, cnType, z.Name)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionLDAPAuthorization, err)
+		return
+	}
+
+	values, err := s.applyChallenge(ctx, z, name, value, remove)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, action, err)
+		return
+	}
+	// Caches are flushed even without changes, resolvers may keep a negative answer
+	// for the name queried by the client before adding the records
+	err = s.flushRRSets(r.Context(), acmeServerID, []zones.ResourceRecordSet{{Name: name, Type: "TXT"}})
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, err)
+		return
+	}
+
+	result, err := s.waitChallenge(r.Context(), z.Name, name, values, time.Duration(timeout)*time.Second)
+	if err != nil {
+		if r.Context().Err() != nil {
+			// Client has gone away
+			return
+		}
+		s.errorWriter.WriteError(w, r.URL.Path, action, err)
+		return
+	}
+
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": action,
+		"zone":   z.Name,
+		"rr":     name,
+	}).Infof("Challenge records %s of zone %s were changed, visible: %t after %d attempts", name, z.Name, result.Visible, result.Attempts)
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, action, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
+
+// applyChallenge adds or removes the value of challenge records name of the zone and returns
+// the resulting values. PowerDNS replaces the whole RRset, so the zone is locked against other writers
+// on this node, and the RRset is re-read after the change and changed again if another node overwrote it.
+func (s *ACMEHandler) applyChallenge(ctx context.Context, z *zones.Zone, name, value string, remove bool) ([]string, error) {
+	unlock := s.LockZone(z.ID)
+	defer unlock()
+
+	for attempt := 0; ; attempt++ {
+		current, err := s.auth.Zones().GetZone(ctx, acmeServerID, z.ID)
+		if err != nil {
+			return nil, errors.WrapPDNS(err, "getting zone %s", z.Name)
+		}
+		change, values := zone.ACMEChallengeChange(current, name, value, remove)
+		if change == nil {
+			return values, nil
+		}
+		if attempt == acmeChangeAttempts {
+			return nil, errors.Conflict.Newf("challenge records %s are changed concurrently", name)
+		}
+		err = s.patchRRSets(ctx, acmeServerID, z.ID, []zones.ResourceRecordSet{*change}, rrsetField)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// findZone returns the closest authoritative zone of the name, challenges of Slave zones must be set on their masters
+func (s *ACMEHandler) findZone(ctx context.Context, name string) (*zones.Zone, error) {
+	list, err := s.auth.Zones().ListZones(ctx, acmeServerID)
+	if err != nil {
+		return nil, errors.WrapPDNS(err, "listing zones")
+	}
+	names := make([]string, len(list))
+	for i, z := range list {
+		names[i] = z.Name
+	}
+	found := zone.FindZone(names, name)
+	for i := range list {
+		z := &list[i]
+		if found == "" || strings.ToLower(network.Canonicalize(z.Name)) != found {
+			continue
+		}
+		if z.Kind == zones.ZoneKindSlave {
+			return nil, errors.AddFieldError(errors.BadRequest.Newf("zone %s of %s is Slave", z.Name, name), "name", "must be in a Native or Master zone")
+		}
+		return z, nil
+	}
+	return nil, errors.NotFound.Newf("no authoritative zone for %s", name)
+}
+
+// authorize checks LDAP permission of the user for challenge records of the zone.
+// Permissions for the zone of acme type allow changes of challenge records only,
+// permissions for the zone of zones type allow any changes.
+func (s *ACMEHandler) authorize(ctx context.Context, r *http.Request, cnType, zoneName string) error {
+	if !viper.GetBool("ldap.enabled") {
+		return nil
+	}
+	uid := r.Header.Get("X-PDNS-Client-UID")
+	if uid == "" {
+		return errors.Unauthorized.New("X-PDNS-Client-UID header is required")
+	}
+	for _, zoneType := range []string{zone.ACMEZoneType, forwardzone.ZoneTypeZone} {
+		authorized, err := s.ldapAuth.AuthorizeViaLDAP(ctx, cnType, zoneType, zoneName, uid)
+		if err != nil {
+			s.logger.WithContext(ctx).WithFields(logrus.Fields{
+				"action":   log.ActionLDAPAuthorization,
+				"zone":     zoneName,
+				"zoneType": zoneType,
+				"uid":      uid,
+			}).Errorf("Failed to authorize user %s for %s: %v", uid, cnType, err)
+			return err
+		}
+		if authorized {
+			return nil
+		}
+	}
+	return errors.Forbidden.Newf("user %s has no %s permission for %s %s", uid, cnType, zone.ACMEZoneType, zoneName)
+}
+
+// waitChallenge waits until all nodes answer with the values of challenge records
+func (s *ACMEHandler) waitChallenge(ctx context.Context, zoneName, name string, values []string, timeout time.Duration) (*ACMEChallenge, error) {
+	records := make([]string, len(values))
+	for i, value := range values {
+		records[i] = zone.TXTContent(value)
+	}
+	exps := []zone.Expectation{{Name: name, Type: "TXT", Records: records}}
+	if err := zone.PrepareExpectations(zoneName, exps); err != nil {
+		return nil, err
+	}
+	bodyBytes, err := json.Marshal(exps)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding expected RRsets")
+	}
+
+	verification, err := waitVerification(ctx, s.internalClient, acmeServerID, zoneName, bodyBytes, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &ACMEChallenge{
+		Name:     name,
+		Zone:     zoneName,
+		Values:   values,
+		Visible:  verification.Converged,
+		Attempts: verification.Attempts,
+		Nodes:    verification.Nodes,
+	}, nil
+}
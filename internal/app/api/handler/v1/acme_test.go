@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyChallengeConcurrently(t *testing.T) {
+	patchZone, p := newPatchZone(t)
+	s := NewACMEHandler(patchZone, nil)
+
+	// Certificate for a domain and its wildcard has challenges of the same name
+	values := map[string]string{
+		"example.com":   "LoqXcYV8q5ONbJQxbmR7SCTNo3tiAXDfowyjxAjEuX0",
+		"*.example.com": "BSs9gvPvLMaWW-F9lzY5FSa5JEbsrGP1pgJTw7IeVFs",
+	}
+	z := &zones.Zone{ID: "example.com.", Name: "example.com."}
+	errs := make(chan error, len(values))
+	for domain, value := range values {
+		name, err := zone.ACMEChallengeName(domain)
+		require.NoError(t, err)
+		go func(name, value string) {
+			_, err := s.applyChallenge(context.Background(), z, name, value, false)
+			errs <- err
+		}(name, value)
+	}
+	for range values {
+		require.NoError(t, <-errs)
+	}
+
+	rrset := p.zone.GetRecordSet("_acme-challenge.example.com.", "TXT")
+	require.NotNil(t, rrset)
+	var got []string
+	for _, record := range rrset.Records {
+		got = append(got, zone.TXTValue(rrset.Name, record.Content))
+	}
+	require.ElementsMatch(t, []string{values["example.com"], values["*.example.com"]}, got)
+}
+
+func TestApplyChallengeWaitsForZoneLock(t *testing.T) {
+	patchZone, p := newPatchZone(t)
+	s := NewACMEHandler(patchZone, nil)
+
+	// Another writer of the zone, like DNS UPDATE, holds the lock
+	unlock := s.LockZone("Example.COM")
+	errs := make(chan error, 1)
+	go func() {
+		_, err := s.applyChallenge(context.Background(), &zones.Zone{ID: "example.com.", Name: "example.com."}, "_acme-challenge.example.com.", "LoqXcYV8q5ONbJQxbmR7SCTNo3tiAXDfowyjxAjEuX0", false)
+		errs <- err
+	}()
+	select {
+	case err := <-errs:
+		t.Fatalf("challenge was changed while the zone was locked: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	require.NoError(t, <-errs)
+	require.NotNil(t, p.zone.GetRecordSet("_acme-challenge.example.com.", "TXT"))
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+)
+
+// defaultAuditLimit is a number of events returned if limit is not set
+const defaultAuditLimit = 100
+
+type auditQuerier interface {
+	Query(f audit.Filter) ([]audit.Event, error)
+}
+
+type AuditHandler struct {
+	config      config.Config
+	errorWriter errorWriter
+	stats       stats.PrometheusStatsCollector
+	querier     auditQuerier
+}
+
+// NewAuditHandler returns new AuditHandler
+func NewAuditHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, querier auditQuerier) *AuditHandler {
+	return &AuditHandler{config: config, errorWriter: errorWriter, stats: stats, querier: querier}
+}
+
+// ListEvents returns audit events, newest first.
+// Events are filtered by zone, user, action and time range (RFC 3339) from query parameters.
+func (s *AuditHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	q := r.URL.Query()
+	f := audit.Filter{
+		Zone:   q.Get("zone"),
+		Actor:  q.Get("user"),
+		Action: q.Get("action"),
+		Limit:  defaultAuditLimit,
+	}
+
+	var err error
+	if from := q.Get("from"); from != "" {
+		if f.From, err = time.Parse(time.RFC3339, from); err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionAuditList, errors.BadRequest.Wrap(err, "bad 'from' query parameter"))
+			return
+		}
+	}
+	if to := q.Get("to"); to != "" {
+		if f.To, err = time.Parse(time.RFC3339, to); err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionAuditList, errors.BadRequest.Wrap(err, "bad 'to' query parameter"))
+			return
+		}
+	}
+	if limit := q.Get("limit"); limit != "" {
+		if f.Limit, err = strconv.Atoi(limit); err != nil || f.Limit < 0 {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionAuditList, errors.BadRequest.Newf("bad 'limit' query parameter: %s", limit))
+			return
+		}
+	}
+
+	events, err := s.querier.Query(f)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionAuditList, errors.Wrap(err, "querying audit log"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(events)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionAuditList, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/sirupsen/logrus"
+)
+
+type authCacheInvalidator interface {
+	InvalidateAuthCache(username string)
+}
+
+type AuthCacheHandler struct {
+	config      config.Config
+	stats       stats.PrometheusStatsCollector
+	logger      *logrus.Logger
+	invalidator authCacheInvalidator
+}
+
+// NewAuthCacheHandler returns new AuthCacheHandler
+func NewAuthCacheHandler(config config.Config, stats stats.PrometheusStatsCollector, logger *logrus.Logger, invalidator authCacheInvalidator) *AuthCacheHandler {
+	return &AuthCacheHandler{config: config, stats: stats, logger: logger, invalidator: invalidator}
+}
+
+// FlushAuthCache drops cached authorization decisions after ACL changes in LDAP.
+// Decisions are dropped for the user from 'uid' query parameter or for all users.
+func (s *AuthCacheHandler) FlushAuthCache(w http.ResponseWriter, r *http.Request) {
+	uid := r.FormValue("uid")
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	audit.FromContext(r.Context()).SetAction(log.ActionLDAPFlushCache)
+	s.invalidator.InvalidateAuthCache(uid)
+
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionLDAPFlushCache,
+		"uid":    uid,
+	}).Info("Authorization cache was flushed")
+
+	w.WriteHeader(http.StatusNoContent)
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusNoContent)
+}
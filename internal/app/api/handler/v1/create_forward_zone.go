@@ -17,7 +17,7 @@ limitations under the License.
 package v1
 
 import (
-	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -25,6 +25,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
@@ -56,18 +57,21 @@ func (s *AddForwardZonesHandler) AddForwardZones(w http.ResponseWriter, r *http.
 	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
 	defer timer.ObserveDuration()
 
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionForwardZoneAdd)
+
 	var bodyBytes []byte
-	var data bytes.Buffer
 	if r.Body != nil {
 		bodyBytes, _ = ioutil.ReadAll(r.Body)
-		data.Write(bodyBytes)
 	}
 
-	fzsInput, err := forwardzone.ParseForwardZonesInput(&data)
-	if err != nil {
-		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneAdd, err)
+	// Input is validated by ValidationMiddleware
+	var fzsInput forwardzone.ForwardZones
+	if err := json.Unmarshal(bodyBytes, &fzsInput); err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneAdd, errors.BadRequest.Wrap(err, "decoding forward zones"))
 		return
 	}
+	event.AddForwardZones(fzsInput...)
 
 	file, err := os.OpenFile(forwardzone.ForwardZonesFile, os.O_RDWR|os.O_APPEND, 0644)
 	if err != nil {
@@ -95,14 +99,16 @@ func (s *AddForwardZonesHandler) AddForwardZones(w http.ResponseWriter, r *http.
 	if viper.GetBool("ldap.enabled") {
 		for _, inputFZ := range fzsInput {
 			// Create forward-zone in LDAP
-			if err := s.ldapZoneAdder.LDAPAddZone(forwardzone.ZoneTypeForwardZone, inputFZ.Name); err != nil {
+			if err := s.ldapZoneAdder.LDAPAddZone(r.Context(), forwardzone.ZoneTypeForwardZone, inputFZ.Name); err != nil {
 				s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneAdd, err)
 				return
 			}
 		}
 	}
 
-	if err := s.internalClient.AddZone(serverID, zoneType, bodyBytes); err != nil {
+	nodes, err := s.internalClient.AddZone(r.Context(), serverID, zoneType, bodyBytes)
+	event.AddNodes(nodes)
+	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneAdd, err)
 		return
 	}
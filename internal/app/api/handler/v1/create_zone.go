@@ -31,30 +31,53 @@ import (
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
 	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/requestid"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"golang.org/x/net/context"
 )
 
+type zoneTemplates interface {
+	Get(name string) (*config.ZoneTemplate, error)
+}
+
+// ldapZoneManager adds LDAP entries of new zones and deletes them if creation fails
+type ldapZoneManager interface {
+	ldap.LDAPZoneAdder
+	ldap.LDAPZoneDeleter
+}
+
+type metadataSetter interface {
+	tsigKeyLister
+	SetMetadata(ctx context.Context, serverID, zoneID, kind string, values []string) error
+}
+
 type AddZone struct {
-	config        config.Config
-	ldapZoneAdder ldap.LDAPZoneAdder
-	errorWriter   errorWriter
-	stats         stats.PrometheusStatsCollector
-	logger        *logrus.Logger
-	auth          pdnsApi.Client
+	config      config.Config
+	ldapZones   ldapZoneManager
+	errorWriter errorWriter
+	stats       stats.PrometheusStatsCollector
+	logger      *logrus.Logger
+	auth        pdnsApi.Client
+	templates   zoneTemplates
+	metadata    metadataSetter
 }
 
-func NewAddZone(config config.Config, ldapZoneAdder ldap.LDAPZoneAdder, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, auth pdnsApi.Client) *AddZone {
-	return &AddZone{config: config, ldapZoneAdder: ldapZoneAdder, errorWriter: errorWriter, stats: stats, logger: logger, auth: auth}
+func NewAddZone(config config.Config, ldapZones ldapZoneManager, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, auth pdnsApi.Client, templates zoneTemplates, metadata metadataSetter) *AddZone {
+	return &AddZone{config: config, ldapZones: ldapZones, errorWriter: errorWriter, stats: stats, logger: logger, auth: auth, templates: templates, metadata: metadata}
 }
 
 // AddZone creates a new domain, returns the Zone on creation.
+// With template query parameter the zone is created from the zone template, fields of the input zone override it.
+// Slave zones are created with masters, TSIG keys of the zone are set as metadata.
+// If the zone can't be fully configured, it is deleted, so the request can be retried.
 func (s *AddZone) AddZone(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	serverID := vars["serverID"]
@@ -69,47 +92,98 @@ func (s *AddZone) AddZone(w http.ResponseWriter, r *http.Request) {
 
 	decoder := json.NewDecoder(ioutil.NopCloser(bytes.NewReader(bodyBytes)))
 
-	var input zones.Zone
-	err := decoder.Decode(&input)
+	var newZone zone.NewZone
+	err := decoder.Decode(&newZone)
 	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, errors.BadRequest.Wrap(err, "decoding input zone"))
 		return
 	}
 
-	// Create zone from LDAP
-	if viper.GetBool("ldap.enabled") {
-		if err := s.ldapZoneAdder.LDAPAddZone(forwardzone.ZoneTypeZone, input.Name); err != nil {
+	metadata := make(map[string][]string)
+	if name := r.URL.Query().Get("template"); name != "" {
+		tmpl, err := s.templates.Get(name)
+		if err != nil {
 			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, err)
 			return
 		}
+		newZone.Zone, err = zone.ApplyTemplate(tmpl, newZone.Zone)
+		if err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, errors.Wrapf(err, "applying zone template %s", name))
+			return
+		}
+		metadata = zone.TemplateMetadata(tmpl)
+	}
+	if newZone.Kind == 0 {
+		newZone.Kind = zones.ZoneKindNative
+	}
+	err = newZone.Validate()
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, err)
+		return
+	}
+	input := newZone.Zone
+	tsigKeys := newZone.TSIGKeys()
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionZoneAdd)
+	event.SetZone(serverID, input.Name)
+	for _, rrset := range input.ResourceRecordSets {
+		event.AddChange(nil, rrset)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
 	defer cancel()
 
+	err = checkTSIGKeys(ctx, s.metadata, serverID, input.Name, tsigKeys)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, err)
+		return
+	}
+	for kind, values := range tsigKeys.TSIGMetadata() {
+		metadata[kind] = values
+	}
+
+	// Create zone from LDAP
+	if viper.GetBool("ldap.enabled") {
+		if err := s.ldapZones.LDAPAddZone(r.Context(), forwardzone.ZoneTypeZone, input.Name); err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, err)
+			return
+		}
+	}
+
 	createdZone, err := s.auth.Zones().CreateZone(ctx, serverID, input)
 	if err != nil {
-		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, errors.Wrapf(err, "creating zone %s", input.Name))
+		s.rollback(r, serverID, "", input.Name)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, errors.WrapPDNS(err, "creating zone %s", input.Name))
 		return
 	}
+	for kind, values := range metadata {
+		err = s.metadata.SetMetadata(ctx, serverID, createdZone.ID, kind, values)
+		if err != nil {
+			s.rollback(r, serverID, createdZone.ID, input.Name)
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, errors.WrapPDNS(err, "setting metadata %s of zone %s", kind, input.Name))
+			return
+		}
+	}
 
 	// Add zone to forwarder
 	var fz = forwardzone.ForwardZone{
 		Name:        input.Name,
 		Nameservers: []string{zone.LocalNameserver},
 	}
-	client := &http.Client{}
+	client := tracing.NewHTTPClient()
 	url := fmt.Sprintf("http://127.0.0.1:8080/api/v1/servers/%s/forward-zones", serverID)
 	b, err := json.Marshal(fz)
 	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, errors.Wrapf(err, "marshaling forward-zone %s", input.Name))
 		return
 	}
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	req, err := http.NewRequestWithContext(tracing.Detach(r.Context()), http.MethodPost, url, bytes.NewReader(b))
 	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, errors.Wrapf(err, "make a request for add forward-zone %s", input.Name))
 		return
 	}
+	req.Header.Set(requestid.Header, requestid.FromContext(r.Context()))
 	resp, err := client.Do(req)
 	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, errors.Wrapf(err, "do request for add forward-zone %s", input.Name))
@@ -124,9 +198,32 @@ func (s *AddZone) AddZone(w http.ResponseWriter, r *http.Request) {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneAdd, errors.Wrap(err, "encoding JSON response"))
 		return
 	}
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
 		"action": log.ActionZoneAdd,
 		"zone":   fz.Name,
 	}).Infof("Zone %s was created with nameservers %s", fz.Name, strings.Join(fz.Nameservers, ","))
 	s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusCreated)
 }
+
+// rollback deletes the zone from PowerDNS, if it was created, and its LDAP entry after a failed step of creation.
+// Otherwise retries of the request fail with conflict on a half-configured zone. Errors are only logged,
+// the error of the failed step is returned to the client.
+func (s *AddZone) rollback(r *http.Request, serverID, zoneID, name string) {
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	logger := s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionZoneAdd,
+		"zone":   name,
+	})
+	if zoneID != "" {
+		if err := s.auth.Zones().DeleteZone(ctx, serverID, zoneID); err != nil {
+			logger.Errorf("Cannot delete zone %s after failed creation: %v", name, err)
+		}
+	}
+	if viper.GetBool("ldap.enabled") {
+		if err := s.ldapZones.LDAPDelZone(ctx, forwardzone.ZoneTypeZone, name); err != nil {
+			logger.Errorf("Cannot delete zone %s from LDAP after failed creation: %v", name, err)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
@@ -55,6 +56,9 @@ func (s *DelForwardZoneHandler) DelForwardZone(w http.ResponseWriter, r *http.Re
 	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
 	defer timer.ObserveDuration()
 
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionForwardZoneDelete)
+
 	file, err := os.OpenFile(forwardzone.ForwardZonesFile, os.O_RDWR|os.O_APPEND, 0644)
 	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneDelete, errors.Wrap(err, "reading forward-zones-file"))
@@ -83,13 +87,15 @@ func (s *DelForwardZoneHandler) DelForwardZone(w http.ResponseWriter, r *http.Re
 	}
 
 	if viper.GetBool("ldap.enabled") {
-		if err := s.ldapZoneDeleter.LDAPDelZone(forwardzone.ZoneTypeForwardZone, zoneID); err != nil {
+		if err := s.ldapZoneDeleter.LDAPDelZone(r.Context(), forwardzone.ZoneTypeForwardZone, zoneID); err != nil {
 			s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneDelete, err)
 			return
 		}
 	}
 
-	if err := s.internalClient.DelZone(serverID, zoneType, zoneID); err != nil {
+	nodes, err := s.internalClient.DelZone(r.Context(), serverID, zoneType, zoneID)
+	event.AddNodes(nodes)
+	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneDelete, err)
 		return
 	}
@@ -17,7 +17,7 @@ limitations under the License.
 package v1
 
 import (
-	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -25,6 +25,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
@@ -56,18 +57,21 @@ func (s *DelForwardZonesHandler) DelForwardZones(w http.ResponseWriter, r *http.
 	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
 	defer timer.ObserveDuration()
 
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionForwardZoneDelete)
+
 	var bodyBytes []byte
-	var data bytes.Buffer
 	if r.Body != nil {
 		bodyBytes, _ = ioutil.ReadAll(r.Body)
-		data.Write(bodyBytes)
 	}
 
-	fzs, err := forwardzone.ParseForwardZonesInput(&data)
-	if err != nil {
-		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneDelete, errors.BadRequest.Wrap(err, "parsing forward-zones"))
+	// Input is validated by ValidationMiddleware
+	var fzs forwardzone.ForwardZones
+	if err := json.Unmarshal(bodyBytes, &fzs); err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneDelete, errors.BadRequest.Wrap(err, "decoding forward zones"))
 		return
 	}
+	event.AddForwardZones(fzs...)
 
 	file, err := os.OpenFile(forwardzone.ForwardZonesFile, os.O_RDWR|os.O_APPEND, 0644)
 	if err != nil {
@@ -101,14 +105,16 @@ func (s *DelForwardZonesHandler) DelForwardZones(w http.ResponseWriter, r *http.
 	if viper.GetBool("ldap.enabled") {
 		for _, inputFZ := range fzs {
 			// Delete forward-zone from LDAP
-			if err := s.ldapZoneDeleter.LDAPDelZone(forwardzone.ZoneTypeForwardZone, inputFZ.Name); err != nil {
+			if err := s.ldapZoneDeleter.LDAPDelZone(r.Context(), forwardzone.ZoneTypeForwardZone, inputFZ.Name); err != nil {
 				s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneDelete, err)
 				return
 			}
 		}
 	}
 
-	if err := s.internalClient.DelZones(serverID, zoneType, bodyBytes); err != nil {
+	nodes, err := s.internalClient.DelZones(r.Context(), serverID, zoneType, bodyBytes)
+	event.AddNodes(nodes)
+	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneDelete, err)
 		return
 	}
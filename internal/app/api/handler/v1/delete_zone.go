@@ -9,11 +9,14 @@ import (
 	pdnsApi "github.com/mittwald/go-powerdns"
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/requestid"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"golang.org/x/net/context"
@@ -37,6 +40,7 @@ func NewDeleteZone(config config.Config, ldapZoneDeleter ldap.LDAPZoneDeleter, e
 }
 
 // DeleteZone Deletes this zone, all attached metadata and rrsets.
+// If-Match header is compared with the zone ETag before deletion.
 func (s *DeleteZone) DeleteZone(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	serverID := vars["serverID"]
@@ -45,31 +49,39 @@ func (s *DeleteZone) DeleteZone(w http.ResponseWriter, r *http.Request) {
 	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
 	defer timer.ObserveDuration()
 
+	audit.FromContext(r.Context()).SetAction(log.ActionZoneDelete)
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	if err := checkZoneETag(ctx, r, s.auth, serverID, zoneID); err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneDelete, err)
+		return
+	}
+
 	// Delete zone from LDAP
 	if viper.GetBool("ldap.enabled") {
-		if err := s.ldapZoneDeleter.LDAPDelZone(forwardzone.ZoneTypeZone, zoneID); err != nil {
+		if err := s.ldapZoneDeleter.LDAPDelZone(r.Context(), forwardzone.ZoneTypeZone, zoneID); err != nil {
 			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneDelete, err)
 			return
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
-	defer cancel()
-
 	err := s.auth.Zones().DeleteZone(ctx, serverID, zoneID)
 	if err != nil {
-		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneDelete, errors.Wrapf(err, "deleting zone %s", zoneID))
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneDelete, errors.WrapPDNS(err, "deleting zone %s", zoneID))
 		return
 	}
 
 	// Delete zone from forward-zones-file
-	client := &http.Client{}
+	client := tracing.NewHTTPClient()
 	url := fmt.Sprintf("http://127.0.0.1:8080/api/v1/servers/%s/forward-zones/%s", serverID, zoneID)
-	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	req, err := http.NewRequestWithContext(tracing.Detach(r.Context()), http.MethodDelete, url, nil)
 	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneDelete, errors.Wrapf(err, "make a request for delete forward-zone %s", zoneID))
 		return
 	}
+	req.Header.Set(requestid.Header, requestid.FromContext(r.Context()))
 	resp, err := client.Do(req)
 	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneDelete, errors.Wrapf(err, "doing a request for delete forward-zone %s", zoneID))
@@ -81,7 +93,7 @@ func (s *DeleteZone) DeleteZone(w http.ResponseWriter, r *http.Request) {
 	s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusCreated)
 
 	w.WriteHeader(http.StatusNoContent)
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
 		"action": log.ActionZoneDelete,
 		"zone":   zoneID,
 	}).Infof("Zone %s was deleted", zoneID)
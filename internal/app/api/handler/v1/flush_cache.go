@@ -0,0 +1,78 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/sirupsen/logrus"
+)
+
+type FlushCacheHandler struct {
+	config         config.Config
+	errorWriter    errorWriter
+	stats          stats.PrometheusStatsCollector
+	logger         *logrus.Logger
+	internalClient internalClient
+}
+
+// NewFlushCacheHandler returns new FlushCacheHandler
+func NewFlushCacheHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, internalClient internalClient) *FlushCacheHandler {
+	return &FlushCacheHandler{config: config, errorWriter: errorWriter, stats: stats, logger: logger, internalClient: internalClient}
+}
+
+// FlushCache flushes a cache-entry by name on all nodes
+func (s *FlushCacheHandler) FlushCache(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	domain := r.FormValue("domain")
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionFlushCache)
+	event.SetZone(serverID, domain)
+
+	if domain == "" {
+		err := errors.BadRequest.New("not enough query parameters")
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, errors.AddFieldError(err, "domain", "is required"))
+		return
+	}
+
+	nodes, err := s.internalClient.FlushAllCache(r.Context(), serverID, network.Canonicalize(domain))
+	event.AddNodes(nodes)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, err)
+		return
+	}
+
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionFlushCache,
+		"domain": domain,
+	}).Infof("Cache for %s was flushed", domain)
+
+	w.WriteHeader(http.StatusNoContent)
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusNoContent)
+}
@@ -19,9 +19,12 @@ package v1
 import (
 	"bufio"
 	"encoding/json"
-	"fmt"
 
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/etag"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/listing"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 
 	"net/http"
@@ -36,24 +39,31 @@ import (
 
 type ForwardZonesHandler struct {
 	config         config.Config
+	errorWriter    errorWriter
 	stats          stats.PrometheusStatsCollector
 	powerDNSClient pdnsApi.Client
 	consulClient   *api.Client
 }
 
-func NewForwardZonesHandler(config config.Config, stats stats.PrometheusStatsCollector, powerDNSClient pdnsApi.Client) *ForwardZonesHandler {
-	return &ForwardZonesHandler{config: config, stats: stats, powerDNSClient: powerDNSClient}
+func NewForwardZonesHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, powerDNSClient pdnsApi.Client) *ForwardZonesHandler {
+	return &ForwardZonesHandler{config: config, errorWriter: errorWriter, stats: stats, powerDNSClient: powerDNSClient}
 }
 
-// ListForwardZones returns forwarding zones list
+// ListForwardZones returns forwarding zones list.
+// Forward zones are filtered, sorted and paginated by query parameters, see listing.ParseParams.
 func (s *ForwardZonesHandler) ListForwardZones(w http.ResponseWriter, r *http.Request) {
 	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
 	defer timer.ObserveDuration()
 
+	params, err := listing.ParseParams(r.URL.Query(), listing.FieldName)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZonesList, err)
+		return
+	}
+
 	file, err := os.Open(forwardzone.ForwardZonesFile)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("reading forward-zones-file: %v", err), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZonesList, errors.Wrap(err, "reading forward-zones-file"))
 		return
 	}
 	defer file.Close()
@@ -69,11 +79,26 @@ func (s *ForwardZonesHandler) ListForwardZones(w http.ResponseWriter, r *http.Re
 		}
 	}
 
+	items := make([]listing.Item, 0, len(fzs))
+	for _, fz := range fzs {
+		items = append(items, listing.Item{Name: fz.Name})
+	}
+	page, err := params.Apply(items)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZonesList, err)
+		return
+	}
+	result := make(forwardzone.ForwardZones, 0, len(page.Indexes))
+	for _, i := range page.Indexes {
+		result = append(result, fzs[i])
+	}
+
+	page.SetHeaders(w, r)
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(fzs)
+	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("encoding forward-zones: %v", err), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZonesList, errors.Wrap(err, "encoding forward-zones"))
 		return
 	}
 	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
@@ -89,8 +114,7 @@ func (s *ForwardZonesHandler) ListForwardZone(w http.ResponseWriter, r *http.Req
 
 	file, err := os.Open(forwardzone.ForwardZonesFile)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("reading forward-zones-file: %v", err), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneList, errors.Wrap(err, "reading forward-zones-file"))
 		return
 	}
 	defer file.Close()
@@ -100,19 +124,24 @@ func (s *ForwardZonesHandler) ListForwardZone(w http.ResponseWriter, r *http.Req
 	for scanner.Scan() {
 		scnr := scanner.Text()
 		fz, _ := forwardzone.ParseForwardZoneLine(scnr)
-		if fz.Name == zoneID {
+		if fz != nil && fz.Name == zoneID {
+			tag, err := etag.Compute(fz)
+			if err != nil {
+				s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneList, err)
+				return
+			}
 			// 200 OK
+			w.Header().Set(etag.Header, tag)
+			w.Header().Set("Content-Type", "application/json;charset=utf-8")
 			w.WriteHeader(http.StatusOK)
-			err := json.NewEncoder(w).Encode(fz)
+			err = json.NewEncoder(w).Encode(fz)
 			if err != nil {
-				http.Error(w, fmt.Sprintf("encoding forward-zones: %v", err), http.StatusInternalServerError)
-				s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+				s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneList, errors.Wrap(err, "encoding forward-zones"))
 				return
 			}
 			s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
 			return
 		}
 	}
-	w.WriteHeader(http.StatusNotFound)
-	s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusNotFound)
+	s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneList, errors.NotFound.Newf("forward zone %s not found", zoneID))
 }
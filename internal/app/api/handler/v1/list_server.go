@@ -18,13 +18,14 @@ package v1
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/mux"
 	pdns "github.com/mittwald/go-powerdns"
 	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
 	"golang.org/x/net/context"
@@ -36,8 +37,8 @@ type ListServerHandler struct {
 	powerDNSClient pdns.Client
 }
 
-func NewListServerHandler(config config.Config, stats stats.PrometheusStatsCollector, powerDNSClient pdns.Client) *ListServersHandler {
-	return &ListServersHandler{config: config, stats: stats, powerDNSClient: powerDNSClient}
+func NewListServerHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, powerDNSClient pdns.Client) *ListServersHandler {
+	return &ListServersHandler{config: config, errorWriter: errorWriter, stats: stats, powerDNSClient: powerDNSClient}
 }
 
 // ListServer list all servers
@@ -53,16 +54,14 @@ func (s *ListServersHandler) ListServer(w http.ResponseWriter, r *http.Request)
 
 	server, err := s.powerDNSClient.Servers().GetServer(ctx, serverID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to get all servers list: %v", err), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionServerList, errors.WrapPDNS(err, "failed to get server %s", serverID))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(w).Encode(server)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionServerList, errors.Wrap(err, "encoding JSON response"))
 		return
 	}
 	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
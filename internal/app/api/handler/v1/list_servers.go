@@ -55,7 +55,7 @@ func (s *ListServersHandler) ListServers(w http.ResponseWriter, r *http.Request)
 
 	servers, err := s.powerDNSClient.Servers().ListServers(ctx)
 	if err != nil {
-		s.errorWriter.WriteError(w, r.URL.Path, log.ActionServersList, errors.Wrap(err, "failed to get all servers list"))
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionServersList, errors.WrapPDNS(err, "failed to get all servers list"))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
@@ -65,6 +65,6 @@ func (s *ListServersHandler) ListServers(w http.ResponseWriter, r *http.Request)
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionServersList, errors.Wrap(err, "encoding json answer"))
 		return
 	}
-	s.logger.Debug("list all servers")
+	s.logger.WithContext(r.Context()).Debug("list all servers")
 	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
 }
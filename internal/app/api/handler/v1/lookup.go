@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/dnsclient"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// lookupBoth queries both authoritative server and recursor
+const lookupBoth = "both"
+
+// Lookup is a result of DNS query to local PowerDNS servers
+type Lookup struct {
+	Name    string         `json:"name"`
+	Type    string         `json:"type"`
+	Results []LookupResult `json:"results"`
+}
+
+// LookupResult is an answer of a single server
+type LookupResult struct {
+	// Server is auth or recursor
+	Server string            `json:"server"`
+	Error  string            `json:"error,omitempty"`
+	Answer *dnsclient.Answer `json:"answer,omitempty"`
+}
+
+// LookupHandler runs DNS queries like dig for clients without access to DNS ports
+type LookupHandler struct {
+	config      config.Config
+	errorWriter errorWriter
+	stats       stats.PrometheusStatsCollector
+	logger      *logrus.Logger
+}
+
+func NewLookupHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger) *LookupHandler {
+	return &LookupHandler{config: config, errorWriter: errorWriter, stats: stats, logger: logger}
+}
+
+// Lookup queries local PowerDNS Authoritative without recursion and Recursor with recursion
+// for 'name' and 'type' (A by default) over DNS. 'server' is auth, recursor or both (default).
+func (s *LookupHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	query := r.URL.Query()
+	name := query.Get("name")
+	rrType := strings.ToUpper(query.Get("type"))
+	if rrType == "" {
+		rrType = "A"
+	}
+	server := query.Get("server")
+	if server == "" {
+		server = lookupBoth
+	}
+
+	err := errors.BadRequest.New("invalid lookup parameters")
+	var invalid bool
+	if _, ok := dns.IsDomainName(name); name == "" || !ok {
+		err, invalid = errors.AddFieldError(err, "name", "must be a domain name"), true
+	}
+	qtype, ok := dns.StringToType[rrType]
+	if !ok {
+		err, invalid = errors.AddFieldError(err, "type", "must be a record type"), true
+	}
+	if server != zone.ServerAuth && server != zone.ServerRecursor && server != lookupBoth {
+		err, invalid = errors.AddFieldError(err, "server", "must be auth, recursor or both"), true
+	}
+	if invalid {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionLookup, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.config.PDNS.RecursorConfig.Timeout)*time.Second)
+	defer cancel()
+
+	result := Lookup{Name: dns.Fqdn(name), Type: rrType, Results: []LookupResult{}}
+	if server == zone.ServerAuth || server == lookupBoth {
+		result.Results = append(result.Results, s.query(ctx, zone.ServerAuth, s.config.PDNS.AuthConfig.DNSAddress, result.Name, qtype, false))
+	}
+	if server == zone.ServerRecursor || server == lookupBoth {
+		result.Results = append(result.Results, s.query(ctx, zone.ServerRecursor, s.config.PDNS.RecursorConfig.DNSAddress, result.Name, qtype, true))
+	}
+
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionLookup,
+	}).Debugf("Lookup %s %s on %s", result.Name, rrType, server)
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionLookup, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
+
+// query returns the answer of the server or the error of the query
+func (s *LookupHandler) query(ctx context.Context, server, addr, name string, qtype uint16, recursion bool) LookupResult {
+	m, rtt, err := dnsclient.Query(ctx, addr, name, qtype, recursion)
+	if err != nil {
+		return LookupResult{Server: server, Error: err.Error()}
+	}
+	return LookupResult{Server: server, Answer: dnsclient.NewAnswer(addr, m, rtt)}
+}
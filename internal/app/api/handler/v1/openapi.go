@@ -0,0 +1,59 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+)
+
+// OpenAPIHandler serves OpenAPI specification of the public API
+type OpenAPIHandler struct {
+	config      config.Config
+	errorWriter errorWriter
+	stats       stats.PrometheusStatsCollector
+	doc         *openapi3.T
+}
+
+// NewOpenAPIHandler returns new OpenAPIHandler
+func NewOpenAPIHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, doc *openapi3.T) *OpenAPIHandler {
+	return &OpenAPIHandler{config: config, errorWriter: errorWriter, stats: stats, doc: doc}
+}
+
+// Get returns OpenAPI specification as JSON
+func (s *OpenAPIHandler) Get(w http.ResponseWriter, r *http.Request) {
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	b, err := json.Marshal(s.doc)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionOpenAPI, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
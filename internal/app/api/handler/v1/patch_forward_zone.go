@@ -17,11 +17,17 @@ limitations under the License.
 package v1
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"os"
 
 	"github.com/gorilla/mux"
 	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/etag"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
@@ -53,7 +59,23 @@ func (s *PatchForwardZoneHandler) PatchForwardZone(w http.ResponseWriter, r *htt
 		bodyBytes, _ = ioutil.ReadAll(r.Body)
 	}
 
-	if err := s.internalClient.PatchZone(serverID, zoneType, zoneID, bodyBytes); err != nil {
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionForwardZoneUpdate)
+	var fz forwardzone.ForwardZone
+	if err := json.Unmarshal(bodyBytes, &fz); err == nil {
+		event.AddForwardZones(fz)
+	}
+
+	// If-Match is checked once against forward-zones-file of this node, which ETag of public API is computed of,
+	// so nodes with already applied changes don't fail a retry of a partially applied request
+	if err := checkForwardZoneETag(r, zoneID); err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneUpdate, err)
+		return
+	}
+
+	nodes, err := s.internalClient.PatchZone(r.Context(), serverID, zoneType, zoneID, bodyBytes)
+	event.AddNodes(nodes)
+	if err != nil {
 		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneUpdate, err)
 		return
 	}
@@ -61,3 +83,32 @@ func (s *PatchForwardZoneHandler) PatchForwardZone(w http.ResponseWriter, r *htt
 	w.WriteHeader(http.StatusNoContent)
 	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusNoContent)
 }
+
+// checkForwardZoneETag compares If-Match header with ETag of the forward zone as ListForwardZone returns it
+func checkForwardZoneETag(r *http.Request, zoneID string) error {
+	if !etag.Requested(r) {
+		return nil
+	}
+
+	file, err := os.Open(forwardzone.ForwardZonesFile)
+	if err != nil {
+		return errors.Wrap(err, "reading forward-zones-file")
+	}
+	defer file.Close()
+
+	fzs, err := forwardzone.ParseForwardZoneFile(file)
+	if err != nil {
+		return errors.Wrap(err, "parsing forward-zones-file")
+	}
+	var current string
+	for _, fz := range fzs {
+		if network.Canonicalize(zoneID) == network.Canonicalize(fz.Name) {
+			current, err = etag.Compute(fz)
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return etag.Check(r, current)
+}
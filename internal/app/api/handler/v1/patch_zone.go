@@ -2,27 +2,36 @@ package v1
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	pdnsApi "github.com/mittwald/go-powerdns"
 	"github.com/mittwald/go-powerdns/apis/zones"
 	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/client"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/keylock"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
 
 type internalClient interface {
-	FlushAllCache(serverID, name string) error
-	AddZone(serverID, zoneType string, bodyBytes []byte) error
-	DelZones(serverID, zoneType string, bodyBytes []byte) error
-	DelZone(serverID, zoneType, zoneID string) error
-	PatchZone(serverID, zoneType, zoneID string, bodyBytes []byte) error
+	FlushAllCache(ctx context.Context, serverID, name string) ([]client.NodeResult, error)
+	AddZone(ctx context.Context, serverID, zoneType string, bodyBytes []byte) ([]client.NodeResult, error)
+	DelZones(ctx context.Context, serverID, zoneType string, bodyBytes []byte) ([]client.NodeResult, error)
+	DelZone(ctx context.Context, serverID, zoneType, zoneID string) ([]client.NodeResult, error)
+	PatchZone(ctx context.Context, serverID, zoneType, zoneID string, bodyBytes []byte) ([]client.NodeResult, error)
+	ZoneState(ctx context.Context, serverID, zoneID string, rrsets bool) ([]client.NodeResult, error)
+	VerifyZone(ctx context.Context, serverID, zoneID string, bodyBytes []byte) ([]client.NodeResult, error)
 }
 
 type ptrrecorder interface {
@@ -30,26 +39,45 @@ type ptrrecorder interface {
 	DelPTR(ctx context.Context, serverID string, zoneID string, rrset zones.ResourceRecordSet) error
 }
 
+type rrsetPatcher interface {
+	PatchRRSets(ctx context.Context, serverID, zoneID string, rrsets []zones.ResourceRecordSet) error
+}
+
 type PatchZone struct {
 	config         config.Config
 	errorWriter    errorWriter
 	stats          stats.PrometheusStatsCollector
 	logger         *logrus.Logger
 	auth           pdnsApi.Client
+	rrsetPatcher   rrsetPatcher
 	ptrrecorder    ptrrecorder
 	internalClient internalClient
+	// zoneLocks serializes changes of RRsets of the same zone on this node by all writers:
+	// zone PATCH, RRset PUT and DELETE, ACME challenges and DNS UPDATE
+	zoneLocks *keylock.Locker
+}
+
+func NewPatchZone(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, auth pdnsApi.Client, rrsetPatcher rrsetPatcher, ptrrecorder ptrrecorder, internalClient internalClient) *PatchZone {
+	return &PatchZone{config: config, errorWriter: errorWriter, stats: stats, logger: logger, auth: auth, rrsetPatcher: rrsetPatcher, ptrrecorder: ptrrecorder, internalClient: internalClient, zoneLocks: keylock.New()}
 }
 
-func NewPatchZone(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, auth pdnsApi.Client, ptrrecorder ptrrecorder, internalClient internalClient) *PatchZone {
-	return &PatchZone{config: config, errorWriter: errorWriter, stats: stats, logger: logger, auth: auth, ptrrecorder: ptrrecorder, internalClient: internalClient}
+// LockZone locks changes of RRsets of the zone and returns the function unlocking it.
+// The lock is held from reading the state the changes depend on until they are applied,
+// it is not reentrant, so patchRRSets and UpdateRRSets don't take it.
+func (s *PatchZone) LockZone(zoneID string) (unlock func()) {
+	return s.zoneLocks.Lock(strings.ToLower(network.Canonicalize(zoneID)))
 }
 
-// PatchZone Deletes this zone, all attached metadata and rrsets.
+// PatchZone Creates/modifies/deletes RRsets present in the payload.
+// If-Match header is compared with the zone ETag before the changes.
 func (s *PatchZone) PatchZone(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	serverID := vars["serverID"]
 	zoneID := vars["zoneID"]
 
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionZoneUpdate)
+
 	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
 	defer timer.ObserveDuration()
 
@@ -61,19 +89,97 @@ func (s *PatchZone) PatchZone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
 	defer cancel()
 
-	for _, rrset := range z.ResourceRecordSets {
+	err = s.patchZoneIfMatch(ctx, r, serverID, zoneID, z.ResourceRecordSets)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneUpdate, err)
+		return
+	}
+	err = s.flushRRSets(r.Context(), serverID, z.ResourceRecordSets)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionZoneUpdate,
+		"zone":   zoneID,
+	}).Infof("Zone %s was updated", zoneID)
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusNoContent)
+}
+
+// UpdateRRSets applies RRset changes to the zone like PatchZone and flushes cache of their names on all nodes.
+// It is used by DNS UPDATE listener, changes are added to the audit event from ctx.
+// The caller must hold LockZone of the zone.
+func (s *PatchZone) UpdateRRSets(ctx context.Context, serverID, zoneID string, rrsets []zones.ResourceRecordSet) error {
+	patchCtx, cancel := context.WithTimeout(tracing.Detach(ctx), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	err := s.patchRRSets(patchCtx, serverID, zoneID, rrsets, zoneRRSetField)
+	if err != nil {
+		return err
+	}
+	return s.flushRRSets(ctx, serverID, rrsets)
+}
+
+// patchZoneIfMatch checks If-Match header with the zone ETag and applies the changes.
+// PowerDNS has no conditional update, so the zone is locked from the check until the changes are applied.
+func (s *PatchZone) patchZoneIfMatch(ctx context.Context, r *http.Request, serverID, zoneID string, rrsets []zones.ResourceRecordSet) error {
+	unlock := s.LockZone(zoneID)
+	defer unlock()
+
+	err := checkZoneETag(ctx, r, s.auth, serverID, zoneID)
+	if err != nil {
+		return err
+	}
+	return s.patchRRSets(ctx, serverID, zoneID, rrsets, zoneRRSetField)
+}
+
+// zoneRRSetField returns a prefix of field names for the i-th RRset of the zone in validation errors
+func zoneRRSetField(i int) string {
+	return fmt.Sprintf("rrsets.%d.", i)
+}
+
+// patchRRSets validates RRsets, applies the changes to the zone, updates PTR records and adds changes to the audit event.
+// field returns a prefix of field names for the i-th RRset in validation errors.
+func (s *PatchZone) patchRRSets(ctx context.Context, serverID, zoneID string, rrsets []zones.ResourceRecordSet, field func(i int) string) error {
+	event := audit.FromContext(ctx)
+
+	// Current state of the zone for validation and the audit diff
+	before, err := s.auth.Zones().GetZone(ctx, serverID, zoneID)
+	if err != nil {
+		return errors.WrapPDNS(err, "getting zone %s", zoneID)
+	}
+	err = zone.ValidateRRSets(before, rrsets, field)
+	if err != nil {
+		return err
+	}
+
+	changes := make([]zones.ResourceRecordSet, 0, len(rrsets))
+	for _, rrset := range rrsets {
+		switch rrset.ChangeType {
+		case zones.ChangeTypeReplace, zones.ChangeTypeDelete:
+			changes = append(changes, rrset)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	// All RRsets are sent in one PATCH, so PowerDNS applies all of them or none
+	err = s.rrsetPatcher.PatchRRSets(ctx, serverID, zoneID, changes)
+	if err != nil {
+		return errors.WrapPDNS(err, "updating zone %s", zoneID)
+	}
+
+	for _, rrset := range changes {
+		event.AddChange(before.GetRecordSet(rrset.Name, rrset.Type), rrset)
 		switch rrset.ChangeType {
 		case zones.ChangeTypeReplace:
-			err = s.auth.Zones().AddRecordSetToZone(ctx, serverID, zoneID, rrset)
-			if err != nil {
-				s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneUpdate, errors.Wrapf(err, "updating zone %s", zoneID))
-				return
-			}
 			for _, record := range rrset.Records {
-				s.logger.WithFields(logrus.Fields{
+				s.logger.WithContext(ctx).WithFields(logrus.Fields{
 					"action": log.ActionZoneUpdate,
 					"zone":   zoneID,
 					"rr":     rrset.Name,
@@ -82,18 +188,12 @@ func (s *PatchZone) PatchZone(w http.ResponseWriter, r *http.Request) {
 					// Add new PTR
 					err = s.ptrrecorder.AddPTR(ctx, serverID, zoneID, rrset)
 					if err != nil {
-						s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneUpdate, errors.Wrap(err, "updating revers zone"))
-						return
+						return errors.Wrap(err, "updating revers zone")
 					}
 				}
 			}
 		case zones.ChangeTypeDelete:
-			err = s.auth.Zones().RemoveRecordSetFromZone(ctx, serverID, zoneID, rrset.Name, rrset.Type)
-			if err != nil {
-				s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneUpdate, errors.Wrapf(err, "deleting RR %s from zone %s", rrset.Name, zoneID))
-				return
-			}
-			s.logger.WithFields(logrus.Fields{
+			s.logger.WithContext(ctx).WithFields(logrus.Fields{
 				"action": log.ActionZoneUpdate,
 				"zone":   zoneID,
 				"rr":     rrset.Name,
@@ -101,25 +201,22 @@ func (s *PatchZone) PatchZone(w http.ResponseWriter, r *http.Request) {
 			// Delete PTR
 			err = s.ptrrecorder.DelPTR(ctx, serverID, zoneID, rrset)
 			if err != nil {
-				s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneUpdate, errors.Wrapf(err, "deleting PTR %s from zone %s", rrset.Name, zoneID))
-				return
+				return errors.Wrapf(err, "deleting PTR %s from zone %s", rrset.Name, zoneID)
 			}
-		default:
-			continue
 		}
 	}
-	// Flush cache
-	for _, rr := range z.ResourceRecordSets {
-		if err := s.internalClient.FlushAllCache(serverID, rr.Name); err != nil {
-			s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, err)
-			return
+	return nil
+}
+
+// flushRRSets flushes cache for RRsets names on all nodes
+func (s *PatchZone) flushRRSets(ctx context.Context, serverID string, rrsets []zones.ResourceRecordSet) error {
+	event := audit.FromContext(ctx)
+	for _, rr := range rrsets {
+		nodes, err := s.internalClient.FlushAllCache(ctx, serverID, rr.Name)
+		event.AddNodes(nodes)
+		if err != nil {
+			return err
 		}
 	}
-
-	w.WriteHeader(http.StatusNoContent)
-	s.logger.WithFields(logrus.Fields{
-		"action": log.ActionZoneDelete,
-		"zone":   zoneID,
-	}).Infof("Zone %s was deleted", zoneID)
-	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusNoContent)
+	return nil
 }
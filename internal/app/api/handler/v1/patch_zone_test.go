@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	pdnsApi "github.com/mittwald/go-powerdns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/client"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/etag"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePowerDNS serves the zone example.com. and applies PATCH of its RRsets
+type fakePowerDNS struct {
+	mu   sync.Mutex
+	zone zones.Zone
+}
+
+func (p *fakePowerDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/servers/localhost/zones/example.com.") {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"Not Found"}`))
+		return
+	}
+	p.mu.Lock()
+	z := p.zone
+	p.mu.Unlock()
+	// Widen the window between reading and changing the RRset
+	time.Sleep(10 * time.Millisecond)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(z)
+}
+
+func (p *fakePowerDNS) PatchRRSets(ctx context.Context, serverID, zoneID string, rrsets []zones.ResourceRecordSet) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, change := range rrsets {
+		kept := make([]zones.ResourceRecordSet, 0, len(p.zone.ResourceRecordSets)+1)
+		for _, rrset := range p.zone.ResourceRecordSets {
+			if rrset.Name != change.Name || rrset.Type != change.Type {
+				kept = append(kept, rrset)
+			}
+		}
+		if change.ChangeType == zones.ChangeTypeReplace {
+			kept = append(kept, change)
+		}
+		p.zone.ResourceRecordSets = kept
+	}
+	return nil
+}
+
+func (p *fakePowerDNS) AddPTR(ctx context.Context, serverID string, zoneID string, rrset zones.ResourceRecordSet) error {
+	return nil
+}
+
+func (p *fakePowerDNS) DelPTR(ctx context.Context, serverID string, zoneID string, rrset zones.ResourceRecordSet) error {
+	return nil
+}
+
+type responseErrorWriter struct{}
+
+func (responseErrorWriter) WriteError(w http.ResponseWriter, urlPath string, action string, err error) {
+	network.WriteErrorResponse(w, err)
+}
+
+type fakeStats struct{}
+
+func (fakeStats) CountCall(env, node, path, method string, status int) {}
+
+func (fakeStats) CountError(env, node, path string, status int) {}
+
+func (fakeStats) GetLabeledResponseTimePeersHistogramTimer(env, node, path, method string) *prometheus.Timer {
+	return prometheus.NewTimer(prometheus.ObserverFunc(func(float64) {}))
+}
+
+// fakeInternalClient flushes cache on no nodes, other internal requests are not used
+type fakeInternalClient struct {
+	internalClient
+}
+
+func (fakeInternalClient) FlushAllCache(ctx context.Context, serverID, name string) ([]client.NodeResult, error) {
+	return nil, nil
+}
+
+// newPatchZone returns PatchZone changing the zone example.com. of fake PowerDNS
+func newPatchZone(t *testing.T) (*PatchZone, *fakePowerDNS) {
+	p := &fakePowerDNS{zone: zones.Zone{ID: "example.com.", Name: "example.com.", Kind: zones.ZoneKindNative}}
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	auth, err := pdnsApi.New(pdnsApi.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	var cfg config.Config
+	cfg.PDNS.AuthConfig.Timeout = 5
+	return NewPatchZone(cfg, responseErrorWriter{}, fakeStats{}, logrus.New(), auth, p, p, fakeInternalClient{}), p
+}
+
+func TestPatchZoneIfMatchConcurrently(t *testing.T) {
+	s, _ := newPatchZone(t)
+	current, err := s.auth.Zones().GetZone(context.Background(), "localhost", "example.com.")
+	require.NoError(t, err)
+	tag, err := etag.Compute(current)
+	require.NoError(t, err)
+
+	// Both writers have read the same state of the zone
+	statuses := make(chan int, 2)
+	for _, content := range []string{"192.0.2.10", "192.0.2.20"} {
+		body, err := json.Marshal(zones.Zone{ResourceRecordSets: []zones.ResourceRecordSet{{
+			Name: "host.example.com.", Type: "A", TTL: 300, ChangeType: zones.ChangeTypeReplace,
+			Records: []zones.Record{{Content: content}},
+		}}})
+		require.NoError(t, err)
+		r := httptest.NewRequest(http.MethodPatch, "/api/v1/servers/localhost/zones/example.com.", bytes.NewReader(body))
+		r.Header.Set(etag.IfMatchHeader, tag)
+		r = mux.SetURLVars(r, map[string]string{"serverID": "localhost", "zoneID": "example.com."})
+		go func() {
+			w := httptest.NewRecorder()
+			s.PatchZone(w, r)
+			statuses <- w.Code
+		}()
+	}
+
+	got := []int{<-statuses, <-statuses}
+	require.ElementsMatch(t, []int{http.StatusNoContent, http.StatusPreconditionFailed}, got)
+}
@@ -0,0 +1,238 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/etag"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// RRSetHandler manages a single RRset of a zone.
+// It shares PTR handling and cache flushing with PatchZone.
+// Responses have ETag of the RRset, If-Match header is checked before changes.
+type RRSetHandler struct {
+	*PatchZone
+}
+
+func NewRRSetHandler(patchZone *PatchZone) *RRSetHandler {
+	return &RRSetHandler{PatchZone: patchZone}
+}
+
+// GetRRSet returns RRset by name and type
+func (s *RRSetHandler) GetRRSet(w http.ResponseWriter, r *http.Request) {
+	serverID, zoneID, name, rrType, err := rrsetVars(r)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetList, err)
+		return
+	}
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	rrset, err := s.getRRSet(ctx, serverID, zoneID, name, rrType)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetList, err)
+		return
+	}
+	s.writeRRSet(w, r, http.StatusOK, rrset)
+}
+
+// PutRRSet replaces RRset by name and type with records from the payload
+func (s *RRSetHandler) PutRRSet(w http.ResponseWriter, r *http.Request) {
+	serverID, zoneID, name, rrType, err := rrsetVars(r)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetUpdate, err)
+		return
+	}
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionRRSetUpdate)
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	var rrset zones.ResourceRecordSet
+	err = json.NewDecoder(r.Body).Decode(&rrset)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetUpdate, errors.BadRequest.Wrap(err, "decoding input RRset"))
+		return
+	}
+	if len(rrset.Records) == 0 {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetUpdate, errors.AddFieldError(
+			errors.BadRequest.New("RRset has no records"),
+			"records", "must not be empty, use DELETE to remove RRset",
+		))
+		return
+	}
+	// Name and type are taken from the path only
+	rrset.Name = name
+	rrset.Type = rrType
+	rrset.ChangeType = zones.ChangeTypeReplace
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	rrsets := []zones.ResourceRecordSet{rrset}
+	err = s.patchRRSetIfMatch(ctx, r, serverID, zoneID, name, rrType, rrsets)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetUpdate, err)
+		return
+	}
+	err = s.flushRRSets(r.Context(), serverID, rrsets)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, err)
+		return
+	}
+
+	result, err := s.getRRSet(ctx, serverID, zoneID, name, rrType)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetUpdate, err)
+		return
+	}
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionRRSetUpdate,
+		"zone":   zoneID,
+		"rr":     name,
+	}).Infof("RRset %s %s was replaced in zone %s", name, rrType, zoneID)
+	s.writeRRSet(w, r, http.StatusOK, result)
+}
+
+// DeleteRRSet removes RRset by name and type
+func (s *RRSetHandler) DeleteRRSet(w http.ResponseWriter, r *http.Request) {
+	serverID, zoneID, name, rrType, err := rrsetVars(r)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetDelete, err)
+		return
+	}
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionRRSetDelete)
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	// Deleting an absent RRset is not an error, so clients can retry
+	rrsets := []zones.ResourceRecordSet{{Name: name, Type: rrType, ChangeType: zones.ChangeTypeDelete}}
+	err = s.patchRRSetIfMatch(ctx, r, serverID, zoneID, name, rrType, rrsets)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetDelete, err)
+		return
+	}
+	err = s.flushRRSets(r.Context(), serverID, rrsets)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionRRSetDelete,
+		"zone":   zoneID,
+		"rr":     name,
+	}).Infof("RRset %s %s was removed from zone %s", name, rrType, zoneID)
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusNoContent)
+}
+
+func (s *RRSetHandler) getRRSet(ctx context.Context, serverID, zoneID, name, rrType string) (*zones.ResourceRecordSet, error) {
+	zone, err := s.auth.Zones().GetZone(ctx, serverID, zoneID)
+	if err != nil {
+		return nil, errors.WrapPDNS(err, "list zone %s", zoneID)
+	}
+	rrset := zone.GetRecordSet(name, rrType)
+	if rrset == nil {
+		return nil, errors.NotFound.Newf("RRset %s %s not found in zone %s", name, rrType, zoneID)
+	}
+	return rrset, nil
+}
+
+// patchRRSetIfMatch checks If-Match header with the RRset ETag and applies the changes,
+// the zone is locked from the check until the changes are applied
+func (s *RRSetHandler) patchRRSetIfMatch(ctx context.Context, r *http.Request, serverID, zoneID, name, rrType string, rrsets []zones.ResourceRecordSet) error {
+	unlock := s.LockZone(zoneID)
+	defer unlock()
+
+	err := s.checkRRSetETag(ctx, r, serverID, zoneID, name, rrType)
+	if err != nil {
+		return err
+	}
+	return s.patchRRSets(ctx, serverID, zoneID, rrsets, rrsetField)
+}
+
+// checkRRSetETag compares If-Match header with ETag of the RRset returned by GetRRSet
+func (s *RRSetHandler) checkRRSetETag(ctx context.Context, r *http.Request, serverID, zoneID, name, rrType string) error {
+	if !etag.Requested(r) {
+		return nil
+	}
+	rrset, err := s.getRRSet(ctx, serverID, zoneID, name, rrType)
+	if errors.GetType(err) == errors.NotFound {
+		return etag.Check(r, "")
+	}
+	if err != nil {
+		return err
+	}
+	tag, err := etag.Compute(rrset)
+	if err != nil {
+		return err
+	}
+	return etag.Check(r, tag)
+}
+
+func (s *RRSetHandler) writeRRSet(w http.ResponseWriter, r *http.Request, status int, rrset *zones.ResourceRecordSet) {
+	tag, err := etag.Compute(rrset)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetList, err)
+		return
+	}
+	w.Header().Set(etag.Header, tag)
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(rrset)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionRRSetList, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, status)
+}
+
+// rrsetField names validation errors by fields of the RRset payload
+func rrsetField(int) string {
+	return ""
+}
+
+// rrsetVars returns path variables with canonical zone and RRset names and upper-case type.
+// The name @ means the zone apex, other names must be inside the zone.
+func rrsetVars(r *http.Request) (serverID, zoneID, name, rrType string, err error) {
+	vars := mux.Vars(r)
+	serverID = vars["serverID"]
+	zoneID = network.Canonicalize(strings.ToLower(vars["zoneID"]))
+	name = network.Canonicalize(strings.ToLower(vars["name"]))
+	rrType = strings.ToUpper(vars["type"])
+
+	if vars["name"] == "@" {
+		name = zoneID
+	}
+	if name != zoneID && !strings.HasSuffix(name, "."+zoneID) {
+		err = errors.AddFieldError(
+			errors.BadRequest.Newf("RRset %s is out of zone %s", name, zoneID),
+			"name", "must be @ or a name inside the zone",
+		)
+	}
+	return serverID, zoneID, name, rrType, err
+}
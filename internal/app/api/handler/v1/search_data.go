@@ -18,12 +18,13 @@ package v1
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 
 	"github.com/gorilla/mux"
@@ -40,8 +41,8 @@ type SearchDataHandler struct {
 	powerDNSClient pdns.Client
 }
 
-func NewSearchDataHandler(config config.Config, stats stats.PrometheusStatsCollector, powerDNSClient pdns.Client) *ListServersHandler {
-	return &ListServersHandler{config: config, stats: stats, powerDNSClient: powerDNSClient}
+func NewSearchDataHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, powerDNSClient pdns.Client) *ListServersHandler {
+	return &ListServersHandler{config: config, errorWriter: errorWriter, stats: stats, powerDNSClient: powerDNSClient}
 }
 
 // SearchData lists all known servers
@@ -60,16 +61,16 @@ func (s *ListServersHandler) SearchData(w http.ResponseWriter, r *http.Request)
 	q := r.URL.Query()
 	query := q.Get("q")
 	if len(q) == 0 || query == "" {
-		http.Error(w, "not enough query parameters", http.StatusBadRequest)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusBadRequest)
+		err = errors.BadRequest.New("not enough query parameters")
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionSearchData, errors.AddFieldError(err, "q", "is required"))
 		return
 	}
 	if max = q.Get("max"); max == "" {
 		max = forwardzone.DefaultMaxResults
 	}
 	if m, err = strconv.Atoi(max); err != nil {
-		http.Error(w, fmt.Sprintf("bad 'max' query parameter: %v", err), http.StatusBadRequest)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusBadRequest)
+		err = errors.BadRequest.Wrap(err, "bad 'max' query parameter")
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionSearchData, errors.AddFieldError(err, "max", "must be an integer"))
 		return
 	}
 	objectType := q.Get("object_type")
@@ -84,13 +85,12 @@ func (s *ListServersHandler) SearchData(w http.ResponseWriter, r *http.Request)
 		ot = search.ObjectTypeAll
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
 	defer cancel()
 
 	result, err := s.powerDNSClient.Search().Search(ctx, serverID, query, m, ot)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to search by query %s: %v", query, err), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionSearchData, errors.WrapPDNS(err, "failed to search by query %s", query))
 		return
 	}
 
@@ -98,8 +98,7 @@ func (s *ListServersHandler) SearchData(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionSearchData, errors.Wrap(err, "encoding JSON response"))
 		return
 	}
 	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
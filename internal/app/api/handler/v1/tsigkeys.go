@@ -0,0 +1,240 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/tsigkey"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+type tsigKeyClient interface {
+	ListTSIGKeys(ctx context.Context, serverID string) ([]tsigkey.TSIGKey, error)
+	GetTSIGKey(ctx context.Context, serverID, keyID string) (*tsigkey.TSIGKey, error)
+	CreateTSIGKey(ctx context.Context, serverID string, key tsigkey.TSIGKey) (*tsigkey.TSIGKey, error)
+	UpdateTSIGKey(ctx context.Context, serverID, keyID string, key tsigkey.TSIGKey) (*tsigkey.TSIGKey, error)
+	DeleteTSIGKey(ctx context.Context, serverID, keyID string) error
+}
+
+// tsigKeysCache is a cache of TSIG keys, like keys of DNS UPDATE listener, reloaded after changes of keys
+type tsigKeysCache interface {
+	Invalidate()
+}
+
+// TSIGKeysHandler manages TSIG keys of PowerDNS Authoritative.
+// Secrets are returned only on creation and update, which require authorization,
+// they are never logged or saved to audit and idempotency stores.
+type TSIGKeysHandler struct {
+	config      config.Config
+	errorWriter errorWriter
+	stats       stats.PrometheusStatsCollector
+	logger      *logrus.Logger
+	client      tsigKeyClient
+	cache       tsigKeysCache
+}
+
+func NewTSIGKeysHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, client tsigKeyClient, cache tsigKeysCache) *TSIGKeysHandler {
+	return &TSIGKeysHandler{config: config, errorWriter: errorWriter, stats: stats, logger: logger, client: client, cache: cache}
+}
+
+// ListTSIGKeys returns TSIG keys without secrets
+func (s *TSIGKeysHandler) ListTSIGKeys(w http.ResponseWriter, r *http.Request) {
+	serverID := mux.Vars(r)["serverID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	keys, err := s.client.ListTSIGKeys(ctx, serverID)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeysList, errors.WrapPDNS(err, "listing TSIG keys"))
+		return
+	}
+	for i := range keys {
+		keys[i] = keys[i].Redacted()
+	}
+	s.writeJSON(w, r, log.ActionTSIGKeysList, http.StatusOK, keys)
+}
+
+// GetTSIGKey returns the TSIG key without secret
+func (s *TSIGKeysHandler) GetTSIGKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	keyID := vars["keyID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	key, err := s.client.GetTSIGKey(ctx, serverID, keyID)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyList, errors.WrapPDNS(err, "getting TSIG key %s", keyID))
+		return
+	}
+	s.writeJSON(w, r, log.ActionTSIGKeyList, http.StatusOK, key.Redacted())
+}
+
+// AddTSIGKey creates a new TSIG key, the secret is generated for the algorithm if it is empty.
+// Returns the key with secret.
+func (s *TSIGKeysHandler) AddTSIGKey(w http.ResponseWriter, r *http.Request) {
+	serverID := mux.Vars(r)["serverID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	var key tsigkey.TSIGKey
+	err := json.NewDecoder(r.Body).Decode(&key)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyAdd, errors.BadRequest.Wrap(err, "decoding input TSIG key"))
+		return
+	}
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionTSIGKeyAdd)
+	event.SetTSIGKey(key)
+
+	err = key.Prepare()
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyAdd, err)
+		return
+	}
+	event.SetTSIGKey(key)
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	created, err := s.client.CreateTSIGKey(ctx, serverID, tsigkey.TSIGKey{Name: key.Name, Algorithm: key.Algorithm, Key: key.Key})
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyAdd, errors.WrapPDNS(err, "creating TSIG key %s", key.Name))
+		return
+	}
+	event.SetTSIGKey(*created)
+	s.cache.Invalidate()
+
+	s.writeSecret(w, r, log.ActionTSIGKeyAdd, http.StatusCreated, created)
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionTSIGKeyAdd,
+	}).Infof("TSIG key %s was created with algorithm %s", created.Name, created.Algorithm)
+}
+
+// UpdateTSIGKey replaces algorithm and secret of the TSIG key, the secret is generated if it is empty.
+// Keys can't be renamed, because zones reference them by name. Returns the key with secret.
+func (s *TSIGKeysHandler) UpdateTSIGKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	keyID := vars["keyID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	var key tsigkey.TSIGKey
+	err := json.NewDecoder(r.Body).Decode(&key)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyUpdate, errors.BadRequest.Wrap(err, "decoding input TSIG key"))
+		return
+	}
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionTSIGKeyUpdate)
+	event.SetTSIGKey(tsigkey.TSIGKey{ID: keyID, Name: key.Name, Algorithm: key.Algorithm})
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	current, err := s.client.GetTSIGKey(ctx, serverID, keyID)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyUpdate, errors.WrapPDNS(err, "getting TSIG key %s", keyID))
+		return
+	}
+	if key.Name == "" {
+		key.Name = current.Name
+	}
+	if !strings.EqualFold(strings.TrimSuffix(key.Name, "."), strings.TrimSuffix(current.Name, ".")) {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyUpdate, errors.AddFieldError(
+			errors.BadRequest.Newf("TSIG key %s can't be renamed", current.Name),
+			"name", "must be the name of the key",
+		))
+		return
+	}
+	key.Name = current.Name
+	err = key.Prepare()
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyUpdate, err)
+		return
+	}
+	event.SetTSIGKey(tsigkey.TSIGKey{ID: current.ID, Name: key.Name, Algorithm: key.Algorithm})
+
+	updated, err := s.client.UpdateTSIGKey(ctx, serverID, current.ID, tsigkey.TSIGKey{Name: key.Name, Algorithm: key.Algorithm, Key: key.Key})
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyUpdate, errors.WrapPDNS(err, "updating TSIG key %s", keyID))
+		return
+	}
+	s.cache.Invalidate()
+
+	s.writeSecret(w, r, log.ActionTSIGKeyUpdate, http.StatusOK, updated)
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionTSIGKeyUpdate,
+	}).Infof("TSIG key %s was updated with algorithm %s", updated.Name, updated.Algorithm)
+}
+
+// DeleteTSIGKey deletes the TSIG key
+func (s *TSIGKeysHandler) DeleteTSIGKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	keyID := vars["keyID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionTSIGKeyDelete)
+	event.SetTSIGKey(tsigkey.TSIGKey{ID: keyID})
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	err := s.client.DeleteTSIGKey(ctx, serverID, keyID)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionTSIGKeyDelete, errors.WrapPDNS(err, "deleting TSIG key %s", keyID))
+		return
+	}
+	s.cache.Invalidate()
+
+	w.WriteHeader(http.StatusNoContent)
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionTSIGKeyDelete,
+	}).Infof("TSIG key %s was deleted", keyID)
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusNoContent)
+}
+
+// writeSecret writes the key with secret, the response must not be cached or saved for replay
+func (s *TSIGKeysHandler) writeSecret(w http.ResponseWriter, r *http.Request, action string, status int, key *tsigkey.TSIGKey) {
+	w.Header().Set("Cache-Control", "no-store")
+	s.writeJSON(w, r, action, status, key)
+}
+
+func (s *TSIGKeysHandler) writeJSON(w http.ResponseWriter, r *http.Request, action string, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, action, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, status)
+}
@@ -22,25 +22,29 @@ import (
 	"runtime"
 
 	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
 )
 
 type VersionHandler struct {
-	config  config.Config
-	stats   stats.PrometheusStatsCollector
-	Version string `json:"version"`
-	Build   string `json:"build"`
-	Go      string `json:"go"`
+	config      config.Config
+	errorWriter errorWriter
+	stats       stats.PrometheusStatsCollector
+	Version     string `json:"version"`
+	Build       string `json:"build"`
+	Go          string `json:"go"`
 }
 
-func NewVersionHandler(config config.Config, stats stats.PrometheusStatsCollector) *VersionHandler {
+func NewVersionHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector) *VersionHandler {
 	return &VersionHandler{
-		config:  config,
-		stats:   stats,
-		Version: config.Version,
-		Build:   config.Build,
-		Go:      runtime.Version(),
+		config:      config,
+		errorWriter: errorWriter,
+		stats:       stats,
+		Version:     config.Version,
+		Build:       config.Build,
+		Go:          runtime.Version(),
 	}
 }
 
@@ -53,8 +57,7 @@ func (s *VersionHandler) Get(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	err := json.NewEncoder(w).Encode(s)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionVersion, errors.Wrap(err, "encoding JSON response"))
 		return
 	}
 	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
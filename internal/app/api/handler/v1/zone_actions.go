@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	pdnsApi "github.com/mittwald/go-powerdns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// ZoneActionsHandler triggers PowerDNS actions on authoritative zones
+type ZoneActionsHandler struct {
+	config      config.Config
+	errorWriter errorWriter
+	stats       stats.PrometheusStatsCollector
+	logger      *logrus.Logger
+	auth        pdnsApi.Client
+}
+
+func NewZoneActionsHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, auth pdnsApi.Client) *ZoneActionsHandler {
+	return &ZoneActionsHandler{config: config, errorWriter: errorWriter, stats: stats, logger: logger, auth: auth}
+}
+
+// AxfrRetrieve retrieves Slave zone from its masters
+func (s *ZoneActionsHandler) AxfrRetrieve(w http.ResponseWriter, r *http.Request) {
+	s.do(w, r, log.ActionZoneAxfrRetrieve, func(ctx context.Context, serverID string, zone *zones.Zone) error {
+		if zone.Kind != zones.ZoneKindSlave {
+			return errors.BadRequest.Newf("zone %s is not Slave, it has no masters to retrieve from", zone.Name)
+		}
+		return s.auth.Zones().RetrieveFromMaster(ctx, serverID, zone.ID)
+	})
+}
+
+// Notify sends DNS NOTIFY for the zone to its slaves
+func (s *ZoneActionsHandler) Notify(w http.ResponseWriter, r *http.Request) {
+	s.do(w, r, log.ActionZoneNotify, func(ctx context.Context, serverID string, zone *zones.Zone) error {
+		if zone.Kind == zones.ZoneKindSlave {
+			return errors.BadRequest.Newf("zone %s is Slave, only its masters can notify", zone.Name)
+		}
+		return s.auth.Zones().NotifySlaves(ctx, serverID, zone.ID)
+	})
+}
+
+// Rectify rectifies DNSSEC data of the zone
+func (s *ZoneActionsHandler) Rectify(w http.ResponseWriter, r *http.Request) {
+	s.do(w, r, log.ActionZoneRectify, func(ctx context.Context, serverID string, zone *zones.Zone) error {
+		if zone.Kind == zones.ZoneKindSlave {
+			return errors.BadRequest.Newf("zone %s is Slave, it is rectified by masters", zone.Name)
+		}
+		if zone.Presigned {
+			return errors.BadRequest.Newf("zone %s is presigned and can't be rectified", zone.Name)
+		}
+		return s.auth.Zones().RectifyZone(ctx, serverID, zone.ID)
+	})
+}
+
+// do checks the zone exists and calls the action with it
+func (s *ZoneActionsHandler) do(w http.ResponseWriter, r *http.Request, action string, fn func(ctx context.Context, serverID string, zone *zones.Zone) error) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	zoneID := vars["zoneID"]
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(action)
+	event.SetZone(serverID, zoneID)
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	zone, err := s.auth.Zones().GetZone(ctx, serverID, zoneID)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, action, errors.WrapPDNS(err, "getting zone %s", zoneID))
+		return
+	}
+	err = fn(ctx, serverID, zone)
+	if err != nil {
+		if errors.GetType(err) == errors.NoType {
+			err = errors.WrapPDNS(err, "%s of zone %s", action, zoneID)
+		}
+		s.errorWriter.WriteError(w, r.URL.Path, action, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": action,
+		"zone":   zoneID,
+	}).Infof("Zone %s: %s is done", zoneID, action)
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusNoContent)
+}
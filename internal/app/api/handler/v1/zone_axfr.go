@@ -0,0 +1,187 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/miekg/dns"
+	pdnsApi "github.com/mittwald/go-powerdns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/tsigkey"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/dnsclient"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Formats of zone transfer
+const (
+	formatJSON = "json"
+	formatBIND = "bind"
+)
+
+type tsigKeyGetter interface {
+	GetTSIGKey(ctx context.Context, serverID, keyID string) (*tsigkey.TSIGKey, error)
+}
+
+// ZoneTransfer is a zone transferred by AXFR
+type ZoneTransfer struct {
+	Zone string `json:"zone"`
+	// Server is an address of DNS listener the zone was transferred from
+	Server string `json:"server"`
+	// Serial is SOA serial served over DNS
+	Serial uint32 `json:"serial"`
+	// APISerial is the serial reported by PowerDNS API
+	APISerial int                       `json:"api_serial"`
+	RRSets    []zones.ResourceRecordSet `json:"rrsets"`
+}
+
+// ZoneTransferHandler transfers zones from PowerDNS Authoritative DNS listener
+type ZoneTransferHandler struct {
+	config      config.Config
+	errorWriter errorWriter
+	stats       stats.PrometheusStatsCollector
+	logger      *logrus.Logger
+	auth        pdnsApi.Client
+	tsigKeys    tsigKeyGetter
+	ldapAuth    ldapAuthorizer
+}
+
+func NewZoneTransferHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, auth pdnsApi.Client, tsigKeys tsigKeyGetter, ldapAuth ldapAuthorizer) *ZoneTransferHandler {
+	return &ZoneTransferHandler{config: config, errorWriter: errorWriter, stats: stats, logger: logger, auth: auth, tsigKeys: tsigKeys, ldapAuth: ldapAuth}
+}
+
+// TransferZone returns the zone as served over DNS by AXFR, in JSON with RRsets like PowerDNS API or in BIND format.
+// Transfer is signed by TSIG key from config or from 'tsig_key' query parameter.
+// Other keys than the configured one may give access to zones of other users,
+// so with LDAP authorization they require read permission for the key.
+func (s *ZoneTransferHandler) TransferZone(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	zoneID := vars["zoneID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatJSON
+	}
+	if format != formatJSON && format != formatBIND {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTransfer, errors.AddFieldError(
+			errors.BadRequest.Newf("unknown format %s", format),
+			"format", "must be json or bind",
+		))
+		return
+	}
+	keyName := r.URL.Query().Get("tsig_key")
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	configured := s.config.PDNS.AuthConfig.AXFRTSIGKey
+	if keyName == "" {
+		keyName = configured
+	} else if dns.Fqdn(keyName) != dns.Fqdn(configured) {
+		if err := s.authorizeKey(ctx, r, dns.Fqdn(keyName)); err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionLDAPAuthorization, err)
+			return
+		}
+	}
+
+	z, err := s.auth.Zones().GetZone(ctx, serverID, zoneID)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTransfer, errors.WrapPDNS(err, "getting zone %s", zoneID))
+		return
+	}
+	var tsig *dnsclient.TSIG
+	if keyName != "" {
+		key, err := s.tsigKeys.GetTSIGKey(ctx, serverID, dns.Fqdn(keyName))
+		if err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTransfer, errors.WrapPDNS(err, "getting TSIG key %s", keyName))
+			return
+		}
+		tsig = &dnsclient.TSIG{Name: key.Name, Algorithm: tsigkey.DNSAlgorithm(key.Algorithm), Secret: key.Key}
+	}
+
+	addr := s.config.PDNS.AuthConfig.DNSAddress
+	rrs, err := dnsclient.Transfer(ctx, addr, z.Name, tsig)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTransfer, err)
+		return
+	}
+
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionZoneTransfer,
+		"zone":   z.Name,
+	}).Debugf("Zone %s was transferred from %s with %d records", z.Name, addr, len(rrs))
+
+	if format == formatBIND {
+		var b strings.Builder
+		for _, rr := range rrs {
+			b.WriteString(rr.String())
+			b.WriteByte('\n')
+		}
+		w.Header().Set("Content-Type", "text/plain;charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, b.String())
+		s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+		return
+	}
+
+	transfer := ZoneTransfer{
+		Zone:      z.Name,
+		Server:    addr,
+		APISerial: z.Serial,
+		RRSets:    zone.RRSetsFromDNS(rrs),
+	}
+	if len(rrs) > 0 {
+		if soa, ok := rrs[0].(*dns.SOA); ok {
+			transfer.Serial = soa.Serial
+		}
+	}
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(transfer)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTransfer, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
+
+// authorizeKey checks LDAP permission of the user to read the TSIG key, like GET of the key would require
+func (s *ZoneTransferHandler) authorizeKey(ctx context.Context, r *http.Request, keyID string) error {
+	if !viper.GetBool("ldap.enabled") {
+		return nil
+	}
+	uid := r.Header.Get("X-PDNS-Client-UID")
+	if uid == "" {
+		return errors.Unauthorized.New("X-PDNS-Client-UID header is required to use tsig_key")
+	}
+	authorized, err := s.ldapAuth.AuthorizeViaLDAP(ctx, ldap.CNTypeRead, tsigkey.ZoneType, keyID, uid)
+	if err != nil {
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"action":   log.ActionLDAPAuthorization,
+			"zone":     keyID,
+			"zoneType": tsigkey.ZoneType,
+			"uid":      uid,
+		}).Errorf("Failed to authorize user %s for %s: %v", uid, ldap.CNTypeRead, err)
+		return err
+	}
+	if !authorized {
+		return errors.Forbidden.Newf("user %s has no %s permission for %s %s", uid, ldap.CNTypeRead, tsigkey.ZoneType, keyID)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/client"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// ZoneConsistency is a result of comparing the zone between nodes
+type ZoneConsistency struct {
+	Zone string `json:"zone"`
+	// Consistent is true if all nodes have the same zone state
+	Consistent bool `json:"consistent"`
+	// Serial and RRSetsHash are the state most of nodes have
+	Serial     int             `json:"serial"`
+	RRSetsHash string          `json:"rrsets_hash,omitempty"`
+	Nodes      []NodeZoneState `json:"nodes"`
+}
+
+// NodeZoneState is the zone state on a single node
+type NodeZoneState struct {
+	Node       string `json:"node"`
+	Status     int    `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Serial     int    `json:"serial,omitempty"`
+	RRSetsHash string `json:"rrsets_hash,omitempty"`
+	// InSync is true if the node has the same state as most of nodes
+	InSync bool `json:"in_sync"`
+}
+
+// ZoneConsistencyHandler compares zones between nodes
+type ZoneConsistencyHandler struct {
+	config         config.Config
+	errorWriter    errorWriter
+	stats          stats.PrometheusStatsCollector
+	logger         *logrus.Logger
+	internalClient internalClient
+}
+
+func NewZoneConsistencyHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, internalClient internalClient) *ZoneConsistencyHandler {
+	return &ZoneConsistencyHandler{config: config, errorWriter: errorWriter, stats: stats, logger: logger, internalClient: internalClient}
+}
+
+// GetZoneConsistency compares SOA serials of the zone on all healthy nodes,
+// and hashes of RRsets if 'rrsets' query parameter is true.
+func (s *ZoneConsistencyHandler) GetZoneConsistency(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	zoneID := network.Canonicalize(vars["zoneID"])
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	var withRRSets bool
+	if v := r.URL.Query().Get("rrsets"); v != "" {
+		var err error
+		withRRSets, err = strconv.ParseBool(v)
+		if err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneConsistency, errors.AddFieldError(
+				errors.BadRequest.Newf("invalid rrsets %s", v),
+				"rrsets", "must be a boolean",
+			))
+			return
+		}
+	}
+
+	// Failed nodes are reported in the result, so only an error without results fails the request
+	nodes, err := s.internalClient.ZoneState(r.Context(), serverID, zoneID, withRRSets)
+	if err != nil && nodes == nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneConsistency, err)
+		return
+	}
+
+	result := ZoneConsistency{Zone: zoneID, Nodes: make([]NodeZoneState, len(nodes))}
+	var states []zone.State
+	for i, node := range nodes {
+		ns := nodeZoneState(node)
+		result.Nodes[i] = ns
+		if ns.Error == "" {
+			states = append(states, zone.State{Serial: ns.Serial, RRSetsHash: ns.RRSetsHash})
+		}
+	}
+	ref, _ := zone.ReferenceState(states)
+	result.Serial, result.RRSetsHash = ref.Serial, ref.RRSetsHash
+	result.Consistent = true
+	for i := range result.Nodes {
+		ns := &result.Nodes[i]
+		ns.InSync = ns.Error == "" && ns.Serial == ref.Serial && ns.RRSetsHash == ref.RRSetsHash
+		result.Consistent = result.Consistent && ns.InSync
+	}
+
+	if !result.Consistent {
+		s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+			"action": log.ActionZoneConsistency,
+			"zone":   zoneID,
+		}).Warnf("Zone %s differs between nodes", zoneID)
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneConsistency, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
+
+// nodeZoneState decodes the zone state or the error from the node response
+func nodeZoneState(node client.NodeResult) NodeZoneState {
+	ns := NodeZoneState{Node: node.Node, Status: node.Status, Error: nodeError(node)}
+	if ns.Error != "" {
+		return ns
+	}
+	var state zone.State
+	if err := json.Unmarshal(node.Body, &state); err != nil {
+		ns.Error = fmt.Sprintf("decoding zone state: %v", err)
+		return ns
+	}
+	ns.Serial, ns.RRSetsHash = state.Serial, state.RRSetsHash
+	return ns
+}
+
+// nodeError returns the error of internal request to the node, from the error response if the node answered
+func nodeError(node client.NodeResult) string {
+	if node.Error != "" || node.Status == http.StatusOK {
+		return node.Error
+	}
+	var errResp network.ErrorResponse
+	if err := json.Unmarshal(node.Body, &errResp); err != nil || errResp.Message == "" {
+		return fmt.Sprintf("unexpected status %d", node.Status)
+	}
+	return errResp.Message
+}
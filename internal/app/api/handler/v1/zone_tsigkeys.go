@@ -0,0 +1,178 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	pdnsApi "github.com/mittwald/go-powerdns"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/tsigkey"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+type tsigKeyLister interface {
+	ListTSIGKeys(ctx context.Context, serverID string) ([]tsigkey.TSIGKey, error)
+}
+
+type zoneMetadataClient interface {
+	tsigKeyLister
+	GetMetadata(ctx context.Context, serverID, zoneID, kind string) ([]string, error)
+	SetMetadata(ctx context.Context, serverID, zoneID, kind string, values []string) error
+	DeleteMetadata(ctx context.Context, serverID, zoneID, kind string) error
+}
+
+// ZoneTSIGKeysHandler manages TSIG keys referenced by authoritative zones in AXFR metadata
+type ZoneTSIGKeysHandler struct {
+	config      config.Config
+	errorWriter errorWriter
+	stats       stats.PrometheusStatsCollector
+	logger      *logrus.Logger
+	auth        pdnsApi.Client
+	metadata    zoneMetadataClient
+}
+
+func NewZoneTSIGKeysHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, auth pdnsApi.Client, metadata zoneMetadataClient) *ZoneTSIGKeysHandler {
+	return &ZoneTSIGKeysHandler{config: config, errorWriter: errorWriter, stats: stats, logger: logger, auth: auth, metadata: metadata}
+}
+
+// GetZoneTSIGKeys returns TSIG keys referenced by the zone
+func (s *ZoneTSIGKeysHandler) GetZoneTSIGKeys(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	zoneID := vars["zoneID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	z, err := s.auth.Zones().GetZone(ctx, serverID, zoneID)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTSIGKeysList, errors.WrapPDNS(err, "getting zone %s", zoneID))
+		return
+	}
+	keys := zone.TSIGKeys{MasterTSIGKeyIDs: []string{}, SlaveTSIGKeyIDs: []string{}}
+	for kind, ids := range map[string]*[]string{zone.MetadataTSIGAllowAXFR: &keys.MasterTSIGKeyIDs, zone.MetadataAXFRMasterTSIG: &keys.SlaveTSIGKeyIDs} {
+		values, err := s.metadata.GetMetadata(ctx, serverID, z.ID, kind)
+		if err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTSIGKeysList, errors.WrapPDNS(err, "getting metadata %s of zone %s", kind, zoneID))
+			return
+		}
+		*ids = append(*ids, values...)
+	}
+	s.writeTSIGKeys(w, r, log.ActionZoneTSIGKeysList, keys)
+}
+
+// PutZoneTSIGKeys replaces TSIG keys referenced by the zone, the keys must exist.
+// Empty lists remove the references.
+func (s *ZoneTSIGKeysHandler) PutZoneTSIGKeys(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	zoneID := vars["zoneID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	var keys zone.TSIGKeys
+	err := json.NewDecoder(r.Body).Decode(&keys)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTSIGKeysUpdate, errors.BadRequest.Wrap(err, "decoding input TSIG keys"))
+		return
+	}
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionZoneTSIGKeysUpdate)
+	event.SetZone(serverID, zoneID)
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	z, err := s.auth.Zones().GetZone(ctx, serverID, zoneID)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTSIGKeysUpdate, errors.WrapPDNS(err, "getting zone %s", zoneID))
+		return
+	}
+	err = keys.Validate(z.Name, z.Kind)
+	if err == nil {
+		err = checkTSIGKeys(ctx, s.metadata, serverID, z.Name, &keys)
+	}
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTSIGKeysUpdate, err)
+		return
+	}
+
+	for kind, ids := range map[string][]string{zone.MetadataTSIGAllowAXFR: keys.MasterTSIGKeyIDs, zone.MetadataAXFRMasterTSIG: keys.SlaveTSIGKeyIDs} {
+		if len(ids) > 0 {
+			err = s.metadata.SetMetadata(ctx, serverID, z.ID, kind, ids)
+		} else {
+			err = s.metadata.DeleteMetadata(ctx, serverID, z.ID, kind)
+		}
+		if err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneTSIGKeysUpdate, errors.WrapPDNS(err, "setting metadata %s of zone %s", kind, zoneID))
+			return
+		}
+	}
+
+	if keys.MasterTSIGKeyIDs == nil {
+		keys.MasterTSIGKeyIDs = []string{}
+	}
+	if keys.SlaveTSIGKeyIDs == nil {
+		keys.SlaveTSIGKeyIDs = []string{}
+	}
+	s.writeTSIGKeys(w, r, log.ActionZoneTSIGKeysUpdate, keys)
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionZoneTSIGKeysUpdate,
+		"zone":   zoneID,
+	}).Infof("Zone %s references TSIG keys %v for AXFR from this server and %v for AXFR from masters", zoneID, keys.MasterTSIGKeyIDs, keys.SlaveTSIGKeyIDs)
+}
+
+func (s *ZoneTSIGKeysHandler) writeTSIGKeys(w http.ResponseWriter, r *http.Request, action string, keys zone.TSIGKeys) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(keys)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, action, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
+
+// checkTSIGKeys returns BadRequest error if the zone references unknown TSIG keys,
+// PowerDNS doesn't check keys in metadata
+func checkTSIGKeys(ctx context.Context, lister tsigKeyLister, serverID, zoneName string, keys *zone.TSIGKeys) error {
+	if len(keys.MasterTSIGKeyIDs) == 0 && len(keys.SlaveTSIGKeyIDs) == 0 {
+		return nil
+	}
+	known, err := lister.ListTSIGKeys(ctx, serverID)
+	if err != nil {
+		return errors.WrapPDNS(err, "listing TSIG keys")
+	}
+	var fes []errors.FieldError
+	for field, ids := range map[string][]string{"master_tsig_key_ids": keys.MasterTSIGKeyIDs, "slave_tsig_key_ids": keys.SlaveTSIGKeyIDs} {
+		for _, i := range tsigkey.Missing(known, ids) {
+			fes = append(fes, errors.FieldError{Field: fmt.Sprintf("%s.%d", field, i), Message: "must be an existing TSIG key"})
+		}
+	}
+	if len(fes) == 0 {
+		return nil
+	}
+	sort.Slice(fes, func(i, j int) bool { return fes[i].Field < fes[j].Field })
+	err = errors.BadRequest.Newf("unknown TSIG keys of zone %s", zoneName)
+	for _, fe := range fes {
+		err = errors.AddFieldError(err, fe.Field, fe.Message)
+	}
+	return err
+}
@@ -0,0 +1,184 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// Polling of zone verification
+const (
+	// MaxVerifyTimeout is the longest time in seconds to wait for changes to converge
+	MaxVerifyTimeout = 300
+	// MaxVerifyRRSets limits the number of DNS queries of every attempt on every node
+	MaxVerifyRRSets = 50
+	verifyInterval  = time.Second
+)
+
+// VerifyRequest is a list of RRsets expected to be served by all nodes
+type VerifyRequest struct {
+	RRSets []zone.Expectation `json:"rrsets"`
+	// Timeout is a number of seconds to repeat checks until all nodes answer as expected, checks are done once if 0
+	Timeout int `json:"timeout"`
+}
+
+// ZoneVerification is a result of checking expected RRsets on all nodes
+type ZoneVerification struct {
+	Zone string `json:"zone"`
+	// Converged is true if all servers of all nodes answer as expected
+	Converged bool `json:"converged"`
+	// Attempts is a number of times the checks were done
+	Attempts int                `json:"attempts"`
+	Nodes    []NodeVerification `json:"nodes"`
+}
+
+// NodeVerification is a result of checks on a single node
+type NodeVerification struct {
+	Node      string       `json:"node"`
+	Status    int          `json:"status,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Converged bool         `json:"converged"`
+	Checks    []zone.Check `json:"checks"`
+}
+
+// ZoneVerifyHandler checks that nodes serve changes of the zone over DNS
+type ZoneVerifyHandler struct {
+	config         config.Config
+	errorWriter    errorWriter
+	stats          stats.PrometheusStatsCollector
+	logger         *logrus.Logger
+	internalClient internalClient
+}
+
+func NewZoneVerifyHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, logger *logrus.Logger, internalClient internalClient) *ZoneVerifyHandler {
+	return &ZoneVerifyHandler{config: config, errorWriter: errorWriter, stats: stats, logger: logger, internalClient: internalClient}
+}
+
+// VerifyZone queries authoritative server and recursor of every healthy node over DNS
+// and compares answers with expected RRsets. With timeout the checks are repeated
+// until all nodes answer as expected or the timeout expires.
+func (s *ZoneVerifyHandler) VerifyZone(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	zoneID := network.Canonicalize(vars["zoneID"])
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	event := audit.FromContext(r.Context())
+	event.SetAction(log.ActionZoneVerify)
+	event.SetZone(serverID, zoneID)
+
+	var req VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, errors.BadRequest.Wrap(err, "decoding expected RRsets"))
+		return
+	}
+	if len(req.RRSets) == 0 {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, errors.AddFieldError(
+			errors.BadRequest.Newf("no expected RRsets of zone %s", zoneID), "rrsets", "must not be empty"))
+		return
+	}
+	if len(req.RRSets) > MaxVerifyRRSets {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, errors.AddFieldError(
+			errors.BadRequest.Newf("too many expected RRsets of zone %s", zoneID), "rrsets", fmt.Sprintf("must have at most %d items", MaxVerifyRRSets)))
+		return
+	}
+	if req.Timeout < 0 || req.Timeout > MaxVerifyTimeout {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, errors.AddFieldError(
+			errors.BadRequest.Newf("invalid timeout %d", req.Timeout), "timeout", fmt.Sprintf("must be between 0 and %d", MaxVerifyTimeout)))
+		return
+	}
+	if err := zone.PrepareExpectations(zoneID, req.RRSets); err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, err)
+		return
+	}
+	bodyBytes, err := json.Marshal(req.RRSets)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, errors.Wrap(err, "encoding expected RRsets"))
+		return
+	}
+
+	result, err := waitVerification(r.Context(), s.internalClient, serverID, zoneID, bodyBytes, time.Duration(req.Timeout)*time.Second)
+	if err != nil {
+		if r.Context().Err() != nil {
+			// Client has gone away
+			return
+		}
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, err)
+		return
+	}
+
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionZoneVerify,
+		"zone":   zoneID,
+	}).Infof("Zone %s converged: %t after %d attempts", zoneID, result.Converged, result.Attempts)
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
+
+// waitVerification does checks on all nodes until all of them answer as expected or the timeout expires.
+// Checks are done once if timeout is 0. Returns ctx error if it is done while waiting.
+func waitVerification(ctx context.Context, internalClient internalClient, serverID, zoneID string, bodyBytes []byte, timeout time.Duration) (*ZoneVerification, error) {
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		result, err := verify(ctx, internalClient, serverID, zoneID, bodyBytes)
+		if err != nil {
+			return nil, err
+		}
+		result.Attempts = attempt
+		if result.Converged || time.Now().Add(verifyInterval).After(deadline) {
+			return result, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(verifyInterval):
+		}
+	}
+}
+
+// verify does checks on all nodes once
+func verify(ctx context.Context, internalClient internalClient, serverID, zoneID string, bodyBytes []byte) (*ZoneVerification, error) {
+	// Failed nodes are reported in the result, so only an error without results fails the request
+	nodes, err := internalClient.VerifyZone(ctx, serverID, zoneID, bodyBytes)
+	if err != nil && nodes == nil {
+		return nil, err
+	}
+
+	result := &ZoneVerification{Zone: zoneID, Converged: true, Nodes: make([]NodeVerification, len(nodes))}
+	for i, node := range nodes {
+		nv := NodeVerification{Node: node.Node, Status: node.Status, Error: nodeError(node), Checks: []zone.Check{}}
+		if nv.Error == "" {
+			if err := json.Unmarshal(node.Body, &nv.Checks); err != nil {
+				nv.Error = fmt.Sprintf("decoding checks: %v", err)
+			}
+		}
+		nv.Converged = nv.Error == ""
+		for _, c := range nv.Checks {
+			nv.Converged = nv.Converged && c.OK
+		}
+		result.Nodes[i] = nv
+		result.Converged = result.Converged && nv.Converged
+	}
+	return result, nil
+}
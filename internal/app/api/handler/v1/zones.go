@@ -18,15 +18,17 @@ package v1
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/mux"
 	pdnsApi "github.com/mittwald/go-powerdns"
 	"github.com/mittwald/go-powerdns/apis/zones"
-	"github.com/mittwald/go-powerdns/pdnshttp"
 	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/etag"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/listing"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
 	"golang.org/x/net/context"
@@ -34,15 +36,17 @@ import (
 
 type ZonesHandler struct {
 	config         config.Config
+	errorWriter    errorWriter
 	stats          stats.PrometheusStatsCollector
 	powerDNSClient pdnsApi.Client
 }
 
-func NewZonesHandler(config config.Config, stats stats.PrometheusStatsCollector, powerDNSClient pdnsApi.Client) *ZonesHandler {
-	return &ZonesHandler{config: config, stats: stats, powerDNSClient: powerDNSClient}
+func NewZonesHandler(config config.Config, errorWriter errorWriter, stats stats.PrometheusStatsCollector, powerDNSClient pdnsApi.Client) *ZonesHandler {
+	return &ZonesHandler{config: config, errorWriter: errorWriter, stats: stats, powerDNSClient: powerDNSClient}
 }
 
-// ListZones list all zones in a server
+// ListZones list all zones in a server.
+// Zones are filtered, sorted and paginated by query parameters, see listing.ParseParams.
 func (s *ZonesHandler) ListZones(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	serverID := vars["serverID"]
@@ -51,50 +55,55 @@ func (s *ZonesHandler) ListZones(w http.ResponseWriter, r *http.Request) {
 	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
 	defer timer.ObserveDuration()
 
-	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	params, err := listing.ParseParams(r.URL.Query(), listing.FieldName, listing.FieldKind)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZonesList, err)
+		return
+	}
 
-	var zones []zones.Zone
-	var err error
+	var zs []zones.Zone
 
 	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
 	defer cancel()
 
-	// Get zone by name from query parameters
 	if zoneID != "" {
-		zones, err = s.powerDNSClient.Zones().ListZone(ctx, serverID, zoneID)
+		// Get zone by name from query parameters
+		zs, err = s.powerDNSClient.Zones().ListZone(ctx, serverID, zoneID)
 		if err != nil {
-			// 404 Not Found
-			if _, ok := err.(pdnshttp.ErrNotFound); ok {
-				http.Error(w, fmt.Sprintf("zone %s not found", zoneID), http.StatusNotFound)
-				s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusNotFound)
-				return
-			}
-			// 500 Internal Serrver Error
-			http.Error(w, fmt.Sprintf("list zone %s: %v", zoneID, err), http.StatusInternalServerError)
-			s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneList, errors.WrapPDNS(err, "list zone %s", zoneID))
 			return
 		}
-		// 200 OK
-		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(zones)
+	} else {
+		// Get zones
+		zs, err = s.powerDNSClient.Zones().ListZones(ctx, serverID)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("encoding JSON response: %v", err), http.StatusInternalServerError)
-			s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZonesList, errors.WrapPDNS(err, "list zones"))
+			return
 		}
-		return
 	}
 
-	// Get zones
-	zones, err = s.powerDNSClient.Zones().ListZones(ctx, serverID)
+	items := make([]listing.Item, 0, len(zs))
+	for _, z := range zs {
+		items = append(items, listing.Item{Name: z.Name, Kind: zoneKindName(z.Kind)})
+	}
+	page, err := params.Apply(items)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("list zones: %v", err), http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZonesList, err)
 		return
 	}
+	result := make([]zones.Zone, 0, len(page.Indexes))
+	for _, i := range page.Indexes {
+		result = append(result, zs[i])
+	}
+
+	// 200 OK
+	page.SetHeaders(w, r)
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(zones)
+	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("encoding JSON response: %v", err), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZonesList, errors.Wrap(err, "encoding JSON response"))
+		return
 	}
 	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
 }
@@ -108,31 +117,60 @@ func (s *ZonesHandler) ListZone(w http.ResponseWriter, r *http.Request) {
 	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
 	defer timer.ObserveDuration()
 
-	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
 	defer cancel()
 
 	zone, err := s.powerDNSClient.Zones().GetZone(ctx, serverID, zoneID)
 	if err != nil {
-		// 404 Not Found
-		if _, ok := err.(pdnshttp.ErrNotFound); ok {
-			http.Error(w, fmt.Sprintf("zone %s not found", zoneID), http.StatusNotFound)
-			s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusNotFound)
-			return
-		}
-		// 500 Internal Error
-		http.Error(w, fmt.Sprintf("list zone %s: %v", zoneID, err), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneList, errors.WrapPDNS(err, "list zone %s", zoneID))
+		return
+	}
+	tag, err := etag.Compute(zone)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneList, err)
 		return
 	}
 	// 200 OK
+	w.Header().Set(etag.Header, tag)
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(w).Encode(zone)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("encoding JSON response: %v", err), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneList, errors.Wrap(err, "encoding JSON response"))
 		return
 	}
 	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
 }
+
+// zoneKindName returns kind name as PowerDNS API does
+func zoneKindName(kind zones.ZoneKind) string {
+	switch kind {
+	case zones.ZoneKindMaster:
+		return "Master"
+	case zones.ZoneKindSlave:
+		return "Slave"
+	default:
+		return "Native"
+	}
+}
+
+// checkZoneETag compares If-Match header with ETag of the zone returned by ListZone.
+// A missing zone fails the check only if the header is set.
+func checkZoneETag(ctx context.Context, r *http.Request, auth pdnsApi.Client, serverID, zoneID string) error {
+	if !etag.Requested(r) {
+		return nil
+	}
+	zone, err := auth.Zones().GetZone(ctx, serverID, zoneID)
+	if err != nil {
+		err = errors.WrapPDNS(err, "getting zone %s", zoneID)
+		if errors.GetType(err) == errors.NotFound {
+			return etag.Check(r, "")
+		}
+		return err
+	}
+	tag, err := etag.Compute(zone)
+	if err != nil {
+		return err
+	}
+	return etag.Check(r, tag)
+}
@@ -0,0 +1,37 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package openapi holds OpenAPI specification of the public API
+package openapi
+
+import (
+	"context"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// Load parses and validates the specification
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	if err != nil {
+		return nil, errors.Wrap(err, "loading OpenAPI specification")
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, errors.Wrap(err, "validating OpenAPI specification")
+	}
+	return doc, nil
+}
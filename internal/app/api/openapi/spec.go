@@ -0,0 +1,2019 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openapi
+
+// spec is an OpenAPI 3 specification of the public API.
+// Keep it in sync with routes in api/app.go.
+const spec = `
+openapi: 3.0.3
+info:
+  title: pdns-api
+  description: HTTP API for PowerDNS Authoritative and Recursor cluster
+  license:
+    name: Apache 2.0
+    url: http://www.apache.org/licenses/LICENSE-2.0
+  version: v1
+tags:
+  - name: health
+  - name: servers
+  - name: zones
+  - name: forward-zones
+  - name: tsigkeys
+  - name: dns
+  - name: acme
+  - name: system
+paths:
+  /api/v1/health:
+    get:
+      tags: [health]
+      operationId: health
+      summary: Liveness of the instance
+      deprecated: true
+      responses:
+        "200":
+          $ref: "#/components/responses/Alive"
+  /api/v1/health/live:
+    get:
+      tags: [health]
+      operationId: healthLive
+      summary: Liveness of the instance
+      responses:
+        "200":
+          $ref: "#/components/responses/Alive"
+  /api/v1/health/ready:
+    get:
+      tags: [health]
+      operationId: healthReady
+      summary: Readiness of the instance with status of every dependency
+      responses:
+        "200":
+          $ref: "#/components/responses/Ready"
+        "503":
+          $ref: "#/components/responses/Ready"
+  /api/v1/health/internal:
+    get:
+      tags: [health]
+      operationId: healthInternal
+      summary: Readiness of internal API with status of worker dependencies, used by the check of Consul internal service
+      responses:
+        "200":
+          $ref: "#/components/responses/Ready"
+        "503":
+          $ref: "#/components/responses/Ready"
+  /api/v1/openapi.json:
+    get:
+      tags: [system]
+      operationId: openAPI
+      summary: This specification
+      responses:
+        "200":
+          description: OpenAPI specification
+          content:
+            application/json:
+              schema:
+                type: object
+  /api/v1/version:
+    get:
+      tags: [system]
+      operationId: version
+      summary: Version of pdns-api
+      responses:
+        "200":
+          description: Version
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Version"
+  /api/v1/audit:
+    get:
+      tags: [system]
+      operationId: listAuditEvents
+      summary: Audit log of mutating operations, newest first
+      description: >
+        With LDAP authorization enabled, requires read permission for audit.
+      security:
+        - clientUID: []
+      parameters:
+        - name: zone
+          in: query
+          schema:
+            type: string
+        - name: user
+          in: query
+          schema:
+            type: string
+        - name: action
+          in: query
+          schema:
+            type: string
+        - name: from
+          in: query
+          schema:
+            type: string
+            format: date-time
+        - name: to
+          in: query
+          schema:
+            type: string
+            format: date-time
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            minimum: 0
+      responses:
+        "200":
+          description: Audit events
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/AuditEvent"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/auth/cache:
+    delete:
+      tags: [system]
+      operationId: flushAuthCache
+      summary: Drop cached authorization decisions
+      description: Available only if LDAP authorization is enabled. Requires delete permission for all zones.
+      security:
+        - clientUID: []
+      parameters:
+        - name: uid
+          in: query
+          description: Drop decisions of the user only
+          schema:
+            type: string
+      responses:
+        "204":
+          description: Cache was flushed
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+  /api/v1/lookup:
+    get:
+      tags: [dns]
+      operationId: lookup
+      summary: Query local PowerDNS servers over DNS
+      description: >
+        Runs a DNS query like dig against PowerDNS Authoritative without recursion
+        and PowerDNS Recursor with recursion on this node.
+      parameters:
+        - name: name
+          in: query
+          required: true
+          schema:
+            type: string
+            minLength: 1
+        - name: type
+          in: query
+          schema:
+            type: string
+            default: A
+        - name: server
+          in: query
+          schema:
+            type: string
+            enum: [auth, recursor, both]
+            default: both
+      responses:
+        "200":
+          description: Answers of the servers, failed queries have an error instead of an answer
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Lookup"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+  /api/v1/acme/challenge:
+    post:
+      tags: [acme]
+      operationId: addACMEChallenge
+      summary: Add ACME DNS-01 challenge record and wait until all nodes serve it
+      description: >
+        Adds the value to TXT records _acme-challenge of the domain in its closest authoritative zone,
+        flushes cache of the name on all healthy nodes and repeats checks every second until PowerDNS
+        Authoritative and Recursor of all nodes answer with the values or the timeout expires.
+        With LDAP authorization enabled, replace permission for the zone of acme type allows changes
+        of challenge records only, permission for the zone of zones type is accepted too.
+      security:
+        - clientUID: []
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/ACMEChallengeRequest"
+      responses:
+        "200":
+          description: Challenge records and results of checks on every node
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/ACMEChallenge"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    delete:
+      tags: [acme]
+      operationId: deleteACMEChallenge
+      summary: Remove ACME DNS-01 challenge records and wait until all nodes stop serving them
+      description: >
+        Removes the value, or all values if it is omitted, from TXT records _acme-challenge of the domain
+        and waits like addACMEChallenge. Removing absent values is not an error.
+        With LDAP authorization enabled, delete permission is required.
+      security:
+        - clientUID: []
+      parameters:
+        - name: name
+          in: query
+          required: true
+          schema:
+            type: string
+            minLength: 1
+        - name: value
+          in: query
+          schema:
+            $ref: "#/components/schemas/ACMEChallengeValue"
+        - name: timeout
+          in: query
+          schema:
+            type: integer
+            minimum: 0
+            maximum: 300
+            default: 60
+      responses:
+        "200":
+          description: Remaining challenge records and results of checks on every node
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/ACMEChallenge"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers:
+    get:
+      tags: [servers]
+      operationId: listServers
+      summary: List PowerDNS servers
+      responses:
+        "200":
+          description: Servers
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Server"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+    get:
+      tags: [servers]
+      operationId: getServer
+      summary: Get PowerDNS server
+      responses:
+        "200":
+          description: Server
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Server"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/search-data:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+    get:
+      tags: [servers]
+      operationId: searchData
+      summary: Search zones, records and comments
+      parameters:
+        - name: q
+          in: query
+          required: true
+          description: Search term, * and ? wildcards are supported
+          schema:
+            type: string
+            minLength: 1
+        - name: max
+          in: query
+          description: Maximum number of results
+          schema:
+            type: integer
+            minimum: 1
+            default: 10
+        - name: object_type
+          in: query
+          schema:
+            type: string
+            enum: [all, zone, record, comment]
+      responses:
+        "200":
+          description: Search results
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/SearchResult"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/cache/flush:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+    put:
+      tags: [servers]
+      operationId: flushCache
+      summary: Flush cache of Authoritative and Recursor on all nodes
+      description: >
+        With LDAP authorization enabled, requires replace permission for cache.
+      security:
+        - clientUID: []
+      parameters:
+        - name: domain
+          in: query
+          required: true
+          description: Domain name to flush
+          schema:
+            type: string
+            minLength: 1
+      responses:
+        "204":
+          description: Cache was flushed
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+    get:
+      tags: [zones]
+      operationId: listZones
+      summary: List authoritative zones
+      parameters:
+        - name: zone
+          in: query
+          description: Return only the zone with this name
+          schema:
+            type: string
+        - $ref: "#/components/parameters/limit"
+        - $ref: "#/components/parameters/cursor"
+        - $ref: "#/components/parameters/name"
+        - $ref: "#/components/parameters/suffix"
+        - name: kind
+          in: query
+          description: Return only zones of these kinds, comma separated
+          schema:
+            type: string
+            example: Native,Master
+        - name: sort
+          in: query
+          description: Field to sort by, with minus prefix for descending order
+          schema:
+            type: string
+            enum: [name, -name, kind, -kind]
+            default: name
+      responses:
+        "200":
+          description: Zones without RRsets
+          headers:
+            X-Total-Count:
+              $ref: "#/components/headers/TotalCount"
+            X-Next-Cursor:
+              $ref: "#/components/headers/NextCursor"
+            Link:
+              $ref: "#/components/headers/Link"
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Zone"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    post:
+      tags: [zones]
+      operationId: createZone
+      summary: Create authoritative zone
+      description: >
+        With template the zone gets kind, nameservers, SOA, metadata, baseline RRsets
+        and DNSSEC settings of the zone template, fields of the request override them.
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/idempotencyKey"
+        - name: template
+          in: query
+          description: Name of a zone template
+          schema:
+            type: string
+            pattern: "^[a-zA-Z0-9_-]+$"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/NewZone"
+      responses:
+        "201":
+          description: Created zone
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Zone"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "409":
+          $ref: "#/components/responses/Conflict"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones/{zoneID}:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+    get:
+      tags: [zones]
+      operationId: getZone
+      summary: Get authoritative zone with RRsets
+      responses:
+        "200":
+          description: Zone
+          headers:
+            ETag:
+              $ref: "#/components/headers/ETag"
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Zone"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    patch:
+      tags: [zones]
+      operationId: patchZone
+      summary: Replace or delete RRsets of authoritative zone
+      description: >
+        Records are validated by type before the changes, invalid records,
+        TTLs, out-of-zone names and CNAME conflicts are reported as field errors.
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/ifMatch"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/ZonePatch"
+      responses:
+        "204":
+          description: Zone was updated
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "412":
+          $ref: "#/components/responses/PreconditionFailed"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    delete:
+      tags: [zones]
+      operationId: deleteZone
+      summary: Delete authoritative zone
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/ifMatch"
+      responses:
+        "204":
+          description: Zone was deleted
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "412":
+          $ref: "#/components/responses/PreconditionFailed"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones/{zoneID}/rrsets/{name}/{type}:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+      - name: name
+        in: path
+        required: true
+        description: RRset name inside the zone, with or without trailing dot, @ for the zone apex
+        schema:
+          type: string
+          maxLength: 254
+          pattern: "^(@|[a-zA-Z0-9.*_-]+)$"
+      - name: type
+        in: path
+        required: true
+        description: RRset type, case-insensitive
+        schema:
+          type: string
+          pattern: "^[a-zA-Z0-9]+$"
+    get:
+      tags: [zones]
+      operationId: getRRSet
+      summary: Get RRset of authoritative zone
+      responses:
+        "200":
+          description: RRset
+          headers:
+            ETag:
+              $ref: "#/components/headers/ETag"
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/RRSet"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    put:
+      tags: [zones]
+      operationId: putRRSet
+      summary: Replace RRset of authoritative zone
+      description: >
+        Name and type of the RRset are taken from the path.
+        Records are validated by type like in patchZone.
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/ifMatch"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/RRSetPut"
+      responses:
+        "200":
+          description: Resulting RRset
+          headers:
+            ETag:
+              $ref: "#/components/headers/ETag"
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/RRSet"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "412":
+          $ref: "#/components/responses/PreconditionFailed"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    delete:
+      tags: [zones]
+      operationId: deleteRRSet
+      summary: Delete RRset of authoritative zone
+      description: Deleting an absent RRset succeeds.
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/ifMatch"
+      responses:
+        "204":
+          description: RRset was deleted
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "412":
+          $ref: "#/components/responses/PreconditionFailed"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones/{zoneID}/axfr-retrieve:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+    put:
+      tags: [zones]
+      operationId: axfrRetrieveZone
+      summary: Retrieve Slave zone from its masters
+      description: Zone must be Slave.
+      security:
+        - clientUID: []
+      responses:
+        "204":
+          description: Action is done
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones/{zoneID}/notify:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+    put:
+      tags: [zones]
+      operationId: notifyZone
+      summary: Send DNS NOTIFY to slaves of the zone
+      description: Zone must not be Slave.
+      security:
+        - clientUID: []
+      responses:
+        "204":
+          description: Action is done
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones/{zoneID}/rectify:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+    put:
+      tags: [zones]
+      operationId: rectifyZone
+      summary: Rectify DNSSEC data of the zone
+      description: Zone must not be Slave or presigned.
+      security:
+        - clientUID: []
+      responses:
+        "204":
+          description: Action is done
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones/{zoneID}/axfr:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+    get:
+      tags: [zones]
+      operationId: transferZone
+      summary: Transfer the zone by AXFR from PowerDNS DNS listener
+      description: >
+        Returns the zone as it is served over DNS, to compare it with the zone reported by PowerDNS API.
+        The transfer is signed by TSIG key if it is set in the query or in config, the key must be
+        allowed by TSIG-ALLOW-AXFR metadata of the zone.
+        With LDAP authorization enabled, a key other than the configured one requires read permission for the key.
+      security:
+        - clientUID: []
+      parameters:
+        - name: format
+          in: query
+          description: JSON with RRsets sorted like in PowerDNS API or BIND zone file
+          schema:
+            type: string
+            enum: [json, bind]
+            default: json
+        - name: tsig_key
+          in: query
+          description: Name of TSIG key in PowerDNS to sign the transfer
+          schema:
+            type: string
+            pattern: "^[a-zA-Z0-9._-]+$"
+      responses:
+        "200":
+          description: Transferred zone
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/ZoneTransfer"
+            text/plain:
+              schema:
+                type: string
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones/{zoneID}/consistency:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+    get:
+      tags: [zones]
+      operationId: getZoneConsistency
+      summary: Compare the zone between all healthy nodes
+      description: >
+        Asks every healthy pdns-api node registered in Consul for SOA serial of the zone
+        and optionally a hash of its RRsets. Nodes which differ from most of nodes or failed
+        to answer are reported with in_sync false.
+      parameters:
+        - name: rrsets
+          in: query
+          description: Compare hashes of RRsets in addition to SOA serials
+          schema:
+            type: boolean
+            default: false
+      responses:
+        "200":
+          description: Zone state on every node
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/ZoneConsistency"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones/{zoneID}/verify:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+    post:
+      tags: [zones]
+      operationId: verifyZone
+      summary: Check that all nodes serve expected RRsets over DNS
+      description: >
+        Queries PowerDNS Authoritative and Recursor of every healthy pdns-api node over DNS
+        and compares answers with expected RRsets. With timeout the checks are repeated every second
+        until all nodes answer as expected or the timeout expires.
+        With LDAP authorization enabled, requires replace permission for the zone, as the checks load DNS servers of all nodes.
+      security:
+        - clientUID: []
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/VerifyRequest"
+      responses:
+        "200":
+          description: Results of checks on every node
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/ZoneVerification"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/zones/{zoneID}/tsigkeys:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+    get:
+      tags: [zones, tsigkeys]
+      operationId: getZoneTSIGKeys
+      summary: Get TSIG keys referenced by the zone in AXFR metadata
+      responses:
+        "200":
+          description: TSIG keys of the zone
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/ZoneTSIGKeys"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    put:
+      tags: [zones, tsigkeys]
+      operationId: putZoneTSIGKeys
+      summary: Replace TSIG keys referenced by the zone in AXFR metadata
+      description: >
+        Keys must exist. Master keys are set as TSIG-ALLOW-AXFR metadata, the slave key as AXFR-MASTER-TSIG
+        and is allowed for Slave zones only. Empty lists remove the references.
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/idempotencyKey"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/ZoneTSIGKeys"
+      responses:
+        "200":
+          description: TSIG keys of the zone
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/ZoneTSIGKeys"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/forward-zones:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+    get:
+      tags: [forward-zones]
+      operationId: listForwardZones
+      summary: List forward zones of Recursor
+      parameters:
+        - $ref: "#/components/parameters/limit"
+        - $ref: "#/components/parameters/cursor"
+        - $ref: "#/components/parameters/name"
+        - $ref: "#/components/parameters/suffix"
+        - name: sort
+          in: query
+          description: Field to sort by, with minus prefix for descending order
+          schema:
+            type: string
+            enum: [name, -name]
+            default: name
+      responses:
+        "200":
+          description: Forward zones
+          headers:
+            X-Total-Count:
+              $ref: "#/components/headers/TotalCount"
+            X-Next-Cursor:
+              $ref: "#/components/headers/NextCursor"
+            Link:
+              $ref: "#/components/headers/Link"
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/ForwardZone"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+    post:
+      tags: [forward-zones]
+      operationId: createForwardZones
+      summary: Create forward zones on all Recursor nodes
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/idempotencyKey"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/ForwardZones"
+      responses:
+        "201":
+          description: Forward zones were created
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "409":
+          $ref: "#/components/responses/Conflict"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    delete:
+      tags: [forward-zones]
+      operationId: deleteForwardZones
+      summary: Delete forward zones on all Recursor nodes
+      security:
+        - clientUID: []
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/ForwardZones"
+      responses:
+        "204":
+          description: Forward zones were deleted
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/forward-zones/{zoneID}:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/zoneID"
+    get:
+      tags: [forward-zones]
+      operationId: getForwardZone
+      summary: Get forward zone
+      responses:
+        "200":
+          description: Forward zone
+          headers:
+            ETag:
+              $ref: "#/components/headers/ETag"
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/ForwardZone"
+        "404":
+          $ref: "#/components/responses/NotFound"
+    patch:
+      tags: [forward-zones]
+      operationId: patchForwardZone
+      summary: Replace nameservers of forward zone on all Recursor nodes
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/ifMatch"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/ForwardZone"
+      responses:
+        "204":
+          description: Forward zone was updated
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "412":
+          $ref: "#/components/responses/PreconditionFailed"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    delete:
+      tags: [forward-zones]
+      operationId: deleteForwardZone
+      summary: Delete forward zone on all Recursor nodes
+      security:
+        - clientUID: []
+      responses:
+        "204":
+          description: Forward zone was deleted
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/tsigkeys:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+    get:
+      tags: [tsigkeys]
+      operationId: listTSIGKeys
+      summary: List TSIG keys without secrets
+      responses:
+        "200":
+          description: TSIG keys
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/TSIGKey"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    post:
+      tags: [tsigkeys]
+      operationId: createTSIGKey
+      summary: Create TSIG key
+      description: >
+        The secret is generated for the algorithm if it is omitted.
+        The response is the only place the secret is returned, it is not saved for Idempotency-Key replays.
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/idempotencyKey"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/NewTSIGKey"
+      responses:
+        "201":
+          description: Created TSIG key with secret
+          headers:
+            Cache-Control:
+              $ref: "#/components/headers/NoStore"
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/TSIGKey"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "409":
+          $ref: "#/components/responses/Conflict"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /api/v1/servers/{serverID}/tsigkeys/{keyID}:
+    parameters:
+      - $ref: "#/components/parameters/serverID"
+      - $ref: "#/components/parameters/keyID"
+    get:
+      tags: [tsigkeys]
+      operationId: getTSIGKey
+      summary: Get TSIG key without secret
+      responses:
+        "200":
+          description: TSIG key
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/TSIGKey"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    put:
+      tags: [tsigkeys]
+      operationId: updateTSIGKey
+      summary: Replace algorithm and secret of TSIG key
+      description: >
+        The secret is generated for the algorithm if it is omitted, so an empty body rotates the secret.
+        Keys can't be renamed because zones reference them by name.
+      security:
+        - clientUID: []
+      parameters:
+        - $ref: "#/components/parameters/idempotencyKey"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/TSIGKeyUpdate"
+      responses:
+        "200":
+          description: Updated TSIG key with secret
+          headers:
+            Cache-Control:
+              $ref: "#/components/headers/NoStore"
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/TSIGKey"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+    delete:
+      tags: [tsigkeys]
+      operationId: deleteTSIGKey
+      summary: Delete TSIG key
+      description: References of the key in zone metadata are not removed.
+      security:
+        - clientUID: []
+      responses:
+        "204":
+          description: TSIG key was deleted
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "403":
+          $ref: "#/components/responses/Forbidden"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "502":
+          $ref: "#/components/responses/UpstreamError"
+        "503":
+          $ref: "#/components/responses/Unavailable"
+  /metrics:
+    get:
+      tags: [system]
+      operationId: metrics
+      summary: Prometheus metrics
+      responses:
+        "200":
+          description: Metrics in Prometheus text format
+          content:
+            text/plain:
+              schema:
+                type: string
+components:
+  securitySchemes:
+    clientUID:
+      type: apiKey
+      in: header
+      name: X-PDNS-Client-UID
+      description: User ID for authorization via LDAP. Required only if LDAP authorization is enabled.
+  parameters:
+    serverID:
+      name: serverID
+      in: path
+      required: true
+      description: PowerDNS server ID, usually localhost
+      schema:
+        type: string
+    zoneID:
+      name: zoneID
+      in: path
+      required: true
+      description: Zone name, with or without trailing dot
+      schema:
+        type: string
+        pattern: "^[a-zA-Z0-9._-]+$"
+    keyID:
+      name: keyID
+      in: path
+      required: true
+      description: TSIG key ID, usually the key name with trailing dot
+      schema:
+        type: string
+        pattern: "^[a-zA-Z0-9._-]+$"
+    limit:
+      name: limit
+      in: query
+      description: Page size, all zones are returned if omitted
+      schema:
+        type: integer
+        minimum: 1
+        maximum: 1000
+    cursor:
+      name: cursor
+      in: query
+      description: Opaque cursor from X-Next-Cursor header of the previous page
+      schema:
+        type: string
+    name:
+      name: name
+      in: query
+      description: Glob pattern of zone name, case-insensitive
+      schema:
+        type: string
+        example: "*.example.com"
+    idempotencyKey:
+      name: Idempotency-Key
+      in: header
+      description: >-
+        Unique key of the request, a retry with the same key and payload returns the saved response
+        with Idempotent-Replayed header. Reusing the key for another payload is a conflict.
+        Accepted by all mutating requests.
+      schema:
+        type: string
+        maxLength: 255
+    ifMatch:
+      name: If-Match
+      in: header
+      description: Apply the change only if the object has one of these ETags, * matches any existing object
+      schema:
+        type: string
+    suffix:
+      name: suffix
+      in: query
+      description: Return only the zone with this name and its subzones
+      schema:
+        type: string
+        example: example.com
+  headers:
+    ETag:
+      description: Hash of the object, pass it in If-Match header of a change
+      schema:
+        type: string
+    TotalCount:
+      description: Number of zones matching filters
+      schema:
+        type: integer
+    NextCursor:
+      description: Cursor of the next page, absent on the last page
+      schema:
+        type: string
+    NoStore:
+      description: no-store, the response contains a secret
+      schema:
+        type: string
+    Link:
+      description: URL of the next page with rel="next", absent on the last page
+      schema:
+        type: string
+  responses:
+    Alive:
+      description: Instance is alive
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Alive"
+    Ready:
+      description: Readiness report
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/HealthReport"
+    BadRequest:
+      description: Request is invalid
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    Unauthorized:
+      description: X-PDNS-Client-UID header is missing
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    Forbidden:
+      description: User has no permission for the zone
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    NotFound:
+      description: Object not found
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    Conflict:
+      description: Object already exists
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    PreconditionFailed:
+      description: Object was changed since its ETag was read
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    UpstreamError:
+      description: PowerDNS returned an error
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    Unavailable:
+      description: PowerDNS, LDAP or Consul is unavailable
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+  schemas:
+    Error:
+      type: object
+      required: [code, message]
+      properties:
+        code:
+          type: string
+          enum: [bad_request, not_found, conflict, unauthorized, forbidden, unavailable, upstream_error, precondition_failed, internal_error]
+        message:
+          type: string
+        details:
+          type: string
+        request_id:
+          type: string
+        fields:
+          type: array
+          items:
+            $ref: "#/components/schemas/FieldError"
+    FieldError:
+      type: object
+      required: [field, message]
+      properties:
+        field:
+          type: string
+        message:
+          type: string
+    Alive:
+      type: object
+      properties:
+        alive:
+          type: boolean
+        hostname:
+          type: string
+    HealthReport:
+      type: object
+      properties:
+        status:
+          type: string
+          enum: [up, degraded, down]
+        hostname:
+          type: string
+        checks:
+          type: object
+          additionalProperties:
+            type: object
+            properties:
+              status:
+                type: string
+                enum: [up, down]
+              latency_ms:
+                type: number
+              error:
+                type: string
+    Version:
+      type: object
+      properties:
+        version:
+          type: string
+        build:
+          type: string
+        go:
+          type: string
+    Server:
+      type: object
+      properties:
+        id:
+          type: string
+        type:
+          type: string
+        daemon_type:
+          type: string
+        version:
+          type: string
+        url:
+          type: string
+        config_url:
+          type: string
+        zones_url:
+          type: string
+    SearchResult:
+      type: object
+      properties:
+        content:
+          type: string
+        disabled:
+          type: boolean
+        name:
+          type: string
+        object_type:
+          type: integer
+          description: 1 all, 2 zone, 3 record, 4 comment
+        zone_id:
+          type: string
+        zone:
+          type: string
+        type:
+          type: string
+        ttl:
+          type: integer
+    Zone:
+      type: object
+      properties:
+        id:
+          type: string
+        name:
+          type: string
+        type:
+          type: string
+        url:
+          type: string
+        kind:
+          $ref: "#/components/schemas/ZoneKind"
+        rrsets:
+          type: array
+          items:
+            $ref: "#/components/schemas/RRSet"
+        serial:
+          type: integer
+        notified_serial:
+          type: integer
+        masters:
+          type: array
+          items:
+            type: string
+        dnssec:
+          type: boolean
+        nsec3param:
+          type: string
+        nsec3narrow:
+          type: boolean
+        presigned:
+          type: boolean
+        soa_edit:
+          type: string
+        soa_edit_api:
+          type: string
+        api_rectify:
+          type: boolean
+        account:
+          type: string
+        nameservers:
+          type: array
+          items:
+            type: string
+    NewZone:
+      allOf:
+        - $ref: "#/components/schemas/Zone"
+        - type: object
+          description: >
+            Kind defaults to the template kind or Native.
+            Slave zones require masters and can't have RRsets or nameservers.
+          required: [name]
+          properties:
+            name:
+              $ref: "#/components/schemas/ZoneName"
+            masters:
+              type: array
+              items:
+                type: string
+                description: IP address with optional port, IPv6 with port in brackets
+                pattern: "^[a-fA-F0-9.:\\[\\]]+$"
+            master_tsig_key_ids:
+              type: array
+              description: TSIG keys allowed to transfer the zone from this server
+              items:
+                type: string
+            slave_tsig_key_ids:
+              type: array
+              description: TSIG key to transfer the Slave zone from masters
+              maxItems: 1
+              items:
+                type: string
+    ZonePatch:
+      type: object
+      required: [rrsets]
+      properties:
+        rrsets:
+          type: array
+          minItems: 1
+          items:
+            allOf:
+              - $ref: "#/components/schemas/RRSet"
+              - type: object
+                required: [name, type, changetype]
+    ZoneKind:
+      type: string
+      enum: [Native, Master, Slave]
+    ZoneName:
+      type: string
+      minLength: 1
+      maxLength: 254
+      pattern: "^[a-zA-Z0-9._-]+$"
+    RRName:
+      type: string
+      minLength: 1
+      maxLength: 254
+      pattern: "^[a-zA-Z0-9.*_-]+$"
+    RRSet:
+      type: object
+      properties:
+        name:
+          $ref: "#/components/schemas/RRName"
+        type:
+          type: string
+          pattern: "^[A-Z0-9]+$"
+        ttl:
+          type: integer
+          minimum: 0
+        changetype:
+          type: string
+          enum: [REPLACE, DELETE]
+        records:
+          type: array
+          items:
+            $ref: "#/components/schemas/Record"
+        comments:
+          type: array
+          items:
+            $ref: "#/components/schemas/Comment"
+    RRSetPut:
+      type: object
+      required: [records]
+      properties:
+        ttl:
+          type: integer
+          minimum: 0
+        records:
+          type: array
+          minItems: 1
+          items:
+            $ref: "#/components/schemas/Record"
+        comments:
+          type: array
+          items:
+            $ref: "#/components/schemas/Comment"
+    Record:
+      type: object
+      required: [content]
+      properties:
+        content:
+          type: string
+        disabled:
+          type: boolean
+        set-ptr:
+          type: boolean
+    Comment:
+      type: object
+      properties:
+        content:
+          type: string
+        account:
+          type: string
+        modified_at:
+          type: integer
+    ZoneTransfer:
+      type: object
+      properties:
+        zone:
+          type: string
+        server:
+          type: string
+          description: Address of DNS listener the zone was transferred from
+        serial:
+          type: integer
+          description: SOA serial served over DNS
+        api_serial:
+          type: integer
+          description: Serial reported by PowerDNS API
+        rrsets:
+          type: array
+          items:
+            $ref: "#/components/schemas/RRSet"
+    ZoneConsistency:
+      type: object
+      properties:
+        zone:
+          type: string
+        consistent:
+          type: boolean
+          description: All nodes have the same zone state
+        serial:
+          type: integer
+          description: SOA serial most of nodes have
+        rrsets_hash:
+          type: string
+          description: Hash of RRsets most of nodes have
+        nodes:
+          type: array
+          items:
+            type: object
+            properties:
+              node:
+                type: string
+              status:
+                type: integer
+              error:
+                type: string
+              serial:
+                type: integer
+              rrsets_hash:
+                type: string
+              in_sync:
+                type: boolean
+    VerifyRequest:
+      type: object
+      required: [rrsets]
+      properties:
+        rrsets:
+          type: array
+          minItems: 1
+          maxItems: 50
+          items:
+            type: object
+            required: [name, type]
+            properties:
+              name:
+                type: string
+              type:
+                type: string
+              records:
+                type: array
+                description: Expected content of records in any order, empty if the RRset must not exist
+                items:
+                  type: string
+        timeout:
+          type: integer
+          minimum: 0
+          maximum: 300
+          default: 0
+          description: Seconds to repeat checks until all nodes answer as expected
+    ZoneVerification:
+      type: object
+      properties:
+        zone:
+          type: string
+        converged:
+          type: boolean
+          description: All servers of all nodes answer as expected
+        attempts:
+          type: integer
+        nodes:
+          type: array
+          items:
+            $ref: "#/components/schemas/NodeVerification"
+    NodeVerification:
+      type: object
+      properties:
+        node:
+          type: string
+        status:
+          type: integer
+        error:
+          type: string
+        converged:
+          type: boolean
+        checks:
+          type: array
+          items:
+            type: object
+            properties:
+              name:
+                type: string
+              type:
+                type: string
+              server:
+                type: string
+                enum: [auth, recursor]
+              ok:
+                type: boolean
+              rcode:
+                type: string
+              records:
+                type: array
+                items:
+                  type: string
+              error:
+                type: string
+    ACMEChallengeRequest:
+      type: object
+      required: [name, value]
+      properties:
+        name:
+          type: string
+          minLength: 1
+          description: Validated domain, may have a wildcard prefix or start with _acme-challenge label
+        value:
+          $ref: "#/components/schemas/ACMEChallengeValue"
+        timeout:
+          type: integer
+          minimum: 0
+          maximum: 300
+          default: 60
+          description: Seconds to wait until all nodes serve the records, checks are done once if 0
+    ACMEChallengeValue:
+      type: string
+      pattern: "^[A-Za-z0-9_-]{1,255}$"
+      description: Base64url-encoded key authorization digest
+    ACMEChallenge:
+      type: object
+      properties:
+        name:
+          type: string
+          description: Name of challenge TXT records
+        zone:
+          type: string
+          description: The closest authoritative zone of the name
+        values:
+          type: array
+          description: Values of enabled challenge records after the change
+          items:
+            type: string
+        visible:
+          type: boolean
+          description: All servers of all nodes answer with the values
+        attempts:
+          type: integer
+        nodes:
+          type: array
+          items:
+            $ref: "#/components/schemas/NodeVerification"
+    Lookup:
+      type: object
+      properties:
+        name:
+          type: string
+        type:
+          type: string
+        results:
+          type: array
+          items:
+            type: object
+            properties:
+              server:
+                type: string
+                enum: [auth, recursor]
+              error:
+                type: string
+              answer:
+                $ref: "#/components/schemas/DNSAnswer"
+    DNSAnswer:
+      type: object
+      properties:
+        server:
+          type: string
+          description: Address of DNS listener the query was sent to
+        rcode:
+          type: string
+        flags:
+          type: object
+          properties:
+            aa:
+              type: boolean
+            tc:
+              type: boolean
+            rd:
+              type: boolean
+            ra:
+              type: boolean
+            ad:
+              type: boolean
+            cd:
+              type: boolean
+        rtt_ms:
+          type: number
+        question:
+          type: array
+          items:
+            $ref: "#/components/schemas/DNSRecord"
+        answer:
+          type: array
+          items:
+            $ref: "#/components/schemas/DNSRecord"
+        authority:
+          type: array
+          items:
+            $ref: "#/components/schemas/DNSRecord"
+        additional:
+          type: array
+          items:
+            $ref: "#/components/schemas/DNSRecord"
+    DNSRecord:
+      type: object
+      properties:
+        name:
+          type: string
+        type:
+          type: string
+        ttl:
+          type: integer
+        content:
+          type: string
+    ZoneTSIGKeys:
+      type: object
+      properties:
+        master_tsig_key_ids:
+          type: array
+          description: TSIG keys allowed to transfer the zone from this server
+          items:
+            type: string
+        slave_tsig_key_ids:
+          type: array
+          description: TSIG key to transfer the Slave zone from masters
+          maxItems: 1
+          items:
+            type: string
+    TSIGKey:
+      type: object
+      properties:
+        id:
+          type: string
+        name:
+          type: string
+        algorithm:
+          $ref: "#/components/schemas/TSIGAlgorithm"
+        key:
+          type: string
+          description: Base64 encoded secret, returned only on creation and update
+        type:
+          type: string
+    NewTSIGKey:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+          minLength: 1
+          maxLength: 254
+          pattern: "^[a-zA-Z0-9._-]+$"
+        algorithm:
+          $ref: "#/components/schemas/TSIGAlgorithm"
+        key:
+          type: string
+          description: Base64 encoded secret, generated if omitted
+    TSIGKeyUpdate:
+      type: object
+      properties:
+        name:
+          type: string
+          description: Name of the key, keys can't be renamed
+        algorithm:
+          $ref: "#/components/schemas/TSIGAlgorithm"
+        key:
+          type: string
+          description: Base64 encoded secret, generated if omitted
+    TSIGAlgorithm:
+      type: string
+      description: HMAC algorithm, hmac-sha256 by default
+      enum: [hmac-md5, hmac-sha1, hmac-sha224, hmac-sha256, hmac-sha384, hmac-sha512]
+    ForwardZone:
+      type: object
+      required: [name, nameservers]
+      properties:
+        name:
+          $ref: "#/components/schemas/ZoneName"
+        nameservers:
+          type: array
+          minItems: 1
+          items:
+            type: string
+            description: IP address with optional port
+            pattern: "^[a-zA-Z0-9.:]+$"
+    ForwardZones:
+      type: array
+      minItems: 1
+      items:
+        $ref: "#/components/schemas/ForwardZone"
+    AuditEvent:
+      type: object
+      properties:
+        time:
+          type: string
+          format: date-time
+        request_id:
+          type: string
+        node:
+          type: string
+        actor:
+          type: string
+        source_ip:
+          type: string
+        method:
+          type: string
+        path:
+          type: string
+        action:
+          type: string
+        server_id:
+          type: string
+        zone:
+          type: string
+        changes:
+          type: array
+          items:
+            type: object
+        forward_zones:
+          type: array
+          items:
+            $ref: "#/components/schemas/ForwardZone"
+        tsig_key:
+          $ref: "#/components/schemas/TSIGKey"
+        result:
+          type: string
+          enum: [success, failure, denied]
+        status:
+          type: integer
+        error:
+          type: string
+        nodes:
+          type: array
+          items:
+            type: object
+`
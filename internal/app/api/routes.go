@@ -0,0 +1,137 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// publicRoutes are handlers of the public API documented in OpenAPI specification
+type publicRoutes struct {
+	Health        http.HandlerFunc
+	Live          http.HandlerFunc
+	Ready         http.HandlerFunc
+	InternalReady http.HandlerFunc
+	ListServers   http.HandlerFunc
+	ListServer    http.HandlerFunc
+	SearchData    http.HandlerFunc
+	Version       http.HandlerFunc
+	OpenAPI       http.HandlerFunc
+	Lookup        http.HandlerFunc
+	ListEvents    http.HandlerFunc
+
+	ListForwardZones http.HandlerFunc
+	ListForwardZone  http.HandlerFunc
+	AddForwardZones  http.HandlerFunc
+	DelForwardZones  http.HandlerFunc
+	PatchForwardZone http.HandlerFunc
+	DelForwardZone   http.HandlerFunc
+
+	ListZones          http.HandlerFunc
+	ListZone           http.HandlerFunc
+	AddZone            http.HandlerFunc
+	PatchZone          http.HandlerFunc
+	DeleteZone         http.HandlerFunc
+	GetRRSet           http.HandlerFunc
+	PutRRSet           http.HandlerFunc
+	DeleteRRSet        http.HandlerFunc
+	GetZoneTSIGKeys    http.HandlerFunc
+	PutZoneTSIGKeys    http.HandlerFunc
+	TransferZone       http.HandlerFunc
+	GetZoneConsistency http.HandlerFunc
+	AxfrRetrieve       http.HandlerFunc
+	Notify             http.HandlerFunc
+	Rectify            http.HandlerFunc
+	VerifyZone         http.HandlerFunc
+
+	ListTSIGKeys  http.HandlerFunc
+	GetTSIGKey    http.HandlerFunc
+	AddTSIGKey    http.HandlerFunc
+	UpdateTSIGKey http.HandlerFunc
+	DeleteTSIGKey http.HandlerFunc
+
+	AddChallenge    http.HandlerFunc
+	DeleteChallenge http.HandlerFunc
+	FlushCache      http.HandlerFunc
+	FlushAuthCache  http.HandlerFunc
+}
+
+// registerPublicRoutes adds routes of the public API to the router.
+// With auth middleware, i.e. LDAP authorization, changes and reading of audit events are authorized,
+// the zoneType variable of their paths is the object type of permissions.
+func registerPublicRoutes(router *mux.Router, h publicRoutes, auth mux.MiddlewareFunc) {
+	router.HandleFunc("/api/v1/health", h.Health).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/health/live", h.Live).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/health/ready", h.Ready).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/health/internal", h.InternalReady).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers", h.ListServers).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}", h.ListServer).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/search-data", h.SearchData).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/forward-zones", h.ListForwardZones).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/forward-zones/{zoneID}", h.ListForwardZone).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/zones", h.ListZones).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}", h.ListZone).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/version", h.Version).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/openapi.json", h.OpenAPI).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/rrsets/{name}/{type}", h.GetRRSet).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/tsigkeys", h.GetZoneTSIGKeys).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/axfr", h.TransferZone).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/consistency", h.GetZoneConsistency).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/lookup", h.Lookup).Methods(http.MethodGet)
+	// TSIG keys are returned without secrets
+	router.HandleFunc("/api/v1/servers/{serverID}/tsigkeys", h.ListTSIGKeys).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/servers/{serverID}/tsigkeys/{keyID}", h.GetTSIGKey).Methods(http.MethodGet)
+	// The zone of challenge records is found by the handler, so it authorizes requests itself
+	router.HandleFunc("/api/v1/acme/challenge", h.AddChallenge).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/acme/challenge", h.DeleteChallenge).Methods(http.MethodDelete)
+
+	authRouter, readRouter := router, router
+	if auth != nil {
+		authRouter = router.Methods(http.MethodDelete, http.MethodPatch, http.MethodPost, http.MethodPut).Subrouter()
+		authRouter.Use(auth)
+		// Audit events have user IDs and changes of all zones, reading them requires read permission for audit
+		readRouter = router.Methods(http.MethodGet).Subrouter()
+		readRouter.Use(auth)
+	}
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}", h.AddForwardZones).Methods(http.MethodPost)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}", h.DelForwardZones).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}/{zoneID}", h.PatchForwardZone).Methods(http.MethodPatch)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:forward-zones}/{zoneID}", h.DelForwardZone).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}", h.AddZone).Methods(http.MethodPost)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}", h.PatchZone).Methods(http.MethodPatch)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}", h.DeleteZone).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}/rrsets/{name}/{type}", h.PutRRSet).Methods(http.MethodPut)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}/rrsets/{name}/{type}", h.DeleteRRSet).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}/axfr-retrieve", h.AxfrRetrieve).Methods(http.MethodPut)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}/notify", h.Notify).Methods(http.MethodPut)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}/rectify", h.Rectify).Methods(http.MethodPut)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}/tsigkeys", h.PutZoneTSIGKeys).Methods(http.MethodPut)
+	// Verification only queries DNS servers, but repeated checks load servers of all nodes
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:zones}/{zoneID}/verify", h.VerifyZone).Methods(http.MethodPost)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:tsigkeys}", h.AddTSIGKey).Methods(http.MethodPost)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:tsigkeys}/{keyID}", h.UpdateTSIGKey).Methods(http.MethodPut)
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:tsigkeys}/{keyID}", h.DeleteTSIGKey).Methods(http.MethodDelete)
+	// Flushing cache of all nodes affects resolution of any name, it requires replace permission for cache
+	authRouter.HandleFunc("/api/v1/servers/{serverID}/{zoneType:cache}/flush", h.FlushCache).Methods(http.MethodPut)
+	readRouter.HandleFunc("/api/v1/{zoneType:audit}", h.ListEvents).Methods(http.MethodGet)
+	if auth != nil {
+		// Authorization cache exists with LDAP only, flushing it requires delete permission for all zones
+		authRouter.HandleFunc("/api/v1/auth/cache", h.FlushAuthCache).Methods(http.MethodDelete)
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+	"github.com/mixanemca/pdns-api/internal/app/api/openapi"
+	"github.com/stretchr/testify/require"
+)
+
+// routeVar is a variable of mux path template, a variable with pattern is a fixed path segment like zoneType
+var routeVar = regexp.MustCompile(`\{[^}:]+(:([^}]+))?\}`)
+
+// TestPublicRoutesInSpec checks every public route is documented, requests of undocumented ones
+// pass validation middleware unchecked. /metrics isn't a part of the API.
+func TestPublicRoutesInSpec(t *testing.T) {
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+	specRouter, err := gorillamux.NewRouter(spec)
+	require.NoError(t, err)
+
+	var h publicRoutes
+	noop := reflect.ValueOf(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for v, i := reflect.ValueOf(&h).Elem(), 0; i < v.NumField(); i++ {
+		v.Field(i).Set(noop)
+	}
+	auth := func(next http.Handler) http.Handler { return next }
+
+	for name, auth := range map[string]mux.MiddlewareFunc{"without auth": nil, "with auth": auth} {
+		t.Run(name, func(t *testing.T) {
+			router := mux.NewRouter()
+			registerPublicRoutes(router, h, auth)
+			var routes int
+			err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+				tmpl, err := route.GetPathTemplate()
+				if err != nil {
+					// Subrouter matching methods only
+					return nil
+				}
+				methods, err := route.GetMethods()
+				require.NoError(t, err, tmpl)
+				path := routeVar.ReplaceAllStringFunc(tmpl, func(v string) string {
+					if m := routeVar.FindStringSubmatch(v); m[2] != "" {
+						return m[2]
+					}
+					return "example.com."
+				})
+				for _, method := range methods {
+					req := httptest.NewRequest(method, path, nil)
+					// Routes of subrouters also have methods of the subrouter, only their own methods match
+					if !route.Match(req, &mux.RouteMatch{}) {
+						continue
+					}
+					_, _, err := specRouter.FindRoute(req)
+					require.NoError(t, err, "%s %s", method, tmpl)
+					routes++
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			require.NotZero(t, routes)
+		})
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"net/http"
 
 	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/health"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 )
 
@@ -31,28 +33,46 @@ type alive struct {
 
 type HealthHandler struct {
 	config config.Config
+	checks []health.Check
 }
 
-func NewHealthHandler(c config.Config) *HealthHandler {
-	return &HealthHandler{config: c}
+// NewHealthHandler returns new HealthHandler with readiness checks of the role dependencies
+func NewHealthHandler(c config.Config, checks ...health.Check) *HealthHandler {
+	return &HealthHandler{config: c, checks: checks}
 }
 
-// Health return json with alive status
+// Health return json with alive status.
+// Deprecated: kept for compatibility, use Live.
 func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
-	// timer := getLabeledResponseTimePeersHistogramTimer(s.Config.Environment, s.Hostname, r.URL.Path, r.Method)
-	// defer timer.ObserveDuration()
+	h.Live(w, r)
+}
 
-	// TODO: add real checks
+// Live return json with alive status, if the process is able to serve requests
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
 	a := alive{
 		Status:   true,
 		Hostname: network.GetHostname(),
 	}
+	writeHealth(w, http.StatusOK, a)
+}
+
+// Ready checks all dependencies and return json with status and latency of every check.
+// Responds with 503 if any check is down.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := health.Run(r.Context(), network.GetHostname(), h.checks)
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(w, status, report)
+}
+
+func writeHealth(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(a)
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		// countError(h.config.Environment, infrastructure.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		network.WriteErrorResponse(w, errors.Wrap(err, "encoding JSON response"))
 	}
-	// countCall(h.config.Environment, infrastucture.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
 }
@@ -17,13 +17,16 @@ limitations under the License.
 package v1
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/health"
 	"github.com/stretchr/testify/require"
 )
 
@@ -49,3 +52,27 @@ func TestHealth(t *testing.T) {
 	err = json.Unmarshal(rr.Body.Bytes(), &a)
 	require.NoError(t, err)
 }
+
+func TestReady(t *testing.T) {
+	conf := config.Config{}
+	up := health.Check{Name: "up", Fn: func(ctx context.Context) error { return nil }}
+	down := health.Check{Name: "down", Fn: func(ctx context.Context) error { return errors.New("unreachable") }}
+
+	req, err := http.NewRequest("GET", "/api/v1/health/ready", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	NewHealthHandler(conf, up).Ready(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	NewHealthHandler(conf, up, down).Ready(rr, req)
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	var report health.Report
+	err = json.Unmarshal(rr.Body.Bytes(), &report)
+	require.NoError(t, err)
+	require.Equal(t, health.StatusDown, report.Status)
+	require.Equal(t, health.StatusUp, report.Checks["up"].Status)
+	require.Equal(t, "unreachable", report.Checks["down"].Error)
+}
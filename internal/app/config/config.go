@@ -26,17 +26,23 @@ const (
 )
 
 type Config struct {
-	Role         string       `mapstructure:"role"`
-	DataCenter   string       `mapstructure:"datacenter"`
-	Environment  string       `mapstructure:"environment"`
-	PublicHTTP   HTTPConfig   `mapstructure:"public-http"`
-	Log          LogConfig    `mapstructure:"log"`
-	PDNS         PDNSConfig   `mapstructure:"pdns"`
-	Consul       ConsulConfig `mapstructure:"consul"`
-	LDAP         LDAPConfig   `mapstructure:"ldap"`
-	InternalHTTP HTTPConfig   `mapstructure:"internal-http"`
-	Version      string
-	Build        string
+	Role          string              `mapstructure:"role"`
+	DataCenter    string              `mapstructure:"datacenter"`
+	Environment   string              `mapstructure:"environment"`
+	PublicHTTP    HTTPConfig          `mapstructure:"public-http"`
+	Log           LogConfig           `mapstructure:"log"`
+	PDNS          PDNSConfig          `mapstructure:"pdns"`
+	Consul        ConsulConfig        `mapstructure:"consul"`
+	LDAP          LDAPConfig          `mapstructure:"ldap"`
+	InternalHTTP  HTTPConfig          `mapstructure:"internal-http"`
+	Audit         AuditConfig         `mapstructure:"audit"`
+	Tracing       TracingConfig       `mapstructure:"tracing"`
+	Idempotency   IdempotencyConfig   `mapstructure:"idempotency"`
+	ZoneTemplates ZoneTemplatesConfig `mapstructure:"zone-templates"`
+	DoH           DoHConfig           `mapstructure:"doh"`
+	DDNS          DDNSConfig          `mapstructure:"ddns"`
+	Version       string
+	Build         string
 }
 
 type HTTPConfig struct {
@@ -64,12 +70,18 @@ type RecursorConfig struct {
 	BaseURL string `mapstructure:"base-url"`
 	ApiKey  string `mapstructure:"api-key"`
 	Timeout int    `mapstructure:"timeout"`
+	// DNSAddress is host:port of PowerDNS Recursor DNS listener
+	DNSAddress string `mapstructure:"dns-address"`
 }
 
 type AuthConfig struct {
 	BaseURL string `mapstructure:"base-url"`
 	ApiKey  string `mapstructure:"api-key"`
 	Timeout int    `mapstructure:"timeout"`
+	// DNSAddress is host:port of PowerDNS Authoritative DNS listener
+	DNSAddress string `mapstructure:"dns-address"`
+	// AXFRTSIGKey is a name of TSIG key in PowerDNS to sign zone transfers, transfers are not signed if empty
+	AXFRTSIGKey string `mapstructure:"axfr-tsig-key"`
 }
 
 // LDAPConfig represents LDAP settings in config
@@ -82,6 +94,188 @@ type LDAPConfig struct {
 	SearchBase   string `mapstructure:"search-base"`
 	SearchFilter string `mapstructure:"search-filter"`
 	Debug        bool   `mapstructure:"debug"`
+	// StartTLS upgrades ldap:// connections to TLS
+	StartTLS bool `mapstructure:"start-tls"`
+	// CAFile is a PEM bundle used to verify the LDAP server certificate
+	CAFile             string            `mapstructure:"ca-file"`
+	InsecureSkipVerify bool              `mapstructure:"insecure-skip-verify"`
+	PoolSize           int               `mapstructure:"pool-size"`
+	Retries            int               `mapstructure:"retries"`
+	Timeout            LDAPTimeoutConfig `mapstructure:"timeout"`
+	Breaker            LDAPBreakerConfig `mapstructure:"breaker"`
+	Cache              LDAPCacheConfig   `mapstructure:"cache"`
+}
+
+// LDAPTimeoutConfig represents LDAP timeouts in seconds
+type LDAPTimeoutConfig struct {
+	Dial    int `mapstructure:"dial"`
+	Request int `mapstructure:"request"`
+}
+
+// LDAPCacheConfig represents authorization decisions cache settings
+type LDAPCacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// AllowTTL is a number of seconds to cache allowed decisions
+	AllowTTL int `mapstructure:"allow-ttl"`
+	// DenyTTL is a number of seconds to cache denied decisions
+	DenyTTL    int `mapstructure:"deny-ttl"`
+	MaxEntries int `mapstructure:"max-entries"`
+}
+
+// LDAPBreakerConfig represents LDAP circuit breaker settings
+type LDAPBreakerConfig struct {
+	// Threshold is a number of consecutive failures that opens the breaker
+	Threshold int `mapstructure:"threshold"`
+	// Timeout is a number of seconds before the open breaker lets a probe through
+	Timeout int `mapstructure:"timeout"`
+}
+
+// AuditConfig represents audit log settings in config
+type AuditConfig struct {
+	Enabled bool               `mapstructure:"enabled"`
+	File    AuditFileConfig    `mapstructure:"file"`
+	Syslog  AuditSyslogConfig  `mapstructure:"syslog"`
+	Webhook AuditWebhookConfig `mapstructure:"webhook"`
+	// TrustedProxies are IP addresses and CIDR networks of proxies allowed to set
+	// X-Forwarded-For header for the source IP of events
+	TrustedProxies []string `mapstructure:"trusted-proxies"`
+}
+
+// AuditFileConfig represents JSON lines audit log file settings
+type AuditFileConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+}
+
+// AuditSyslogConfig represents syslog audit sink settings.
+// Empty network and address means local syslog daemon.
+type AuditSyslogConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	Tag     string `mapstructure:"tag"`
+}
+
+// AuditWebhookConfig represents webhook audit sink settings
+type AuditWebhookConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	URL     string `mapstructure:"url"`
+	// Timeout in seconds
+	Timeout   int `mapstructure:"timeout"`
+	QueueSize int `mapstructure:"queue-size"`
+}
+
+// TracingConfig represents OpenTelemetry tracing settings in config
+type TracingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Exporter is otlp or stdout
+	Exporter string `mapstructure:"exporter"`
+	// SampleRatio is a fraction of traces started by pdns-api to sample
+	SampleRatio float64             `mapstructure:"sample-ratio"`
+	OTLP        TracingOTLPConfig   `mapstructure:"otlp"`
+	Stdout      TracingStdoutConfig `mapstructure:"stdout"`
+}
+
+// TracingOTLPConfig represents OTLP over HTTP exporter settings
+type TracingOTLPConfig struct {
+	// Endpoint is host:port of OpenTelemetry collector
+	Endpoint string `mapstructure:"endpoint"`
+	Insecure bool   `mapstructure:"insecure"`
+}
+
+// TracingStdoutConfig represents stdout exporter settings.
+// Spans are written to the file if path is set.
+type TracingStdoutConfig struct {
+	Path string `mapstructure:"path"`
+}
+
+// IdempotencyConfig represents Idempotency-Key header settings
+type IdempotencyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store is consul, shared by all API nodes, or file, local for the node
+	Store string `mapstructure:"store"`
+	// TTL is a number of seconds to keep responses for replay
+	TTL int `mapstructure:"ttl"`
+	// InProgressTTL is a number of seconds a request in progress locks its key,
+	// after that the key is free again if the node has failed to save the response
+	InProgressTTL int `mapstructure:"in-progress-ttl"`
+	// ConsulPrefix is a Consul KV prefix for the consul store
+	ConsulPrefix string `mapstructure:"consul-prefix"`
+	// Path is a JSON file for the file store
+	Path string `mapstructure:"path"`
+}
+
+// ZoneTemplatesConfig represents named templates for new zones.
+// Templates in Consul KV override templates from config with the same name.
+type ZoneTemplatesConfig struct {
+	// ConsulPrefix is a Consul KV prefix with a JSON template in every key, empty disables Consul
+	ConsulPrefix string `mapstructure:"consul-prefix"`
+	// Templates are keyed by name, names are case-insensitive
+	Templates map[string]ZoneTemplate `mapstructure:"templates"`
+}
+
+// ZoneTemplate represents defaults for a new zone. Fields of the created zone override them.
+type ZoneTemplate struct {
+	// Kind is Native or Master
+	Kind        string          `mapstructure:"kind" json:"kind,omitempty"`
+	Nameservers []string        `mapstructure:"nameservers" json:"nameservers,omitempty"`
+	SOA         ZoneTemplateSOA `mapstructure:"soa" json:"soa,omitempty"`
+	SOAEditAPI  string          `mapstructure:"soa-edit-api" json:"soa_edit_api,omitempty"`
+	// Metadata kinds are upper-cased, config keys are lower-cased by the parser
+	Metadata map[string][]string  `mapstructure:"metadata" json:"metadata,omitempty"`
+	Records  []ZoneTemplateRecord `mapstructure:"records" json:"records,omitempty"`
+	DNSSEC   ZoneTemplateDNSSEC   `mapstructure:"dnssec" json:"dnssec,omitempty"`
+}
+
+// ZoneTemplateSOA represents SOA record of a new zone, it is not added if primary is empty.
+// Names not ending with a dot are relative to the zone.
+type ZoneTemplateSOA struct {
+	Primary    string `mapstructure:"primary" json:"primary,omitempty"`
+	Hostmaster string `mapstructure:"hostmaster" json:"hostmaster,omitempty"`
+	// Timers in seconds
+	Refresh int `mapstructure:"refresh" json:"refresh,omitempty"`
+	Retry   int `mapstructure:"retry" json:"retry,omitempty"`
+	Expire  int `mapstructure:"expire" json:"expire,omitempty"`
+	Minimum int `mapstructure:"minimum" json:"minimum,omitempty"`
+	TTL     int `mapstructure:"ttl" json:"ttl,omitempty"`
+}
+
+// ZoneTemplateRecord represents a baseline RRset of a new zone
+type ZoneTemplateRecord struct {
+	// Name is relative to the zone, @ or empty is the zone apex
+	Name    string   `mapstructure:"name" json:"name,omitempty"`
+	Type    string   `mapstructure:"type" json:"type"`
+	TTL     int      `mapstructure:"ttl" json:"ttl"`
+	Records []string `mapstructure:"records" json:"records"`
+}
+
+// ZoneTemplateDNSSEC represents DNSSEC policy of a new zone.
+// Keys are generated by PowerDNS with default-ksk-algorithm and default-zsk-algorithm.
+type ZoneTemplateDNSSEC struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// NSEC3Param enables NSEC3 instead of NSEC, e.g. "1 0 0 -"
+	NSEC3Param  string `mapstructure:"nsec3param" json:"nsec3param,omitempty"`
+	NSEC3Narrow bool   `mapstructure:"nsec3narrow" json:"nsec3narrow,omitempty"`
+}
+
+// DoHConfig represents DNS-over-HTTPS (RFC 8484) settings of api role, DoH is served on the public listener
+type DoHConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Path of DoH URI template without variables
+	Path string `mapstructure:"path"`
+	// ZonesRefresh is a number of seconds between reloads of authoritative zones from PowerDNS API
+	ZonesRefresh int `mapstructure:"zones-refresh"`
+}
+
+// DDNSConfig represents DNS UPDATE (RFC 2136) listener settings of api role
+type DDNSConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Address string `mapstructure:"listen-address"`
+	Port    string `mapstructure:"listen-port"`
+	// SetPTR adds PTR records of added A and AAAA records, like set-ptr of records in API
+	SetPTR bool `mapstructure:"set-ptr"`
+	// KeysRefresh is a number of seconds between reloads of TSIG keys from PowerDNS API
+	KeysRefresh int `mapstructure:"keys-refresh"`
 }
 
 type ConsulConfig struct {
@@ -108,9 +302,50 @@ func Init(version, build string) (*Config, error) {
 	viper.SetDefault("internal-http.timeout.write", 10)
 	viper.SetDefault("pdns.auth.base-url", "http://127.0.0.1:8081")
 	viper.SetDefault("pdns.auth.timeout", 10)
+	viper.SetDefault("pdns.auth.dns-address", "127.0.0.1:5353")
 	viper.SetDefault("pdns.recursor.base-url", "http://127.0.0.1:8082")
 	viper.SetDefault("pdns.recursor.timeout", 10)
+	viper.SetDefault("pdns.recursor.dns-address", "127.0.0.1:53")
+	viper.SetDefault("doh.enabled", false)
+	viper.SetDefault("doh.path", "/dns-query")
+	viper.SetDefault("doh.zones-refresh", 30)
+	viper.SetDefault("ddns.enabled", false)
+	viper.SetDefault("ddns.listen-address", "127.0.0.1")
+	viper.SetDefault("ddns.listen-port", 5300)
+	viper.SetDefault("ddns.set-ptr", false)
+	viper.SetDefault("ddns.keys-refresh", 30)
 	viper.SetDefault("ldap.enabled", false)
+	viper.SetDefault("ldap.start-tls", false)
+	viper.SetDefault("ldap.pool-size", 10)
+	viper.SetDefault("ldap.retries", 2)
+	viper.SetDefault("ldap.timeout.dial", 5)
+	viper.SetDefault("ldap.timeout.request", 5)
+	viper.SetDefault("ldap.breaker.threshold", 5)
+	viper.SetDefault("ldap.breaker.timeout", 30)
+	viper.SetDefault("ldap.cache.enabled", true)
+	viper.SetDefault("ldap.cache.allow-ttl", 60)
+	viper.SetDefault("ldap.cache.deny-ttl", 10)
+	viper.SetDefault("ldap.cache.max-entries", 10000)
+	viper.SetDefault("audit.enabled", true)
+	viper.SetDefault("audit.file.enabled", true)
+	viper.SetDefault("audit.file.path", "/var/log/pdns-api/audit.log")
+	viper.SetDefault("audit.syslog.enabled", false)
+	viper.SetDefault("audit.syslog.tag", "pdns-api")
+	viper.SetDefault("audit.webhook.enabled", false)
+	viper.SetDefault("audit.webhook.timeout", 5)
+	viper.SetDefault("audit.webhook.queue-size", 1000)
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.exporter", "otlp")
+	viper.SetDefault("tracing.sample-ratio", 1.0)
+	viper.SetDefault("tracing.otlp.endpoint", "127.0.0.1:4318")
+	viper.SetDefault("tracing.otlp.insecure", true)
+	viper.SetDefault("idempotency.enabled", true)
+	viper.SetDefault("idempotency.store", "consul")
+	viper.SetDefault("idempotency.ttl", 86400)
+	viper.SetDefault("idempotency.in-progress-ttl", 600)
+	viper.SetDefault("idempotency.consul-prefix", "pdns-api/idempotency")
+	viper.SetDefault("idempotency.path", "/var/lib/pdns-api/idempotency.json")
+	viper.SetDefault("zone-templates.consul-prefix", "pdns-api/zone-templates")
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
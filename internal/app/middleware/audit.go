@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+)
+
+// maxAuditErrorSize limits size of error response body saved to audit event
+const maxAuditErrorSize = 4096
+
+type auditRecorder interface {
+	NewEvent(r *http.Request) *audit.Event
+	Enabled() bool
+	Record(e *audit.Event)
+}
+
+type auditMiddleware struct {
+	recorder auditRecorder
+}
+
+func NewAuditMiddleware(recorder auditRecorder) *auditMiddleware {
+	return &auditMiddleware{recorder: recorder}
+}
+
+// AuditMiddleware records every mutating request to the audit log.
+// Handlers add details to the event from request context, see audit.FromContext.
+func (a *auditMiddleware) AuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !a.recorder.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		e := a.recorder.NewEvent(r)
+		vars := mux.Vars(r)
+		e.SetZone(vars["serverID"], vars["zoneID"])
+		// Handlers set more specific action
+		if route := mux.CurrentRoute(r); route != nil {
+			if tpl, err := route.GetPathTemplate(); err == nil {
+				e.SetAction(fmt.Sprintf("%s %s", r.Method, tpl))
+			}
+		}
+
+		aw := &auditResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(aw, r.WithContext(audit.NewContext(r.Context(), e)))
+
+		e.Finish(aw.Status(), aw.Error())
+		a.recorder.Record(e)
+	})
+}
+
+// auditResponseWriter captures response status and body of errors
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.status >= http.StatusBadRequest && w.body.Len() < maxAuditErrorSize {
+		n := maxAuditErrorSize - w.body.Len()
+		if n > len(b) {
+			n = len(b)
+		}
+		w.body.Write(b[:n])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Error returns message of error response
+func (w *auditResponseWriter) Error() string {
+	var resp network.ErrorResponse
+	if err := json.Unmarshal(w.body.Bytes(), &resp); err == nil {
+		return resp.Message
+	}
+	// Not a JSON error, or truncated
+	return strings.TrimSpace(w.body.String())
+}
+
+// Status returns response status, 200 if handler wrote nothing
+func (w *auditResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
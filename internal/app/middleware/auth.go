@@ -1,13 +1,14 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
-	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
 	"github.com/sirupsen/logrus"
 )
@@ -17,7 +18,7 @@ type errorWriter interface {
 }
 
 type ldapAuth interface {
-	AuthorizeViaLDAP(cnType, zoneType, zone, username string) (bool, error)
+	AuthorizeViaLDAP(ctx context.Context, cnType, zoneType, zone, username string) (bool, error)
 }
 
 type authMiddleware struct {
@@ -37,51 +38,45 @@ func (a *authMiddleware) AuthMiddleware(next http.Handler) http.Handler {
 		vars := mux.Vars(r)
 		zoneType := vars["zoneType"]
 		zoneID := vars["zoneID"]
+		if keyID, ok := vars["keyID"]; ok {
+			// TSIG keys are authorized like zones of tsigkeys type
+			zoneID = keyID
+		}
 		uid := r.Header.Get("X-PDNS-Client-UID")
 
 		if uid == "" {
-			w.WriteHeader(http.StatusUnauthorized)
-			a.stats.CountError(a.config.Environment, network.GetHostname(), r.URL.Path, http.StatusUnauthorized)
+			a.errorWriter.WriteError(w, r.URL.Path, log.ActionLDAPAuthorization, errors.Unauthorized.New("X-PDNS-Client-UID header is required"))
 			return
 		}
 
+		var cnType string
 		switch r.Method {
-		case http.MethodPatch, http.MethodPost:
-			authorized, err := a.ldapAuth.AuthorizeViaLDAP(ldap.CNTypeReplace, zoneType, zoneID, uid)
-			if err != nil {
-				a.logger.WithFields(logrus.Fields{
-					"action":   log.ActionLDAPAuthorization,
-					"zone":     zoneID,
-					"zoneType": zoneType,
-					"uid":      uid,
-				}).Errorf("Failed to authorize user %s for %s: %v", uid, ldap.CNTypeReplace, err)
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				a.stats.CountError(a.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
-				return
-			}
-			if !authorized {
-				w.WriteHeader(http.StatusUnauthorized)
-				a.stats.CountError(a.config.Environment, network.GetHostname(), r.URL.Path, http.StatusUnauthorized)
-				return
-			}
+		case http.MethodPatch, http.MethodPost, http.MethodPut:
+			cnType = ldap.CNTypeReplace
 		case http.MethodDelete:
-			authorized, err := a.ldapAuth.AuthorizeViaLDAP(ldap.CNTypeDelete, zoneType, zoneID, uid)
-			if err != nil {
-				a.logger.WithFields(logrus.Fields{
-					"action":   log.ActionLDAPAuthorization,
-					"zone":     zoneID,
-					"zoneType": zoneType,
-					"uid":      uid,
-				}).Errorf("Failed to authorize user %s for %s: %v", uid, ldap.CNTypeDelete, err)
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				a.stats.CountError(a.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
-				return
-			}
-			if !authorized {
-				w.WriteHeader(http.StatusUnauthorized)
-				a.stats.CountError(a.config.Environment, network.GetHostname(), r.URL.Path, http.StatusUnauthorized)
-				return
-			}
+			cnType = ldap.CNTypeDelete
+		case http.MethodGet:
+			cnType = ldap.CNTypeRead
+		default:
+			// The show must go on...
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		authorized, err := a.ldapAuth.AuthorizeViaLDAP(r.Context(), cnType, zoneType, zoneID, uid)
+		if err != nil {
+			a.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+				"action":   log.ActionLDAPAuthorization,
+				"zone":     zoneID,
+				"zoneType": zoneType,
+				"uid":      uid,
+			}).Errorf("Failed to authorize user %s for %s: %v", uid, cnType, err)
+			a.errorWriter.WriteError(w, r.URL.Path, log.ActionLDAPAuthorization, err)
+			return
+		}
+		if !authorized {
+			a.errorWriter.WriteError(w, r.URL.Path, log.ActionLDAPAuthorization, errors.Forbidden.Newf("user %s has no %s permission for %s %s", uid, cnType, zoneType, zoneID))
+			return
 		}
 
 		// The show must go on...
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/idempotency"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// IdempotencyKeyHeader is set by clients to make retries of mutating requests safe
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed from the store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// maxIdempotentResponseSize limits size of response body saved for replay
+	maxIdempotentResponseSize = 1 << 20
+)
+
+// replayedHeaders are response headers saved for replay
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+type idempotencyStore interface {
+	Reserve(key string, rec *idempotency.Record) (*idempotency.Record, error)
+	Complete(key string, rec idempotency.Record) error
+	Release(key string, rec idempotency.Record) error
+	MaxBodySize() int
+}
+
+type idempotencyMiddleware struct {
+	errorWriter errorWriter
+	logger      *logrus.Logger
+	store       idempotencyStore
+}
+
+func NewIdempotencyMiddleware(errorWriter errorWriter, logger *logrus.Logger, store idempotencyStore) *idempotencyMiddleware {
+	return &idempotencyMiddleware{errorWriter: errorWriter, logger: logger, store: store}
+}
+
+// IdempotencyMiddleware replays the saved response of a mutating request with the same Idempotency-Key.
+// Reusing the key for another request or while the first one is in progress is a conflict.
+// Server errors are not saved, so such requests can be retried with the same key.
+// Neither are authorization and rate limit errors, which depend on the moment of the request
+// and are written by middlewares after this one, and responses with Cache-Control: no-store,
+// like TSIG keys with secrets.
+func (m *idempotencyMiddleware) IdempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			m.errorWriter.WriteError(w, r.URL.Path, log.ActionIdempotency, errors.AddFieldError(
+				errors.BadRequest.Newf("%s header is too long", IdempotencyKeyHeader),
+				IdempotencyKeyHeader, "must be at most 255 characters",
+			))
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = ioutil.ReadAll(r.Body)
+			if err != nil {
+				m.errorWriter.WriteError(w, r.URL.Path, log.ActionIdempotency, errors.BadRequest.Wrap(err, "reading request body"))
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		storeKey := idempotency.Key(r.Header.Get("X-PDNS-Client-UID"), key)
+		rec := idempotency.Record{
+			RequestHash: idempotency.RequestHash(r.Method, r.URL.RequestURI(), body),
+			Created:     time.Now(),
+		}
+		existing, err := m.store.Reserve(storeKey, &rec)
+		if err != nil {
+			m.errorWriter.WriteError(w, r.URL.Path, log.ActionIdempotency, err)
+			return
+		}
+		if existing != nil {
+			m.replay(w, r, rec, existing)
+			return
+		}
+
+		// Responses larger than the store can save are not replayed
+		limit := maxIdempotentResponseSize
+		if max := m.store.MaxBodySize(); max < limit {
+			limit = max
+		}
+		iw := &idempotentResponseWriter{ResponseWriter: w, limit: limit}
+		next.ServeHTTP(iw, r)
+
+		status := iw.Status()
+		if !replayable(status) || iw.overflow || noStore(w.Header()) {
+			err = m.store.Release(storeKey, rec)
+		} else {
+			rec.Status = status
+			rec.Header = make(http.Header)
+			for _, h := range replayedHeaders {
+				if v := w.Header().Get(h); v != "" {
+					rec.Header.Set(h, v)
+				}
+			}
+			rec.Body = iw.body.Bytes()
+			err = m.store.Complete(storeKey, rec)
+		}
+		if err != nil {
+			// The response is already sent, a retry will get a conflict until the record expires
+			m.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+				"action": log.ActionIdempotency,
+			}).Errorf("Failed to save response for %s %s: %v", IdempotencyKeyHeader, key, err)
+		}
+	})
+}
+
+func (m *idempotencyMiddleware) replay(w http.ResponseWriter, r *http.Request, rec idempotency.Record, existing *idempotency.Record) {
+	if existing.RequestHash != rec.RequestHash {
+		m.errorWriter.WriteError(w, r.URL.Path, log.ActionIdempotency, errors.Conflict.Newf("%s was already used for another request", IdempotencyKeyHeader))
+		return
+	}
+	if !existing.Done() {
+		m.errorWriter.WriteError(w, r.URL.Path, log.ActionIdempotency, errors.Conflict.Newf("request with this %s is in progress", IdempotencyKeyHeader))
+		return
+	}
+	for h, v := range existing.Header {
+		w.Header()[h] = v
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(existing.Status)
+	_, _ = w.Write(existing.Body)
+}
+
+// replayable reports whether the response with the status may be saved
+func replayable(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+// noStore reports whether the response must not be saved
+func noStore(h http.Header) bool {
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+	return false
+}
+
+// idempotentResponseWriter captures response status and body for replay
+type idempotentResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	// limit is the size of body saved for replay
+	limit    int
+	overflow bool
+}
+
+func (w *idempotentResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *idempotentResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body.Len()+len(b) > w.limit {
+		w.overflow = true
+	} else {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Status returns response status, 200 if handler wrote nothing
+func (w *idempotentResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
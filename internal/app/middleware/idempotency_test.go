@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/idempotency"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// limitedStore saves response bodies up to maxBodySize, like Consul KV
+type limitedStore struct {
+	idempotency.Store
+	maxBodySize int
+}
+
+func (s limitedStore) MaxBodySize() int {
+	return s.maxBodySize
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	var cfg config.Config
+	cfg.Idempotency = config.IdempotencyConfig{Store: idempotency.StoreFile, TTL: 60, InProgressTTL: 60, Path: filepath.Join(t.TempDir(), "idempotency.json")}
+	fileStore, err := idempotency.NewStore(cfg, nil)
+	require.NoError(t, err)
+	store := &limitedStore{Store: fileStore, maxBodySize: 1024}
+	m := NewIdempotencyMiddleware(responseErrorWriter{}, logrus.New(), store)
+
+	calls := 0
+	status := http.StatusCreated
+	cacheControl := ""
+	h := m.IdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"name":"example.com."}`))
+	}))
+
+	do := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/servers/localhost/zones", strings.NewReader(body))
+		r.Header.Set("X-PDNS-Client-UID", "jdoe")
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		return rr
+	}
+
+	rr := do("abc", `{"name":"example.com"}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Empty(t, rr.Header().Get(IdempotentReplayedHeader))
+
+	// Replay
+	rr = do("abc", `{"name":"example.com"}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, "true", rr.Header().Get(IdempotentReplayedHeader))
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	require.Equal(t, `{"name":"example.com."}`, rr.Body.String())
+	require.Equal(t, 1, calls)
+
+	// Another payload with the same key
+	rr = do("abc", `{"name":"example.org"}`)
+	require.Equal(t, http.StatusConflict, rr.Code)
+	var resp network.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "conflict", resp.Code)
+	require.Equal(t, 1, calls)
+
+	// Requests without key are not tracked
+	do("", `{"name":"example.com"}`)
+	do("", `{"name":"example.com"}`)
+	require.Equal(t, 3, calls)
+
+	// Server errors are not saved
+	status = http.StatusServiceUnavailable
+	rr = do("def", `{"name":"example.net"}`)
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	status = http.StatusCreated
+	rr = do("def", `{"name":"example.net"}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Empty(t, rr.Header().Get(IdempotentReplayedHeader))
+	require.Equal(t, 5, calls)
+
+	// Authorization errors are not saved, the user may be granted permission before retry
+	status = http.StatusForbidden
+	do("jkl", `{"name":"example.info"}`)
+	status = http.StatusCreated
+	rr = do("jkl", `{"name":"example.info"}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Empty(t, rr.Header().Get(IdempotentReplayedHeader))
+	require.Equal(t, 7, calls)
+
+	// Responses with secrets are not saved
+	cacheControl = "private, no-store"
+	do("ghi", `{"name":"transfer"}`)
+	rr = do("ghi", `{"name":"transfer"}`)
+	require.Empty(t, rr.Header().Get(IdempotentReplayedHeader))
+	require.Equal(t, 9, calls)
+	cacheControl = ""
+
+	// Responses larger than the store can save are not saved instead of locking the key
+	store.maxBodySize = 10
+	do("mno", `{"name":"example.biz"}`)
+	rr = do("mno", `{"name":"example.biz"}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Empty(t, rr.Header().Get(IdempotentReplayedHeader))
+	require.Equal(t, 11, calls)
+
+	rr = do(strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+)
+
+type validationMiddleware struct {
+	errorWriter errorWriter
+	router      routers.Router
+	options     *openapi3filter.Options
+}
+
+// NewValidationMiddleware returns middleware validating requests against OpenAPI specification
+func NewValidationMiddleware(errorWriter errorWriter, doc *openapi3.T) (*validationMiddleware, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating OpenAPI router")
+	}
+	return &validationMiddleware{
+		errorWriter: errorWriter,
+		router:      router,
+		options: &openapi3filter.Options{
+			MultiError: true,
+			// Authorization is done by AuthMiddleware
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}, nil
+}
+
+// ValidationMiddleware rejects requests with parameters or body not matching the specification
+// with 400 Bad Request and error for every invalid field.
+// Requests to routes missing in the specification are passed as is.
+func (v *validationMiddleware) ValidationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		vr := r
+		// Handlers always decode body as JSON, so clients may omit Content-Type
+		// or send curl's default application/x-www-form-urlencoded
+		if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+			vr = r.Clone(r.Context())
+			vr.Header.Set("Content-Type", "application/json")
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    vr,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    v.options,
+		}
+		err = openapi3filter.ValidateRequest(r.Context(), input)
+		// Validation reads the body and replaces it with a buffered copy
+		r.Body = vr.Body
+		if err != nil {
+			verr := errors.BadRequest.New("request doesn't match API specification")
+			for _, fe := range requestFieldErrors(err) {
+				verr = errors.AddFieldError(verr, fe.Field, fe.Message)
+			}
+			v.errorWriter.WriteError(w, r.URL.Path, log.ActionRequestValidation, verr)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestFieldErrors converts validation errors to errors of parameters and body fields.
+// Body fields are named by path with dots, e.g. rrsets.0.type.
+func requestFieldErrors(err error) []errors.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var fes []errors.FieldError
+		for _, err := range e {
+			fes = append(fes, requestFieldErrors(err)...)
+		}
+		return fes
+	case *openapi3filter.RequestError:
+		// Parameters are scalars, body fields are named by path from schema errors
+		var field string
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		}
+		switch e.Err.(type) {
+		case *openapi3.SchemaError, openapi3.MultiError:
+			return schemaFieldErrors(field, nil, e.Err)
+		}
+		if field == "" {
+			field = "body"
+		}
+		if e.Err == openapi3filter.ErrInvalidRequired {
+			return []errors.FieldError{{Field: field, Message: "is required"}}
+		}
+		if pe, ok := e.Err.(*openapi3filter.ParseError); ok && pe.Reason != "" {
+			return []errors.FieldError{{Field: field, Message: pe.Reason}}
+		}
+		msg := e.Reason
+		if e.Err != nil {
+			if msg != "" {
+				msg += ": "
+			}
+			msg += e.Err.Error()
+		}
+		return []errors.FieldError{{Field: field, Message: msg}}
+	default:
+		return []errors.FieldError{{Message: err.Error()}}
+	}
+}
+
+// schemaFieldErrors converts JSON schema errors. Errors of allOf and similar
+// keywords have path relative to the parent in Origin.
+func schemaFieldErrors(field string, prefix []string, err error) []errors.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var fes []errors.FieldError
+		for _, err := range e {
+			fes = append(fes, schemaFieldErrors(field, prefix, err)...)
+		}
+		return fes
+	case *openapi3.SchemaError:
+		path := append(append([]string(nil), prefix...), e.JSONPointer()...)
+		if e.Origin != nil {
+			return schemaFieldErrors(field, path, e.Origin)
+		}
+		name := field
+		if name == "" {
+			name = strings.Join(path, ".")
+		}
+		if name == "" {
+			name = "body"
+		}
+		msg := e.Reason
+		if msg == "" {
+			msg = "doesn't match " + e.SchemaField
+		}
+		return []errors.FieldError{{Field: name, Message: msg}}
+	default:
+		return []errors.FieldError{{Field: field, Message: err.Error()}}
+	}
+}
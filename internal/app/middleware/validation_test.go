@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mixanemca/pdns-api/internal/app/api/openapi"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/stretchr/testify/require"
+)
+
+type responseErrorWriter struct{}
+
+func (responseErrorWriter) WriteError(w http.ResponseWriter, urlPath string, action string, err error) {
+	network.WriteErrorResponse(w, err)
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+	vm, err := NewValidationMiddleware(responseErrorWriter{}, spec)
+	require.NoError(t, err)
+
+	var body string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		body = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := vm.ValidationMiddleware(next)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		status      int
+		fields      []errors.FieldError
+	}{
+		{
+			name:   "valid forward zones without content type",
+			method: http.MethodPost,
+			target: "/api/v1/servers/localhost/forward-zones",
+			body:   `[{"name":"example.com","nameservers":["10.0.0.1:53"]}]`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:        "invalid forward zone",
+			method:      http.MethodPost,
+			target:      "/api/v1/servers/localhost/forward-zones",
+			contentType: "application/json",
+			body:        `[{"name":"example.com","nameservers":["bad ns"]}]`,
+			status:      http.StatusBadRequest,
+			fields:      []errors.FieldError{{Field: "0.nameservers.0", Message: `string doesn't match the regular expression "^[a-zA-Z0-9.:]+$"`}},
+		},
+		{
+			name:   "invalid rrset in allOf",
+			method: http.MethodPatch,
+			target: "/api/v1/servers/localhost/zones/example.com.",
+			body:   `{"rrsets":[{"name":"www.example.com.","type":"A","changetype":"UPSERT"}]}`,
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "rrsets.0.changetype", Message: "value is not one of the allowed values"}},
+		},
+		{
+			name:   "missing and invalid query parameters",
+			method: http.MethodGet,
+			target: "/api/v1/servers/localhost/search-data?max=many",
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{
+				{Field: "q", Message: "is required"},
+				{Field: "max", Message: "an invalid integer"},
+			},
+		},
+		{
+			name:   "valid rrset at zone apex",
+			method: http.MethodPut,
+			target: "/api/v1/servers/localhost/zones/example.com./rrsets/@/txt",
+			body:   `{"ttl":300,"records":[{"content":"\"v=spf1 -all\""}]}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "rrset without records",
+			method: http.MethodPut,
+			target: "/api/v1/servers/localhost/zones/example.com./rrsets/www.example.com./A",
+			body:   `{"ttl":300,"records":[]}`,
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "records", Message: "minimum number of items is 1"}},
+		},
+		{
+			name:   "zone from template without kind",
+			method: http.MethodPost,
+			target: "/api/v1/servers/localhost/zones?template=internal",
+			body:   `{"name":"example.com."}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "invalid template name",
+			method: http.MethodPost,
+			target: "/api/v1/servers/localhost/zones?template=a/b",
+			body:   `{"name":"example.com."}`,
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "template", Message: `string doesn't match the regular expression "^[a-zA-Z0-9_-]+$"`}},
+		},
+		{
+			name:   "slave zone with bad master",
+			method: http.MethodPost,
+			target: "/api/v1/servers/localhost/zones",
+			body:   `{"name":"example.com.","kind":"Slave","masters":["[2001:db8::1]:53","ns1.example.com"]}`,
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "masters.1", Message: `string doesn't match the regular expression "^[a-fA-F0-9.:\\[\\]]+$"`}},
+		},
+		{
+			name:   "zone action",
+			method: http.MethodPut,
+			target: "/api/v1/servers/localhost/zones/example.com./axfr-retrieve",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "zone transfer in unknown format",
+			method: http.MethodGet,
+			target: "/api/v1/servers/localhost/zones/example.com./axfr?format=xml",
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "format", Message: "value is not one of the allowed values"}},
+		},
+		{
+			name:   "zone consistency with rrsets",
+			method: http.MethodGet,
+			target: "/api/v1/servers/localhost/zones/example.com./consistency?rrsets=true",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "zone consistency with bad rrsets",
+			method: http.MethodGet,
+			target: "/api/v1/servers/localhost/zones/example.com./consistency?rrsets=maybe",
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "rrsets", Message: "an invalid number"}},
+		},
+		{
+			name:   "zone verify",
+			method: http.MethodPost,
+			target: "/api/v1/servers/localhost/zones/example.com./verify",
+			body:   `{"rrsets":[{"name":"www.example.com.","type":"A","records":["192.0.2.1"]}],"timeout":30}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "zone verify with too long timeout",
+			method: http.MethodPost,
+			target: "/api/v1/servers/localhost/zones/example.com./verify",
+			body:   `{"rrsets":[{"name":"www.example.com.","type":"A"}],"timeout":3600}`,
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "timeout", Message: "number must be most 300"}},
+		},
+		{
+			name:   "lookup",
+			method: http.MethodGet,
+			target: "/api/v1/lookup?name=www.example.com&type=AAAA&server=recursor",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "lookup on unknown server",
+			method: http.MethodGet,
+			target: "/api/v1/lookup?name=www.example.com&server=google",
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "server", Message: "value is not one of the allowed values"}},
+		},
+		{
+			name:   "tsig key with generated secret",
+			method: http.MethodPost,
+			target: "/api/v1/servers/localhost/tsigkeys",
+			body:   `{"name":"transfer","algorithm":"hmac-sha512"}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "tsig key with unknown algorithm",
+			method: http.MethodPost,
+			target: "/api/v1/servers/localhost/tsigkeys",
+			body:   `{"name":"transfer","algorithm":"gss-tsig"}`,
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "algorithm", Message: "value is not one of the allowed values"}},
+		},
+		{
+			name:   "zone with several slave tsig keys",
+			method: http.MethodPut,
+			target: "/api/v1/servers/localhost/zones/example.com./tsigkeys",
+			body:   `{"slave_tsig_key_ids":["a","b"]}`,
+			status: http.StatusBadRequest,
+			fields: []errors.FieldError{{Field: "slave_tsig_key_ids", Message: "maximum number of items is 1"}},
+		},
+		{
+			name:   "route missing in specification",
+			method: http.MethodGet,
+			target: "/unknown",
+			status: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body = ""
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, r)
+
+			require.Equal(t, tt.status, rr.Code, rr.Body.String())
+			if tt.status != http.StatusBadRequest {
+				// Body is still readable by handler
+				require.Equal(t, tt.body, body)
+				return
+			}
+			var resp network.ErrorResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "bad_request", resp.Code)
+			require.ElementsMatch(t, tt.fields, resp.Fields)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdnsctl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) acmeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "acme",
+		Short: "Manage ACME DNS-01 challenge records",
+		Long: `Manage ACME DNS-01 challenge records.
+
+Records _acme-challenge of DOMAIN are changed in its closest authoritative zone,
+then commands wait until all nodes serve them. DOMAIN may be a wildcard.
+The wait should be shorter than the request timeout.`,
+	}
+
+	var wait time.Duration
+	add := &cobra.Command{
+		Use:   "add DOMAIN VALUE",
+		Short: "Add challenge record of the domain",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			challenge, err := a.client.AddACMEChallenge(cmd.Context(), args[0], args[1], wait)
+			if err != nil {
+				return err
+			}
+			return a.printChallenge(challenge)
+		},
+	}
+	add.Flags().DurationVar(&wait, "wait", 20*time.Second, "time to wait until all nodes serve the records, 0 checks once")
+
+	del := &cobra.Command{
+		Use:     "delete DOMAIN [VALUE]",
+		Aliases: []string{"rm"},
+		Short:   "Delete challenge record of the domain, all records if VALUE is omitted",
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var value string
+			if len(args) > 1 {
+				value = args[1]
+			}
+			challenge, err := a.client.DeleteACMEChallenge(cmd.Context(), args[0], value, wait)
+			if err != nil {
+				return err
+			}
+			return a.printChallenge(challenge)
+		},
+	}
+	del.Flags().DurationVar(&wait, "wait", 20*time.Second, "time to wait until all nodes stop serving the records, 0 checks once")
+
+	cmd.AddCommand(add, del)
+	return cmd
+}
+
+// printChallenge prints challenge records, and fails if they are not visible on all nodes, so hooks can retry
+func (a *app) printChallenge(challenge *client.ACMEChallenge) error {
+	row := []string{challenge.Name, challenge.Zone, strings.Join(challenge.Values, " "), strconv.FormatBool(challenge.Visible), strconv.Itoa(challenge.Attempts)}
+	if err := a.out.print(challenge, []string{"NAME", "ZONE", "VALUES", "VISIBLE", "ATTEMPTS"}, [][]string{row}); err != nil {
+		return err
+	}
+	if !challenge.Visible {
+		return fmt.Errorf("challenge records %s are not visible on all nodes after %d attempts", challenge.Name, challenge.Attempts)
+	}
+	return nil
+}
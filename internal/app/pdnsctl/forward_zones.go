@@ -0,0 +1,88 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdnsctl
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) forwardZonesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "forward-zones",
+		Aliases: []string{"forward-zone", "fz"},
+		Short:   "Manage forward zones of PowerDNS Recursor",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List forward zones",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fzs, err := a.client.ListForwardZones(cmd.Context(), a.server)
+			if err != nil {
+				return err
+			}
+			rows := make([][]string, 0, len(fzs))
+			for _, fz := range fzs {
+				rows = append(rows, []string{fz.Name, strings.Join(fz.Nameservers, ",")})
+			}
+			return a.out.print(fzs, []string{"NAME", "NAMESERVERS"}, rows)
+		},
+	}
+
+	add := &cobra.Command{
+		Use:   "add NAME NAMESERVER...",
+		Short: "Add forward zone",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fz := client.ForwardZone{
+				Name:        dns.Fqdn(args[0]),
+				Nameservers: args[1:],
+			}
+			if err := a.client.CreateForwardZones(cmd.Context(), a.server, fz); err != nil {
+				return err
+			}
+			a.out.message("Forward zone %s added", fz.Name)
+			return nil
+		},
+	}
+
+	rm := &cobra.Command{
+		Use:     "rm NAME...",
+		Aliases: []string{"delete"},
+		Short:   "Remove forward zones",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fzs := make([]client.ForwardZone, 0, len(args))
+			for _, name := range args {
+				fzs = append(fzs, client.ForwardZone{Name: dns.Fqdn(name)})
+			}
+			if err := a.client.DeleteForwardZones(cmd.Context(), a.server, fzs...); err != nil {
+				return err
+			}
+			a.out.message("Forward zones %s removed", strings.Join(fqdns(args), ", "))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(list, add, rm)
+	return cmd
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdnsctl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) lookupCommand() *cobra.Command {
+	var server string
+	cmd := &cobra.Command{
+		Use:     "lookup NAME [TYPE]",
+		Aliases: []string{"dig"},
+		Short:   "Query PowerDNS servers of pdns-api node over DNS",
+		Long: `Query PowerDNS servers of pdns-api node over DNS.
+
+Authoritative server is queried without recursion, Recursor with recursion.
+TYPE is A by default.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var rrType string
+			if len(args) > 1 {
+				rrType = args[1]
+			}
+			lookup, err := a.client.Lookup(cmd.Context(), args[0], rrType, server)
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			for _, r := range lookup.Results {
+				if r.Answer == nil {
+					rows = append(rows, []string{r.Server, "ERROR", "", "", "", "", r.Error})
+					continue
+				}
+				rtt := fmt.Sprintf("%.1fms", r.Answer.RTT)
+				flags := answerFlags(r.Answer.Flags)
+				if len(r.Answer.Answer) == 0 {
+					rows = append(rows, []string{r.Server, r.Answer.Rcode, flags, rtt, "", "", ""})
+				}
+				for _, rr := range r.Answer.Answer {
+					rows = append(rows, []string{r.Server, r.Answer.Rcode, flags, rtt, rr.Name + " " + rr.Type, strconv.Itoa(rr.TTL), rr.Content})
+				}
+			}
+			return a.out.print(lookup, []string{"SERVER", "RCODE", "FLAGS", "RTT", "RECORD", "TTL", "CONTENT"}, rows)
+		},
+	}
+	cmd.Flags().StringVar(&server, "server", client.LookupBoth, "server to query: auth, recursor or both")
+	return cmd
+}
+
+// answerFlags returns set flags like dig does
+func answerFlags(f client.DNSFlags) string {
+	var flags []string
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"aa", f.Authoritative},
+		{"tc", f.Truncated},
+		{"rd", f.RecursionDesired},
+		{"ra", f.RecursionAvailable},
+		{"ad", f.AuthenticatedData},
+		{"cd", f.CheckingDisabled},
+	} {
+		if flag.set {
+			flags = append(flags, flag.name)
+		}
+	}
+	return strings.Join(flags, " ")
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdnsctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+type output struct {
+	w      io.Writer
+	format string
+}
+
+func newOutput(w io.Writer, format string) (*output, error) {
+	switch format {
+	case formatTable, formatJSON, formatYAML:
+		return &output{w: w, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, must be table, json or yaml", format)
+	}
+}
+
+// print writes v as JSON or YAML, or rows as a table with header
+func (o *output) print(v interface{}, header []string, rows [][]string) error {
+	switch o.format {
+	case formatJSON:
+		enc := json.NewEncoder(o.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatYAML:
+		// Converted from JSON, so field names are the same as in API
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = o.w.Write(b)
+		return err
+	default:
+		tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// message writes a message about result of mutating command.
+// It is written in table format only, so JSON and YAML output stays machine readable.
+func (o *output) message(format string, args ...interface{}) {
+	if o.format == formatTable {
+		fmt.Fprintf(o.w, format+"\n", args...)
+	}
+}
@@ -0,0 +1,163 @@
+package pdnsctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/mixanemca/pdns-api/pkg/client/clienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func run(t *testing.T, srv *clienttest.Server, stdin string, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewCommand("test", "test")
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetArgs(append([]string{"--url", srv.URL, "--retries", "0"}, args...))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestZoneImportExport(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+
+	zoneFile := `$ORIGIN example.com.
+$TTL 300
+@	IN	SOA	ns1 hostmaster 1 3600 600 604800 300
+@	IN	NS	ns1
+ns1	IN	A	192.0.2.1
+www	60	IN	A	192.0.2.10
+www	60	IN	A	192.0.2.11
+@	IN	MX	10 mail
+`
+	_, err := run(t, srv, zoneFile, "zones", "import", "example.com")
+	require.NoError(t, err)
+
+	z, ok := srv.Zone("example.com.")
+	require.True(t, ok)
+	var www *client.RRSet
+	for i := range z.RRSets {
+		if z.RRSets[i].Name == "www.example.com." {
+			www = &z.RRSets[i]
+		}
+	}
+	require.NotNil(t, www)
+	require.Equal(t, 60, www.TTL)
+	require.Len(t, www.Records, 2)
+
+	exported, err := run(t, srv, "", "zones", "export", "example.com")
+	require.NoError(t, err)
+	require.Contains(t, exported, "mail.example.com.\n")
+
+	rrsets, err := parseZoneFile(strings.NewReader(exported), "example.com.", "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, sortedRRSets(z.RRSets), stripChangeType(rrsets))
+}
+
+func TestSlaveZone(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+
+	_, err := run(t, srv, "", "zones", "create", "example.com", "--master", "192.0.2.1", "--slave-tsig-key", "transfer")
+	require.Error(t, err)
+	out, err := run(t, srv, "", "tsigkeys", "create", "transfer", "--algorithm", "hmac-sha512")
+	require.NoError(t, err)
+	key, ok := srv.TSIGKey("transfer")
+	require.True(t, ok)
+	require.Contains(t, out, key.Key)
+	_, err = run(t, srv, "", "zones", "create", "example.com", "--master", "192.0.2.1", "--slave-tsig-key", "transfer")
+	require.NoError(t, err)
+	z, ok := srv.Zone("example.com.")
+	require.True(t, ok)
+	require.Equal(t, client.ZoneKindSlave, z.Kind)
+	require.Equal(t, []string{"192.0.2.1"}, z.Masters)
+
+	out, err = run(t, srv, "", "zones", "retrieve", "example.com")
+	require.NoError(t, err)
+	require.Contains(t, out, "Zone example.com. retrieved")
+	_, err = run(t, srv, "", "zones", "notify", "example.com")
+	require.Error(t, err)
+}
+
+func TestRecordsAndOutput(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.AddZone(client.Zone{Name: "example.com.", Kind: client.ZoneKindNative})
+
+	_, err := run(t, srv, "", "records", "set", "example.com", "www", "a", "192.0.2.1", "--ttl", "120")
+	require.NoError(t, err)
+
+	out, err := run(t, srv, "", "-o", "json", "zones", "get", "example.com")
+	require.NoError(t, err)
+	var z client.Zone
+	require.NoError(t, json.Unmarshal([]byte(out), &z))
+	require.Equal(t, []client.RRSet{{
+		Name:    "www.example.com.",
+		Type:    "A",
+		TTL:     120,
+		Records: []client.Record{{Content: "192.0.2.1"}},
+	}}, z.RRSets)
+
+	_, err = run(t, srv, "", "records", "delete", "example.com", "www.example.com.", "A")
+	require.NoError(t, err)
+	z, _ = srv.Zone("example.com.")
+	require.Empty(t, z.RRSets)
+
+	_, err = run(t, srv, "", "-o", "xml", "zones", "list")
+	require.Error(t, err)
+}
+
+func TestRecordName(t *testing.T) {
+	require.Equal(t, "example.com.", recordName("@", "example.com."))
+	require.Equal(t, "www.example.com.", recordName("www", "example.com."))
+	require.Equal(t, "www.example.org.", recordName("www.example.org.", "example.com."))
+}
+
+func stripChangeType(rrsets []client.RRSet) []client.RRSet {
+	for i := range rrsets {
+		rrsets[i].ChangeType = ""
+	}
+	return rrsets
+}
+
+func TestLookup(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.AddZone(client.Zone{Name: "example.com.", Kind: client.ZoneKindNative, RRSets: []client.RRSet{
+		{Name: "www.example.com.", Type: "A", TTL: 60, Records: []client.Record{{Content: "192.0.2.1"}}},
+	}})
+
+	out, err := run(t, srv, "", "lookup", "www.example.com")
+	require.NoError(t, err)
+	require.Contains(t, out, "auth")
+	require.Contains(t, out, "rd ra")
+	require.Contains(t, out, "192.0.2.1")
+
+	out, err = run(t, srv, "", "dig", "www.example.com", "AAAA", "--server", "auth")
+	require.NoError(t, err)
+	require.Contains(t, out, "NOERROR")
+	require.NotContains(t, out, "recursor")
+}
+
+func TestACME(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.AddZone(client.Zone{Name: "example.com.", Kind: client.ZoneKindNative})
+
+	out, err := run(t, srv, "", "acme", "add", "*.example.com", "token", "--wait", "0")
+	require.NoError(t, err)
+	require.Contains(t, out, "_acme-challenge.example.com.")
+	require.Contains(t, out, "true")
+
+	out, err = run(t, srv, "", "acme", "rm", "example.com", "-o", "json")
+	require.NoError(t, err)
+	var challenge client.ACMEChallenge
+	require.NoError(t, json.Unmarshal([]byte(out), &challenge))
+	require.Empty(t, challenge.Values)
+	require.True(t, challenge.Visible)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdnsctl
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) recordsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "records",
+		Aliases: []string{"record", "rrset"},
+		Short:   "Manage RRsets of authoritative zones",
+		Long: `Manage RRsets of authoritative zones.
+
+Record names are relative to the zone unless they end with a dot, @ is the zone apex.`,
+	}
+
+	var ttl int
+	var setPTR bool
+	set := &cobra.Command{
+		Use:   "set ZONE NAME TYPE CONTENT...",
+		Short: "Create or replace RRset",
+		Example: `  pdnsctl records set example.com www A 192.0.2.1 192.0.2.2 --ttl 300
+  pdnsctl records set example.com @ MX "10 mx.example.com."`,
+		Args: cobra.MinimumNArgs(4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			zone := dns.Fqdn(args[0])
+			rrset := client.RRSet{
+				Name: recordName(args[1], zone),
+				Type: strings.ToUpper(args[2]),
+				TTL:  ttl,
+			}
+			for _, content := range args[3:] {
+				rrset.Records = append(rrset.Records, client.Record{Content: content, SetPTR: setPTR})
+			}
+			if err := a.client.ReplaceRRSet(cmd.Context(), a.server, zone, rrset); err != nil {
+				return err
+			}
+			a.out.message("RRset %s %s updated", rrset.Name, rrset.Type)
+			return nil
+		},
+	}
+	set.Flags().IntVar(&ttl, "ttl", 3600, "TTL of RRset")
+	set.Flags().BoolVar(&setPTR, "set-ptr", false, "create PTR records in reverse zones")
+
+	del := &cobra.Command{
+		Use:     "delete ZONE NAME TYPE",
+		Aliases: []string{"rm"},
+		Short:   "Delete RRset",
+		Args:    cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			zone := dns.Fqdn(args[0])
+			name := recordName(args[1], zone)
+			rrType := strings.ToUpper(args[2])
+			if err := a.client.DeleteRRSet(cmd.Context(), a.server, zone, name, rrType); err != nil {
+				return err
+			}
+			a.out.message("RRset %s %s deleted", name, rrType)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(set, del)
+	return cmd
+}
+
+// recordName returns FQDN of the record name relative to zone
+func recordName(name, zone string) string {
+	switch {
+	case name == "@":
+		return zone
+	case dns.IsFqdn(name):
+		return name
+	default:
+		return name + "." + zone
+	}
+}
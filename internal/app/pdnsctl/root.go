@@ -0,0 +1,123 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pdnsctl implements pdnsctl, a command-line client of pdns-api
+package pdnsctl
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Config keys, also flags and environment variables with PDNSCTL_ prefix, like PDNSCTL_URL
+const (
+	keyURL     = "url"
+	keyUID     = "uid"
+	keyServer  = "server"
+	keyOutput  = "output"
+	keyTimeout = "timeout"
+	keyRetries = "retries"
+)
+
+type app struct {
+	config *viper.Viper
+	client *client.Client
+	server string
+	out    *output
+}
+
+// NewCommand returns root command of pdnsctl
+func NewCommand(version, build string) *cobra.Command {
+	a := &app{config: viper.New()}
+	var configFile string
+
+	root := &cobra.Command{
+		Use:   "pdnsctl",
+		Short: "Command-line client of pdns-api",
+		Long: `Command-line client of pdns-api.
+
+Settings are read from flags, PDNSCTL_* environment variables (PDNSCTL_URL, PDNSCTL_UID, ...)
+and config file, in that order. Default config file is ~/.config/pdnsctl.yaml or /etc/pdns-api/pdnsctl.yaml.`,
+		Version:       version + " (" + build + ")",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return a.init(cmd, configFile)
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&configFile, "config", "", "config file")
+	flags.String(keyURL, "http://127.0.0.1:8080", "pdns-api URL")
+	flags.String(keyUID, "", "user ID for authorization via LDAP")
+	flags.String(keyServer, "localhost", "PowerDNS server ID")
+	flags.StringP(keyOutput, "o", formatTable, "output format: table, json or yaml")
+	flags.Duration(keyTimeout, 30*time.Second, "request timeout")
+	flags.Int(keyRetries, client.DefaultRetries, "retries of idempotent requests")
+	_ = a.config.BindPFlags(flags)
+
+	a.config.SetEnvPrefix("PDNSCTL")
+	a.config.AutomaticEnv()
+
+	root.AddCommand(
+		a.zonesCommand(),
+		a.recordsCommand(),
+		a.forwardZonesCommand(),
+		a.tsigKeysCommand(),
+		a.searchCommand(),
+		a.lookupCommand(),
+		a.acmeCommand(),
+		a.cacheCommand(),
+	)
+	return root
+}
+
+// init reads config and creates client, after flags are parsed
+func (a *app) init(cmd *cobra.Command, configFile string) error {
+	if configFile != "" {
+		a.config.SetConfigFile(configFile)
+	} else {
+		a.config.SetConfigName("pdnsctl")
+		a.config.SetConfigType("yaml")
+		a.config.AddConfigPath("$HOME/.config")
+		a.config.AddConfigPath("/etc/pdns-api")
+	}
+	if err := a.config.ReadInConfig(); err != nil {
+		// Config file is optional, unless it is set explicitly
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok || configFile != "" {
+			return err
+		}
+	}
+
+	out, err := newOutput(cmd.OutOrStdout(), a.config.GetString(keyOutput))
+	if err != nil {
+		return err
+	}
+	a.out = out
+	a.server = a.config.GetString(keyServer)
+
+	a.client, err = client.New(
+		client.WithBaseURL(a.config.GetString(keyURL)),
+		client.WithClientUID(a.config.GetString(keyUID)),
+		client.WithRetries(a.config.GetInt(keyRetries), client.DefaultRetryWait),
+		client.WithHTTPClient(&http.Client{Timeout: a.config.GetDuration(keyTimeout)}),
+	)
+	return err
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdnsctl
+
+import (
+	"strconv"
+
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) searchCommand() *cobra.Command {
+	var max int
+	var objectType string
+	cmd := &cobra.Command{
+		Use:   "search QUERY",
+		Short: "Search zones, records and comments",
+		Long: `Search zones, records and comments.
+
+QUERY may contain wildcards * and ?.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := a.client.Search(cmd.Context(), a.server, args[0], max, client.ObjectType(objectType))
+			if err != nil {
+				return err
+			}
+			rows := make([][]string, 0, len(results))
+			for _, r := range results {
+				rows = append(rows, []string{string(r.ObjectType), r.Zone, r.Name, r.Type, strconv.Itoa(r.TTL), r.Content})
+			}
+			return a.out.print(results, []string{"OBJECT", "ZONE", "NAME", "TYPE", "TTL", "CONTENT"}, rows)
+		},
+	}
+	cmd.Flags().IntVar(&max, "max", 100, "maximum number of results")
+	cmd.Flags().StringVar(&objectType, "type", string(client.ObjectTypeAll), "object type: all, zone, record or comment")
+	return cmd
+}
+
+func (a *app) cacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage cache of PowerDNS servers",
+	}
+
+	flush := &cobra.Command{
+		Use:   "flush NAME",
+		Short: "Flush cache entries of the domain on all nodes",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := a.client.FlushCache(cmd.Context(), a.server, args[0]); err != nil {
+				return err
+			}
+			a.out.message("Cache of %s flushed", args[0])
+			return nil
+		},
+	}
+
+	cmd.AddCommand(flush)
+	return cmd
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdnsctl
+
+import (
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) tsigKeysCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tsigkeys",
+		Aliases: []string{"tsigkey", "tsig"},
+		Short:   "Manage TSIG keys of PowerDNS Authoritative",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List TSIG keys without secrets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keys, err := a.client.ListTSIGKeys(cmd.Context(), a.server)
+			if err != nil {
+				return err
+			}
+			rows := make([][]string, 0, len(keys))
+			for _, key := range keys {
+				rows = append(rows, []string{key.Name, key.Algorithm})
+			}
+			return a.out.print(keys, []string{"NAME", "ALGORITHM"}, rows)
+		},
+	}
+
+	var algorithm, secret string
+	create := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create TSIG key and print its secret",
+		Long:  "Create TSIG key and print its secret. The secret is generated if it is not set.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := a.client.CreateTSIGKey(cmd.Context(), a.server, client.TSIGKey{
+				Name:      args[0],
+				Algorithm: algorithm,
+				Key:       secret,
+			})
+			if err != nil {
+				return err
+			}
+			return a.printTSIGKey(key)
+		},
+	}
+	create.Flags().StringVar(&algorithm, "algorithm", "", "HMAC algorithm, hmac-sha256 by default")
+	create.Flags().StringVar(&secret, "secret", "", "base64 encoded secret")
+
+	rotate := &cobra.Command{
+		Use:   "rotate NAME",
+		Short: "Replace secret of TSIG key and print it",
+		Long:  "Replace secret of TSIG key and print it. The secret is generated if it is not set.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := a.client.UpdateTSIGKey(cmd.Context(), a.server, dns.Fqdn(args[0]), client.TSIGKey{
+				Algorithm: algorithm,
+				Key:       secret,
+			})
+			if err != nil {
+				return err
+			}
+			return a.printTSIGKey(key)
+		},
+	}
+	rotate.Flags().StringVar(&algorithm, "algorithm", "", "HMAC algorithm, hmac-sha256 by default")
+	rotate.Flags().StringVar(&secret, "secret", "", "base64 encoded secret")
+
+	rm := &cobra.Command{
+		Use:     "rm NAME",
+		Aliases: []string{"delete"},
+		Short:   "Remove TSIG key",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := dns.Fqdn(args[0])
+			if err := a.client.DeleteTSIGKey(cmd.Context(), a.server, name); err != nil {
+				return err
+			}
+			a.out.message("TSIG key %s removed", name)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(list, create, rotate, rm)
+	return cmd
+}
+
+func (a *app) printTSIGKey(key *client.TSIGKey) error {
+	return a.out.print(key, []string{"NAME", "ALGORITHM", "SECRET"}, [][]string{{key.Name, key.Algorithm, key.Key}})
+}
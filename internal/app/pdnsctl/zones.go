@@ -0,0 +1,312 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdnsctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) zonesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "zones",
+		Aliases: []string{"zone"},
+		Short:   "Manage authoritative zones",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List zones",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			zs, err := a.client.ListZones(cmd.Context(), a.server)
+			if err != nil {
+				return err
+			}
+			rows := make([][]string, 0, len(zs))
+			for _, z := range zs {
+				rows = append(rows, []string{z.Name, z.Kind, strconv.Itoa(z.Serial)})
+			}
+			return a.out.print(zs, []string{"NAME", "KIND", "SERIAL"}, rows)
+		},
+	}
+
+	get := &cobra.Command{
+		Use:   "get NAME",
+		Short: "Show zone with RRsets",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			z, err := a.client.GetZone(cmd.Context(), a.server, args[0])
+			if err != nil {
+				return err
+			}
+			return a.out.print(z, rrsetHeader, rrsetRows(z.RRSets))
+		},
+	}
+
+	var kind, template string
+	var nameservers, masters, masterTSIGKeys, slaveTSIGKeys []string
+	create := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create zone",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			zone := client.Zone{
+				Name:             dns.Fqdn(args[0]),
+				Kind:             kind,
+				Nameservers:      fqdns(nameservers),
+				Masters:          masters,
+				MasterTSIGKeyIDs: masterTSIGKeys,
+				SlaveTSIGKeyIDs:  slaveTSIGKeys,
+			}
+			// Zone with masters is Slave unless kind is set explicitly
+			if len(masters) > 0 && !cmd.Flags().Changed("kind") {
+				zone.Kind = client.ZoneKindSlave
+			}
+			var z *client.Zone
+			var err error
+			if template != "" {
+				// Kind of the template is used unless set explicitly
+				if !cmd.Flags().Changed("kind") {
+					zone.Kind = ""
+				}
+				z, err = a.client.CreateZoneFromTemplate(cmd.Context(), a.server, template, zone)
+			} else {
+				z, err = a.client.CreateZone(cmd.Context(), a.server, zone)
+			}
+			if err != nil {
+				return err
+			}
+			if a.out.format != formatTable {
+				return a.out.print(z, nil, nil)
+			}
+			a.out.message("Zone %s created", z.Name)
+			return nil
+		},
+	}
+	create.Flags().StringVar(&kind, "kind", client.ZoneKindNative, "zone kind: Native, Master or Slave")
+	create.Flags().StringSliceVar(&nameservers, "nameserver", nil, "nameserver of the zone, may be repeated")
+	create.Flags().StringVar(&template, "template", "", "zone template, other flags override it")
+	create.Flags().StringSliceVar(&masters, "master", nil, "master IP address with optional port of Slave zone, may be repeated")
+	create.Flags().StringSliceVar(&masterTSIGKeys, "master-tsig-key", nil, "TSIG key allowed to transfer the zone, may be repeated")
+	create.Flags().StringSliceVar(&slaveTSIGKeys, "slave-tsig-key", nil, "TSIG key to transfer Slave zone from masters")
+
+	actions := []struct {
+		use, short, done string
+		fn               func(ctx context.Context, zone string) error
+	}{
+		{"retrieve NAME", "Retrieve Slave zone from masters", "retrieved", func(ctx context.Context, zone string) error {
+			return a.client.AxfrRetrieve(ctx, a.server, zone)
+		}},
+		{"notify NAME", "Send DNS NOTIFY to slaves of the zone", "notified", func(ctx context.Context, zone string) error {
+			return a.client.NotifyZone(ctx, a.server, zone)
+		}},
+		{"rectify NAME", "Rectify DNSSEC data of the zone", "rectified", func(ctx context.Context, zone string) error {
+			return a.client.RectifyZone(ctx, a.server, zone)
+		}},
+	}
+	for _, action := range actions {
+		action := action
+		cmd.AddCommand(&cobra.Command{
+			Use:   action.use,
+			Short: action.short,
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := action.fn(cmd.Context(), args[0]); err != nil {
+					return err
+				}
+				a.out.message("Zone %s %s", dns.Fqdn(args[0]), action.done)
+				return nil
+			},
+		})
+	}
+
+	del := &cobra.Command{
+		Use:     "delete NAME",
+		Aliases: []string{"rm"},
+		Short:   "Delete zone",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := a.client.DeleteZone(cmd.Context(), a.server, args[0]); err != nil {
+				return err
+			}
+			a.out.message("Zone %s deleted", dns.Fqdn(args[0]))
+			return nil
+		},
+	}
+
+	export := &cobra.Command{
+		Use:   "export NAME",
+		Short: "Export zone in BIND zone file format",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			z, err := a.client.GetZone(cmd.Context(), a.server, args[0])
+			if err != nil {
+				return err
+			}
+			return writeZoneFile(cmd.OutOrStdout(), z)
+		},
+	}
+
+	var file string
+	imp := &cobra.Command{
+		Use:   "import NAME",
+		Short: "Import RRsets from BIND zone file",
+		Long: `Import RRsets from BIND zone file.
+
+RRsets from the file replace existing ones, other RRsets of the zone are kept.
+Zone is created with Native kind if it doesn't exist.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := dns.Fqdn(args[0])
+			var r io.Reader = cmd.InOrStdin()
+			if file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			rrsets, err := parseZoneFile(r, name, file)
+			if err != nil {
+				return err
+			}
+
+			if _, err := a.client.GetZone(cmd.Context(), a.server, name); err != nil {
+				if client.GetType(err) != client.NotFound {
+					return err
+				}
+				if _, err := a.client.CreateZone(cmd.Context(), a.server, client.Zone{
+					Name: name,
+					Kind: client.ZoneKindNative,
+				}); err != nil {
+					return err
+				}
+				a.out.message("Zone %s created", name)
+			}
+			if err := a.client.PatchRRSets(cmd.Context(), a.server, name, rrsets...); err != nil {
+				return err
+			}
+			a.out.message("%d RRsets imported to zone %s", len(rrsets), name)
+			return nil
+		},
+	}
+	imp.Flags().StringVarP(&file, "file", "f", "-", "zone file, - for stdin")
+
+	cmd.AddCommand(list, get, create, del, export, imp)
+	return cmd
+}
+
+// writeZoneFile writes RRsets of the zone in BIND format. Disabled records are commented out.
+func writeZoneFile(w io.Writer, z *client.Zone) error {
+	if _, err := fmt.Fprintf(w, "$ORIGIN %s\n", z.Name); err != nil {
+		return err
+	}
+	for _, rrset := range sortedRRSets(z.RRSets) {
+		for _, r := range rrset.Records {
+			prefix := ""
+			if r.Disabled {
+				prefix = "; "
+			}
+			if _, err := fmt.Fprintf(w, "%s%s\t%d\tIN\t%s\t%s\n", prefix, rrset.Name, rrset.TTL, rrset.Type, r.Content); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseZoneFile reads BIND zone file and groups records to RRsets with REPLACE changetype.
+// TTL of RRset is the TTL of its first record.
+func parseZoneFile(r io.Reader, origin, file string) ([]client.RRSet, error) {
+	type key struct{ name, rrType string }
+	var rrsets []client.RRSet
+	index := make(map[key]int)
+
+	zp := dns.NewZoneParser(r, origin, file)
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		h := rr.Header()
+		k := key{strings.ToLower(h.Name), dns.TypeToString[h.Rrtype]}
+		i, ok := index[k]
+		if !ok {
+			i = len(rrsets)
+			index[k] = i
+			rrsets = append(rrsets, client.RRSet{
+				Name:       k.name,
+				Type:       k.rrType,
+				TTL:        int(h.Ttl),
+				ChangeType: client.ChangeTypeReplace,
+			})
+		}
+		content := strings.TrimPrefix(rr.String(), h.String())
+		rrsets[i].Records = append(rrsets[i].Records, client.Record{Content: content})
+	}
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
+	return rrsets, nil
+}
+
+var rrsetHeader = []string{"NAME", "TYPE", "TTL", "CONTENT"}
+
+// rrsetRows returns a row per record
+func rrsetRows(rrsets []client.RRSet) [][]string {
+	var rows [][]string
+	for _, rrset := range sortedRRSets(rrsets) {
+		for _, r := range rrset.Records {
+			content := r.Content
+			if r.Disabled {
+				content += " (disabled)"
+			}
+			rows = append(rows, []string{rrset.Name, rrset.Type, strconv.Itoa(rrset.TTL), content})
+		}
+	}
+	return rows
+}
+
+// sortedRRSets returns RRsets sorted by name and type, SOA goes first
+func sortedRRSets(rrsets []client.RRSet) []client.RRSet {
+	sorted := append([]client.RRSet(nil), rrsets...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].Type == "SOA") != (sorted[j].Type == "SOA") {
+			return sorted[i].Type == "SOA"
+		}
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Type < sorted[j].Type
+	})
+	return sorted
+}
+
+func fqdns(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		out = append(out, dns.Fqdn(n))
+	}
+	return out
+}
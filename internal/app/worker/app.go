@@ -24,14 +24,18 @@ import (
 	"github.com/hashicorp/consul/connect"
 	pdnsApi "github.com/mittwald/go-powerdns"
 	commonV1 "github.com/mixanemca/pdns-api/internal/app/common/handler/v1"
+	"github.com/mixanemca/pdns-api/internal/app/middleware"
 	workerV1 "github.com/mixanemca/pdns-api/internal/app/worker/handler/v1"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone/storage"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/client"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/consul"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/health"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 	"golang.org/x/net/context"
 
 	"github.com/gorilla/mux"
@@ -46,6 +50,8 @@ type app struct {
 	logger             *logrus.Logger
 	publicHTTPServer   *http.Server
 	internalHTTPServer *http.Server
+	// internalChecks are readiness checks of dependencies of internal API only
+	internalChecks []health.Check
 }
 
 func NewApp(cfg config.Config, logger *logrus.Logger) *app {
@@ -68,6 +74,7 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats, withHealth bool) {
 	authPowerDNSClient, err := pdnsApi.New(
 		pdnsApi.WithBaseURL(a.config.PDNS.AuthConfig.BaseURL),
 		pdnsApi.WithAPIKeyAuthentication(a.config.PDNS.AuthConfig.ApiKey),
+		pdnsApi.WithHTTPClient(tracing.NewHTTPClient()),
 	)
 	if err != nil {
 		a.logger.WithFields(logrus.Fields{
@@ -78,6 +85,7 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats, withHealth bool) {
 	recursorPowerDNSClient, err := pdnsApi.New(
 		pdnsApi.WithBaseURL(a.config.PDNS.RecursorConfig.BaseURL),
 		pdnsApi.WithAPIKeyAuthentication(a.config.PDNS.RecursorConfig.ApiKey),
+		pdnsApi.WithHTTPClient(tracing.NewHTTPClient()),
 	)
 	if err != nil {
 		a.logger.WithFields(logrus.Fields{
@@ -105,8 +113,12 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats, withHealth bool) {
 	compositeFZStorage := a.createCompositeStorage()
 
 	internalRouter := mux.NewRouter()
+	// Continue trace started by public API
+	internalRouter.Use(otelmux.Middleware(tracing.ServiceName))
+	// Accept request ID from public API to link logs of the same request
+	internalRouter.Use(middleware.RequestIDMiddleware)
 
-	flushHandler := workerV1.NewFlushHandler(a.config, prometheusStats, authPowerDNSClient, recursorPowerDNSClient, a.logger)
+	flushHandler := workerV1.NewFlushHandler(a.config, errorWriter, prometheusStats, authPowerDNSClient, recursorPowerDNSClient, a.logger)
 	internalAddForwardZoneHandler := workerV1.NewAddForwardZoneHandler(
 		a.config,
 		prometheusStats,
@@ -144,8 +156,13 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats, withHealth bool) {
 		compositeFZStorage,
 	)
 
+	zoneStateHandler := workerV1.NewZoneStateHandler(a.config, errorWriter, prometheusStats, authPowerDNSClient, a.logger)
+	zoneVerifyHandler := workerV1.NewZoneVerifyHandler(a.config, errorWriter, prometheusStats, a.logger)
+
 	// HTTP internal Handlers
 	internalRouter.HandleFunc("/api/v1/internal/{serverID}/cache/flush", flushHandler.FlushInternal).Methods(http.MethodPut)
+	internalRouter.HandleFunc("/api/v1/internal/{serverID}/zones/{zoneID}/state", zoneStateHandler.ZoneStateInternal).Methods(http.MethodGet)
+	internalRouter.HandleFunc("/api/v1/internal/{serverID}/zones/{zoneID}/verify", zoneVerifyHandler.VerifyZoneInternal).Methods(http.MethodPost)
 	internalRouter.HandleFunc("/api/v1/internal/{serverID}/forward-zones", internalAddForwardZoneHandler.AddForwardZonesInternal).Methods(http.MethodPost)
 	internalRouter.HandleFunc("/api/v1/internal/{serverID}/forward-zones", deleteForwardZonesHandler.DeleteForwardZonesInternal).Methods(http.MethodDelete)
 	internalRouter.HandleFunc("/api/v1/internal/{serverID}/forward-zones/{zoneID}", updateForwardZonesHandler.UpdateForwardZonesInternal).Methods(http.MethodPatch)
@@ -161,8 +178,21 @@ func (a *app) Run(prometheusStats *stats.PrometheusStats, withHealth bool) {
 		}
 	}()
 
+	// Internal API applies changes to Recursor and forward-zones file, the check of
+	// internal service must not depend on LDAP or other dependencies of the public API
+	a.internalChecks = []health.Check{
+		{Name: "pdns-recursor", Fn: health.PowerDNS(recursorPowerDNSClient)},
+		{Name: "forward-zones-file", Fn: health.FileReadable(forwardzone.ForwardZonesFile)},
+	}
+
 	if withHealth {
-		a.startPublicServer()
+		// Worker role depends on both PowerDNS servers, Consul for internal service and forward-zones file
+		a.startPublicServer(
+			health.Check{Name: "pdns-auth", Fn: health.PowerDNS(authPowerDNSClient)},
+			health.Check{Name: "pdns-recursor", Fn: health.PowerDNS(recursorPowerDNSClient)},
+			health.Check{Name: "consul", Fn: health.Consul(a.consul)},
+			health.Check{Name: "forward-zones-file", Fn: health.FileReadable(forwardzone.ForwardZonesFile)},
+		)
 	}
 
 	a.logger.Infof("Internal HTTP server started and listen on %s", a.internalHTTPServer.Addr)
@@ -194,13 +224,22 @@ func (a *app) Shutdown(ctx context.Context, withHealth bool) error {
 	return nil
 }
 
-func (a *app) startPublicServer() {
+// InternalChecks returns readiness checks of internal API, used by the check of Consul internal service.
+// Checks are available after Run.
+func (a *app) InternalChecks() []health.Check {
+	return a.internalChecks
+}
+
+func (a *app) startPublicServer(checks ...health.Check) {
 	publicRouter := mux.NewRouter()
 	// Prometheus metrics
 	publicRouter.Handle("/metrics", promhttp.Handler())
 
-	healthHandler := commonV1.NewHealthHandler(a.config)
+	healthHandler := commonV1.NewHealthHandler(a.config, checks...)
 	publicRouter.HandleFunc("/api/v1/health", healthHandler.Health).Methods(http.MethodGet)
+	publicRouter.HandleFunc("/api/v1/health/live", healthHandler.Live).Methods(http.MethodGet)
+	publicRouter.HandleFunc("/api/v1/health/ready", healthHandler.Ready).Methods(http.MethodGet)
+	publicRouter.HandleFunc("/api/v1/health/internal", commonV1.NewHealthHandler(a.config, a.internalChecks...).Ready).Methods(http.MethodGet)
 
 	publicAddr := net.JoinHostPort(a.config.PublicHTTP.Address, a.config.PublicHTTP.Port)
 	a.publicHTTPServer = &http.Server{
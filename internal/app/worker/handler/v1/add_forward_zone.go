@@ -106,7 +106,7 @@ func (s *AddForwardZoneHandler) AddForwardZonesInternal(w http.ResponseWriter, r
 	}
 
 	for _, inputFZ := range input {
-		s.logger.WithFields(logrus.Fields{
+		s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
 			"action":       log.ActionForwardZoneAdd,
 			"forward-zone": network.DeCanonicalize(inputFZ.Name),
 		}).Infof("Forward zone %s added", network.DeCanonicalize(inputFZ.Name))
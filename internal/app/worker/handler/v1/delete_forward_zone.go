@@ -17,7 +17,6 @@ limitations under the License.
 package v1
 
 import (
-	"fmt"
 	"net/http"
 	"os"
 
@@ -26,9 +25,9 @@ import (
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone/storage"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	statistic "github.com/mixanemca/pdns-api/internal/infrastructure/stats"
@@ -98,12 +97,7 @@ func (s *DeleteForwardZoneHandler) DeleteForwardZoneInternal(w http.ResponseWrit
 
 	// Return 404 if forward-zone not found
 	if !found {
-		s.logger.WithFields(logrus.Fields{
-			"action":       log.ActionForwardZoneDelete,
-			"forward-zone": zoneID,
-		}).Warnf("Cannot delete zone. Zone %s not forwarding", network.Canonicalize(zoneID))
-		http.Error(w, fmt.Sprintf("zone %s not forwarding", zoneID), http.StatusNotFound)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusNotFound)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneDelete, errors.NotFound.Newf("zone %s not forwarding", network.Canonicalize(zoneID)))
 		return
 	}
 
@@ -113,7 +107,7 @@ func (s *DeleteForwardZoneHandler) DeleteForwardZoneInternal(w http.ResponseWrit
 		return
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
 		"action":       log.ActionForwardZoneDelete,
 		"forward-zone": zoneID,
 	}).Infof("forwarding zone %s deleted", network.Canonicalize(zoneID))
@@ -116,7 +116,7 @@ func (s *DeleteForwardZonesHandler) DeleteForwardZonesInternal(w http.ResponseWr
 	}
 
 	for _, inputFZ := range input {
-		s.logger.WithFields(logrus.Fields{
+		s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
 			"action":       log.ActionForwardZoneDelete,
 			"forward-zone": network.DeCanonicalize(inputFZ.Name),
 		}).Infof("Forward zone %s was deleted", network.DeCanonicalize(inputFZ.Name))
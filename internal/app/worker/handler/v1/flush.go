@@ -22,26 +22,27 @@ import (
 
 	"github.com/gorilla/mux"
 	pdnsApi "github.com/mittwald/go-powerdns"
-	"github.com/mittwald/go-powerdns/pdnshttp"
 	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/net/context"
 
 	statistic "github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
 )
 
 type FlushHandler struct {
 	config         config.Config
+	errorWriter    errorWriter
 	stats          statistic.PrometheusStatsCollector
 	powerDNSClient pdnsApi.Client
 	recursor       pdnsApi.Client
 	logger         *logrus.Logger
 }
 
-func NewFlushHandler(config config.Config, stats statistic.PrometheusStatsCollector, powerDNSClient pdnsApi.Client, recursor pdnsApi.Client, logger *logrus.Logger) *FlushHandler {
-	return &FlushHandler{config: config, stats: stats, powerDNSClient: powerDNSClient, recursor: recursor, logger: logger}
+func NewFlushHandler(config config.Config, errorWriter errorWriter, stats statistic.PrometheusStatsCollector, powerDNSClient pdnsApi.Client, recursor pdnsApi.Client, logger *logrus.Logger) *FlushHandler {
+	return &FlushHandler{config: config, errorWriter: errorWriter, stats: stats, powerDNSClient: powerDNSClient, recursor: recursor, logger: logger}
 }
 
 // Flush Flush a cache-entry by name
@@ -53,52 +54,29 @@ func (s *FlushHandler) FlushInternal(w http.ResponseWriter, r *http.Request) {
 	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
 	defer timer.ObserveDuration()
 
-	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	// Authoritative
-	authResult, err := s.powerDNSClient.Cache().Flush(context.Background(), serverID, domain)
+	authResult, err := s.powerDNSClient.Cache().Flush(tracing.Detach(r.Context()), serverID, domain)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"action": log.ActionSystem,
-			"rr":     network.DeCanonicalize(domain),
-		}).Error(err.Error())
-		err := json.NewEncoder(w).Encode(err)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
-			return
-		}
-		w.WriteHeader(err.(pdnshttp.ErrUnexpectedStatus).StatusCode)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, err.(pdnshttp.ErrUnexpectedStatus).StatusCode)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, errors.WrapPDNS(err, "flushing authoritative cache for %s", network.DeCanonicalize(domain)))
 		return
 	}
 	// Recursive
-	recResult, err := s.recursor.Cache().Flush(context.Background(), serverID, domain)
+	recResult, err := s.recursor.Cache().Flush(tracing.Detach(r.Context()), serverID, domain)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"action": log.ActionFlushCache,
-			"rr":     network.DeCanonicalize(domain),
-		}).Error(err.Error())
-		err := json.NewEncoder(w).Encode(err)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
-			return
-		}
-		w.WriteHeader(err.(pdnshttp.ErrUnexpectedStatus).StatusCode)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, err.(pdnshttp.ErrUnexpectedStatus).StatusCode)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, errors.WrapPDNS(err, "flushing recursor cache for %s", network.DeCanonicalize(domain)))
 		return
 	}
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
 		"action": log.ActionFlushCache,
 		"rr":     network.DeCanonicalize(domain),
 	}).Infof("%s for %s", log.ActionFlushCache, network.DeCanonicalize(domain))
 	authResult.Count += recResult.Count
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(w).Encode(authResult)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusInternalServerError)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionFlushCache, errors.Wrap(err, "encoding JSON response"))
 		return
 	}
-	s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusOK)
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
 }
@@ -18,7 +18,6 @@ package v1
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"os"
 
@@ -27,9 +26,9 @@ import (
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
 	"github.com/mixanemca/pdns-api/internal/domain/forwardzone/storage"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	statistic "github.com/mixanemca/pdns-api/internal/infrastructure/stats"
@@ -98,12 +97,7 @@ func (s *UpdateForwardZoneHandler) UpdateForwardZonesInternal(w http.ResponseWri
 
 	// Return 404 if zone not forwarding
 	if !found {
-		s.logger.WithFields(logrus.Fields{
-			"action":       log.ActionForwardZoneUpdate,
-			"forward-zone": zoneID,
-		}).Warnf("Cannot update zone %s. Zone not forwarding", network.Canonicalize(zoneID))
-		http.Error(w, fmt.Sprintf("zone %s not forwarding", zoneID), http.StatusNotFound)
-		s.stats.CountError(s.config.Environment, network.GetHostname(), r.URL.Path, http.StatusNotFound)
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionForwardZoneUpdate, errors.NotFound.Newf("zone %s not forwarding", network.Canonicalize(zoneID)))
 		return
 	}
 
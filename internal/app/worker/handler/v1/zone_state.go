@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	pdnsApi "github.com/mittwald/go-powerdns"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
+	"github.com/sirupsen/logrus"
+
+	statistic "github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+)
+
+type ZoneStateHandler struct {
+	config         config.Config
+	errorWriter    errorWriter
+	stats          statistic.PrometheusStatsCollector
+	powerDNSClient pdnsApi.Client
+	logger         *logrus.Logger
+}
+
+func NewZoneStateHandler(config config.Config, errorWriter errorWriter, stats statistic.PrometheusStatsCollector, powerDNSClient pdnsApi.Client, logger *logrus.Logger) *ZoneStateHandler {
+	return &ZoneStateHandler{config: config, errorWriter: errorWriter, stats: stats, powerDNSClient: powerDNSClient, logger: logger}
+}
+
+// ZoneStateInternal returns SOA serial of the zone on this node and a hash of its RRsets if 'rrsets' is true
+func (s *ZoneStateHandler) ZoneStateInternal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serverID := vars["serverID"]
+	zoneID := vars["zoneID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	var withRRSets bool
+	if v := r.URL.Query().Get("rrsets"); v != "" {
+		var err error
+		withRRSets, err = strconv.ParseBool(v)
+		if err != nil {
+			s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneConsistency, errors.AddFieldError(
+				errors.BadRequest.Newf("invalid rrsets %s", v),
+				"rrsets", "must be a boolean",
+			))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	z, err := s.powerDNSClient.Zones().GetZone(ctx, serverID, zoneID)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneConsistency, errors.WrapPDNS(err, "getting zone %s", zoneID))
+		return
+	}
+	state := zone.State{Serial: z.Serial}
+	if withRRSets {
+		state.RRSetsHash = zone.HashRRSets(z.ResourceRecordSets)
+	}
+
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionZoneConsistency,
+		"zone":   z.Name,
+	}).Debugf("Zone %s has serial %d", z.Name, z.Serial)
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(state)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneConsistency, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/dnsclient"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
+	"github.com/sirupsen/logrus"
+
+	statistic "github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+)
+
+type ZoneVerifyHandler struct {
+	config      config.Config
+	errorWriter errorWriter
+	stats       statistic.PrometheusStatsCollector
+	logger      *logrus.Logger
+}
+
+func NewZoneVerifyHandler(config config.Config, errorWriter errorWriter, stats statistic.PrometheusStatsCollector, logger *logrus.Logger) *ZoneVerifyHandler {
+	return &ZoneVerifyHandler{config: config, errorWriter: errorWriter, stats: stats, logger: logger}
+}
+
+// VerifyZoneInternal queries local PowerDNS Authoritative and Recursor over DNS
+// and compares their answers with expected RRsets
+func (s *ZoneVerifyHandler) VerifyZoneInternal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	zoneID := vars["zoneID"]
+
+	timer := s.stats.GetLabeledResponseTimePeersHistogramTimer(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	var exps []zone.Expectation
+	if err := json.NewDecoder(r.Body).Decode(&exps); err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, errors.BadRequest.Wrap(err, "parsing expected RRsets"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(tracing.Detach(r.Context()), time.Duration(s.config.PDNS.RecursorConfig.Timeout)*time.Second)
+	defer cancel()
+
+	servers := []struct {
+		name      string
+		addr      string
+		recursion bool
+	}{
+		{name: zone.ServerAuth, addr: s.config.PDNS.AuthConfig.DNSAddress},
+		{name: zone.ServerRecursor, addr: s.config.PDNS.RecursorConfig.DNSAddress, recursion: true},
+	}
+	checks := make([]zone.Check, len(exps)*len(servers))
+	var wg sync.WaitGroup
+	for i, e := range exps {
+		for j, server := range servers {
+			// https://golang.org/doc/faq#closures_and_goroutines
+			check := &checks[i*len(servers)+j]
+			e, name, addr, recursion := e, server.name, server.addr, server.recursion
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				answer, _, err := dnsclient.Query(ctx, addr, e.Name, dns.StringToType[e.Type], recursion)
+				if err != nil {
+					*check = zone.Check{Name: e.Name, Type: e.Type, Server: name, Records: []string{}, Error: err.Error()}
+					return
+				}
+				*check = e.CheckAnswer(name, answer)
+			}()
+		}
+	}
+	wg.Wait()
+
+	s.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionZoneVerify,
+		"zone":   zoneID,
+	}).Debugf("Zone %s was verified with %d checks", zoneID, len(checks))
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(checks)
+	if err != nil {
+		s.errorWriter.WriteError(w, r.URL.Path, log.ActionZoneVerify, errors.Wrap(err, "encoding JSON response"))
+		return
+	}
+	s.stats.CountCall(s.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
@@ -18,7 +18,6 @@ package forwardzone
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"io"
 	"regexp"
@@ -88,25 +87,6 @@ func ParseForwardZoneFile(r io.Reader) (ForwardZones, error) {
 	return fzs, nil
 }
 
-// ParseForwardZonesInput parses input data and return err if data invalid.
-func ParseForwardZonesInput(data io.Reader) (ForwardZones, error) {
-	// Parse input
-	fzs := make(ForwardZones, 0)
-	decoder := json.NewDecoder(data)
-
-	err := decoder.Decode(&fzs)
-	if err != nil {
-		return nil, errors.BadRequest.Wrap(err, "failed decode forward zones")
-	}
-	// Check input data fields
-	for _, fz := range fzs {
-		if _, err := ParseForwardZoneLine(fz.String()); err != nil {
-			return nil, err
-		}
-	}
-	return fzs, nil
-}
-
 func ForwardZoneIsExist(fzs ForwardZones, searchName string) bool {
 	sort.Sort(fzs)
 	idx := sort.Search(len(fzs), func(i int) bool { return fzs[i].Name == network.Canonicalize(searchName) })
@@ -0,0 +1,139 @@
+// Package tsigkey describes TSIG keys of PowerDNS Authoritative used to authorize zone transfers
+package tsigkey
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// ZoneType is a type of TSIG keys for LDAP authorization
+const ZoneType = "tsigkeys"
+
+// DefaultAlgorithm is used for new keys without algorithm
+const DefaultAlgorithm = "hmac-sha256"
+
+// algorithms maps HMAC algorithms supported by PowerDNS to their digest size in bytes.
+// Generated secrets have the digest size as recommended by RFC 8945.
+var algorithms = map[string]int{
+	"hmac-md5":    16,
+	"hmac-sha1":   20,
+	"hmac-sha224": 28,
+	"hmac-sha256": 32,
+	"hmac-sha384": 48,
+	"hmac-sha512": 64,
+}
+
+// TSIGKey is a TSIG key of PowerDNS Authoritative.
+// Key is a base64 encoded secret, it is empty in redacted keys.
+type TSIGKey struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Algorithm string `json:"algorithm,omitempty"`
+	Key       string `json:"key,omitempty"`
+	Type      string `json:"type,omitempty"`
+}
+
+// Redacted returns the key without secret, for listings, logs and audit
+func (k TSIGKey) Redacted() TSIGKey {
+	k.Key = ""
+	return k
+}
+
+// Algorithms returns names of supported algorithms
+func Algorithms() []string {
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NormalizeAlgorithm returns short lower-case name of the algorithm,
+// hmac-md5.sig-alg.reg.int. of RFC 8945 is hmac-md5.
+func NormalizeAlgorithm(algorithm string) string {
+	algorithm = strings.ToLower(strings.TrimSuffix(algorithm, "."))
+	if algorithm == "hmac-md5.sig-alg.reg.int" {
+		return "hmac-md5"
+	}
+	return algorithm
+}
+
+// DNSAlgorithm returns the algorithm name used in TSIG records, like dns.HmacSHA256
+func DNSAlgorithm(algorithm string) string {
+	algorithm = NormalizeAlgorithm(algorithm)
+	if algorithm == "hmac-md5" {
+		return dns.HmacMD5
+	}
+	return dns.Fqdn(algorithm)
+}
+
+// Generate returns a new random base64 encoded secret for the algorithm
+func Generate(algorithm string) (string, error) {
+	size, ok := algorithms[NormalizeAlgorithm(algorithm)]
+	if !ok {
+		return "", errors.BadRequest.Newf("unsupported TSIG algorithm %s", algorithm)
+	}
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "generating TSIG secret")
+	}
+	return base64.StdEncoding.EncodeToString(secret), nil
+}
+
+// Prepare validates the key and fills defaults: the algorithm is normalized
+// and an empty secret is generated
+func (k *TSIGKey) Prepare() error {
+	var fes []errors.FieldError
+	if _, ok := dns.IsDomainName(k.Name); !ok || k.Name == "" || k.Name == "." {
+		fes = append(fes, errors.FieldError{Field: "name", Message: "must be a domain name"})
+	}
+	if k.Algorithm == "" {
+		k.Algorithm = DefaultAlgorithm
+	}
+	k.Algorithm = NormalizeAlgorithm(k.Algorithm)
+	if _, ok := algorithms[k.Algorithm]; !ok {
+		fes = append(fes, errors.FieldError{Field: "algorithm", Message: "must be one of " + strings.Join(Algorithms(), ", ")})
+	}
+	if k.Key != "" {
+		if _, err := base64.StdEncoding.DecodeString(k.Key); err != nil {
+			fes = append(fes, errors.FieldError{Field: "key", Message: "must be base64 encoded"})
+		}
+	}
+	if len(fes) > 0 {
+		err := errors.BadRequest.Newf("invalid TSIG key %s", k.Name)
+		for _, fe := range fes {
+			err = errors.AddFieldError(err, fe.Field, fe.Message)
+		}
+		return err
+	}
+
+	if k.Key == "" {
+		secret, err := Generate(k.Algorithm)
+		if err != nil {
+			return err
+		}
+		k.Key = secret
+	}
+	return nil
+}
+
+// Missing returns indexes of names absent in keys, names are compared without trailing dot
+func Missing(keys []TSIGKey, names []string) []int {
+	known := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		known[strings.ToLower(strings.TrimSuffix(k.Name, "."))] = true
+	}
+	var missing []int
+	for i, name := range names {
+		if !known[strings.ToLower(strings.TrimSuffix(name, "."))] {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,52 @@
+package tsigkey
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareGeneratesSecret(t *testing.T) {
+	for _, algorithm := range Algorithms() {
+		k := TSIGKey{Name: "transfer", Algorithm: algorithm}
+		require.NoError(t, k.Prepare())
+		secret, err := base64.StdEncoding.DecodeString(k.Key)
+		require.NoError(t, err)
+		require.Len(t, secret, algorithms[algorithm], algorithm)
+	}
+
+	k := TSIGKey{Name: "transfer"}
+	require.NoError(t, k.Prepare())
+	require.Equal(t, DefaultAlgorithm, k.Algorithm)
+
+	k = TSIGKey{Name: "transfer", Algorithm: "HMAC-MD5.SIG-ALG.REG.INT.", Key: "c2VjcmV0"}
+	require.NoError(t, k.Prepare())
+	require.Equal(t, "hmac-md5", k.Algorithm)
+	require.Equal(t, "c2VjcmV0", k.Key)
+}
+
+func TestPrepareInvalid(t *testing.T) {
+	k := TSIGKey{Name: "", Algorithm: "gss-tsig", Key: "not base64!"}
+	err := k.Prepare()
+	require.Equal(t, errors.BadRequest, errors.GetType(err))
+	var fields []string
+	for _, fe := range errors.GetFieldErrors(err) {
+		fields = append(fields, fe.Field)
+	}
+	require.Equal(t, []string{"name", "algorithm", "key"}, fields)
+}
+
+func TestRedacted(t *testing.T) {
+	b, err := json.Marshal(TSIGKey{ID: "transfer.", Name: "transfer", Algorithm: "hmac-sha256", Key: "c2VjcmV0"}.Redacted())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"transfer.","name":"transfer","algorithm":"hmac-sha256"}`, string(b))
+}
+
+func TestMissing(t *testing.T) {
+	keys := []TSIGKey{{ID: "transfer.", Name: "transfer."}, {ID: "notify.", Name: "Notify"}}
+	require.Empty(t, Missing(keys, []string{"transfer", "notify."}))
+	require.Equal(t, []int{1}, Missing(keys, []string{"transfer.", "axfr"}))
+}
@@ -0,0 +1,124 @@
+package zone
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// ACME DNS-01 challenge records, RFC 8555 section 8.4
+const (
+	// ACMEZoneType is a type of zones for LDAP authorization of changes of challenge records only
+	ACMEZoneType = "acme"
+	// ACMEChallengeLabel is the label prepended to validated domain names
+	ACMEChallengeLabel = "_acme-challenge"
+	// ACMEChallengeTTL is TTL of new challenge RRsets, short to let validation retries see changes
+	ACMEChallengeTTL = 60
+)
+
+// acmeValue matches base64url-encoded key authorization digests, RFC 8555 section 8.4
+var acmeValue = regexp.MustCompile(`^[A-Za-z0-9_-]{1,255}$`)
+
+// ACMEChallengeName returns canonical name of challenge TXT records for the domain.
+// The domain may already start with the challenge label, wildcard domains
+// are validated with records of their base domain. Returns BadRequest error with field error.
+func ACMEChallengeName(domain string) (string, error) {
+	name := strings.ToLower(dns.Fqdn(strings.TrimSpace(domain)))
+	name = strings.TrimPrefix(name, "*.")
+	if !strings.HasPrefix(name, ACMEChallengeLabel+".") {
+		name = ACMEChallengeLabel + "." + name
+	}
+	if _, ok := dns.IsDomainName(name); !ok || strings.TrimSpace(domain) == "" || strings.Contains(name, "*") || strings.Contains(name, "..") {
+		return "", errors.AddFieldError(errors.BadRequest.Newf("invalid domain %q", domain), "name", "must be a domain name")
+	}
+	return name, nil
+}
+
+// ValidateACMEValue checks that value is a key authorization digest, so challenge
+// records can't be used to publish arbitrary TXT data. Returns BadRequest error with field error.
+func ValidateACMEValue(value string) error {
+	if !acmeValue.MatchString(value) {
+		return errors.AddFieldError(errors.BadRequest.Newf("invalid challenge value %q", value), "value", "must be 1 to 255 base64url characters")
+	}
+	return nil
+}
+
+// FindZone returns the closest zone of zoneNames containing the name, or empty string if there is none
+func FindZone(zoneNames []string, name string) string {
+	name = strings.ToLower(dns.Fqdn(name))
+	var found string
+	for _, zoneName := range zoneNames {
+		zoneName = strings.ToLower(dns.Fqdn(zoneName))
+		if dns.IsSubDomain(zoneName, name) && dns.CountLabel(zoneName) >= dns.CountLabel(found) {
+			found = zoneName
+		}
+	}
+	return found
+}
+
+// ACMEChallengeChange returns the change of challenge TXT RRset name of the zone adding the value
+// or, if remove is true, removing it. Empty value with remove removes all records.
+// Values are the resulting enabled values of the RRset. The change is nil if the RRset is already as requested.
+func ACMEChallengeChange(z *zones.Zone, name, value string, remove bool) (change *zones.ResourceRecordSet, values []string) {
+	var (
+		current []zones.Record
+		ttl     = ACMEChallengeTTL
+		changed bool
+	)
+	if rrset := z.GetRecordSet(name, "TXT"); rrset != nil {
+		current = rrset.Records
+		ttl = rrset.TTL
+	}
+
+	records := make([]zones.Record, 0, len(current)+1)
+	var found bool
+	for _, record := range current {
+		match := TXTValue(name, record.Content) == value
+		found = found || match
+		switch {
+		case remove && (match || value == ""):
+			changed = true
+			continue
+		case match && record.Disabled:
+			record.Disabled = false
+			changed = true
+		}
+		records = append(records, record)
+	}
+	if !remove && !found {
+		records = append(records, zones.Record{Content: TXTContent(value)})
+		changed = true
+	}
+
+	values = []string{}
+	for _, record := range records {
+		if !record.Disabled {
+			values = append(values, TXTValue(name, record.Content))
+		}
+	}
+	if !changed {
+		return nil, values
+	}
+	if len(records) == 0 {
+		return &zones.ResourceRecordSet{Name: name, Type: "TXT", ChangeType: zones.ChangeTypeDelete}, values
+	}
+	return &zones.ResourceRecordSet{Name: name, Type: "TXT", TTL: ttl, ChangeType: zones.ChangeTypeReplace, Records: records}, values
+}
+
+// TXTContent returns content of TXT record with a single string in the form of PowerDNS API
+func TXTContent(value string) string {
+	return `"` + value + `"`
+}
+
+// TXTValue returns concatenated strings of TXT record content of PowerDNS API
+func TXTValue(name, content string) string {
+	rr, err := dns.NewRR(fmt.Sprintf("%s 0 IN TXT %s", name, content))
+	if err != nil || rr == nil {
+		return content
+	}
+	return strings.Join(rr.(*dns.TXT).Txt, "")
+}
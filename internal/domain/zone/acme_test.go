@@ -0,0 +1,83 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACMEChallengeName(t *testing.T) {
+	for domain, expected := range map[string]string{
+		"Example.com":                     "_acme-challenge.example.com.",
+		"*.example.com.":                  "_acme-challenge.example.com.",
+		"_acme-challenge.www.example.com": "_acme-challenge.www.example.com.",
+	} {
+		name, err := ACMEChallengeName(domain)
+		require.NoError(t, err)
+		require.Equal(t, expected, name, domain)
+	}
+
+	for _, domain := range []string{"", "www.*.example.com", "www..example.com"} {
+		_, err := ACMEChallengeName(domain)
+		require.Equal(t, errors.BadRequest, errors.GetType(err), domain)
+	}
+}
+
+func TestValidateACMEValue(t *testing.T) {
+	require.NoError(t, ValidateACMEValue("LoqXcYV8q5ONbJQxbmR7SCTNo3tiAXDfowyjxAjEuX0"))
+	for _, value := range []string{"", `"quoted"`, "v=spf1 -all"} {
+		require.Equal(t, errors.BadRequest, errors.GetType(ValidateACMEValue(value)), value)
+	}
+}
+
+func TestFindZone(t *testing.T) {
+	names := []string{"example.com.", "sub.example.com.", "example.org"}
+	require.Equal(t, "sub.example.com.", FindZone(names, "_acme-challenge.www.Sub.example.com."))
+	require.Equal(t, "example.com.", FindZone(names, "_acme-challenge.example.com."))
+	require.Equal(t, "example.org.", FindZone(names, "example.org."))
+	require.Empty(t, FindZone(names, "_acme-challenge.notexample.com."))
+}
+
+func TestACMEChallengeChange(t *testing.T) {
+	const name = "_acme-challenge.example.com."
+	z := &zones.Zone{
+		Name: "example.com.",
+		ResourceRecordSets: []zones.ResourceRecordSet{
+			{Name: name, Type: "TXT", TTL: 120, Records: []zones.Record{{Content: `"first"`}, {Content: `"second"`, Disabled: true}}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		zone   *zones.Zone
+		value  string
+		remove bool
+		change *zones.ResourceRecordSet
+		values []string
+	}{
+		{"add to new RRset", &zones.Zone{Name: "example.com."}, "first", false,
+			&zones.ResourceRecordSet{Name: name, Type: "TXT", TTL: ACMEChallengeTTL, ChangeType: zones.ChangeTypeReplace, Records: []zones.Record{{Content: `"first"`}}},
+			[]string{"first"}},
+		{"add", z, "third", false,
+			&zones.ResourceRecordSet{Name: name, Type: "TXT", TTL: 120, ChangeType: zones.ChangeTypeReplace, Records: []zones.Record{{Content: `"first"`}, {Content: `"second"`, Disabled: true}, {Content: `"third"`}}},
+			[]string{"first", "third"}},
+		{"add existing", z, "first", false, nil, []string{"first"}},
+		{"enable", z, "second", false,
+			&zones.ResourceRecordSet{Name: name, Type: "TXT", TTL: 120, ChangeType: zones.ChangeTypeReplace, Records: []zones.Record{{Content: `"first"`}, {Content: `"second"`}}},
+			[]string{"first", "second"}},
+		{"remove", z, "first", true,
+			&zones.ResourceRecordSet{Name: name, Type: "TXT", TTL: 120, ChangeType: zones.ChangeTypeReplace, Records: []zones.Record{{Content: `"second"`, Disabled: true}}},
+			[]string{}},
+		{"remove absent", z, "third", true, nil, []string{"first"}},
+		{"remove all", z, "", true, &zones.ResourceRecordSet{Name: name, Type: "TXT", ChangeType: zones.ChangeTypeDelete}, []string{}},
+		{"remove from absent RRset", &zones.Zone{Name: "example.com."}, "", true, nil, []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			change, values := ACMEChallengeChange(tc.zone, name, tc.value, tc.remove)
+			require.Equal(t, tc.change, change)
+			require.Equal(t, tc.values, values)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package zone
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+)
+
+// State is a state of the zone on a single node, compared between nodes to find divergence
+type State struct {
+	Serial int `json:"serial"`
+	// RRSetsHash is a hash of the zone RRsets, empty if it was not requested
+	RRSetsHash string `json:"rrsets_hash,omitempty"`
+}
+
+// HashRRSets returns a hash of RRsets which doesn't depend on their order, case of names and comments
+func HashRRSets(rrsets []zones.ResourceRecordSet) string {
+	normalized := make([]zones.ResourceRecordSet, len(rrsets))
+	for i, rrset := range rrsets {
+		records := make([]zones.Record, len(rrset.Records))
+		copy(records, rrset.Records)
+		normalized[i] = zones.ResourceRecordSet{
+			Name:    strings.ToLower(rrset.Name),
+			Type:    strings.ToUpper(rrset.Type),
+			TTL:     rrset.TTL,
+			Records: records,
+		}
+	}
+	sortRRSets(normalized)
+
+	h := sha256.New()
+	for _, rrset := range normalized {
+		for _, record := range rrset.Records {
+			fmt.Fprintf(h, "%s\t%d\t%s\t%s\t%t\n", rrset.Name, rrset.TTL, rrset.Type, record.Content, record.Disabled)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ReferenceState returns the state most of nodes have, ties go to the higher serial.
+// Returns false if there are no states.
+func ReferenceState(states []State) (State, bool) {
+	counts := make(map[State]int)
+	var ref State
+	for _, state := range states {
+		counts[state]++
+		n, refN := counts[state], counts[ref]
+		if n > refN || (n == refN && state.Serial > ref.Serial) {
+			ref = state
+		}
+	}
+	return ref, len(states) > 0
+}
@@ -0,0 +1,45 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashRRSets(t *testing.T) {
+	a := []zones.ResourceRecordSet{
+		{Name: "www.example.com.", Type: "A", TTL: 300, Records: []zones.Record{{Content: "192.0.2.1"}, {Content: "192.0.2.2"}}},
+		{Name: "example.com.", Type: "MX", TTL: 3600, Records: []zones.Record{{Content: "10 mail.example.com."}}},
+	}
+	b := []zones.ResourceRecordSet{
+		{Name: "example.com.", Type: "MX", TTL: 3600, Records: []zones.Record{{Content: "10 mail.example.com."}}},
+		{Name: "WWW.example.com.", Type: "A", TTL: 300, Records: []zones.Record{{Content: "192.0.2.2"}, {Content: "192.0.2.1"}},
+			Comments: []zones.Comment{{Content: "web"}}},
+	}
+	require.Equal(t, HashRRSets(a), HashRRSets(b))
+	// Input is not modified
+	require.Equal(t, "WWW.example.com.", b[1].Name)
+	require.Equal(t, "192.0.2.2", b[1].Records[0].Content)
+
+	b[1].TTL = 60
+	require.NotEqual(t, HashRRSets(a), HashRRSets(b))
+	b[1].TTL = 300
+	b[1].Records[0].Disabled = true
+	require.NotEqual(t, HashRRSets(a), HashRRSets(b))
+}
+
+func TestReferenceState(t *testing.T) {
+	_, ok := ReferenceState(nil)
+	require.False(t, ok)
+
+	ref, ok := ReferenceState([]State{{Serial: 1}, {Serial: 2}, {Serial: 1}})
+	require.True(t, ok)
+	require.Equal(t, State{Serial: 1}, ref)
+
+	ref, _ = ReferenceState([]State{{Serial: 1}, {Serial: 2}})
+	require.Equal(t, State{Serial: 2}, ref)
+
+	ref, _ = ReferenceState([]State{{Serial: 2, RRSetsHash: "a"}, {Serial: 2, RRSetsHash: "b"}, {Serial: 2, RRSetsHash: "b"}})
+	require.Equal(t, State{Serial: 2, RRSetsHash: "b"}, ref)
+}
@@ -0,0 +1,52 @@
+package zone
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+)
+
+// RRSetsFromDNS groups DNS records into RRsets in the form of PowerDNS API,
+// sorted by name and type with sorted records, so they can be compared with the API.
+// TTL of RRset is the TTL of its first record.
+func RRSetsFromDNS(rrs []dns.RR) []zones.ResourceRecordSet {
+	index := make(map[string]int)
+	var rrsets []zones.ResourceRecordSet
+	for _, rr := range rrs {
+		h := rr.Header()
+		name := strings.ToLower(h.Name)
+		rrType := dns.TypeToString[h.Rrtype]
+		key := name + "/" + rrType
+		i, ok := index[key]
+		if !ok {
+			i = len(rrsets)
+			index[key] = i
+			rrsets = append(rrsets, zones.ResourceRecordSet{Name: name, Type: rrType, TTL: int(h.Ttl)})
+		}
+		rrsets[i].Records = append(rrsets[i].Records, zones.Record{Content: RecordContent(rr)})
+	}
+
+	sortRRSets(rrsets)
+	return rrsets
+}
+
+// sortRRSets sorts RRsets by name and type and their records by content
+func sortRRSets(rrsets []zones.ResourceRecordSet) {
+	for _, rrset := range rrsets {
+		records := rrset.Records
+		sort.Slice(records, func(i, j int) bool { return records[i].Content < records[j].Content })
+	}
+	sort.Slice(rrsets, func(i, j int) bool {
+		if rrsets[i].Name != rrsets[j].Name {
+			return rrsets[i].Name < rrsets[j].Name
+		}
+		return rrsets[i].Type < rrsets[j].Type
+	})
+}
+
+// RecordContent returns the record data without header, like content of PowerDNS API records
+func RecordContent(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
@@ -0,0 +1,31 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRRSetsFromDNS(t *testing.T) {
+	var rrs []dns.RR
+	for _, s := range []string{
+		"example.com. 3600 IN SOA ns1.example.com. hostmaster.example.com. 1 10800 3600 604800 3600",
+		"WWW.example.com. 300 IN A 192.0.2.2",
+		"www.example.com. 300 IN A 192.0.2.1",
+		"example.com. 3600 IN MX 10 mail.example.com.",
+		"example.com. 300 IN TXT \"v=spf1 -all\"",
+	} {
+		rr, err := dns.NewRR(s)
+		require.NoError(t, err)
+		rrs = append(rrs, rr)
+	}
+
+	require.Equal(t, []zones.ResourceRecordSet{
+		{Name: "example.com.", Type: "MX", TTL: 3600, Records: []zones.Record{{Content: "10 mail.example.com."}}},
+		{Name: "example.com.", Type: "SOA", TTL: 3600, Records: []zones.Record{{Content: "ns1.example.com. hostmaster.example.com. 1 10800 3600 604800 3600"}}},
+		{Name: "example.com.", Type: "TXT", TTL: 300, Records: []zones.Record{{Content: `"v=spf1 -all"`}}},
+		{Name: "www.example.com.", Type: "A", TTL: 300, Records: []zones.Record{{Content: "192.0.2.1"}, {Content: "192.0.2.2"}}},
+	}, RRSetsFromDNS(rrs))
+}
@@ -53,7 +53,7 @@ func (s *PTR) AddPTR(ctx context.Context, serverID string, zoneID string, rrset
 		}
 		err = s.auth.Zones().AddRecordSetToZone(ctx, serverID, "10.in-addr.arpa", ptrRRSet)
 		if err != nil {
-			return errors.Wrapf(err, "failed to update reverse zone %s", LocalReverseZone)
+			return errors.WrapPDNS(err, "failed to update reverse zone %s", LocalReverseZone)
 		}
 		s.logger.Infof("Reverse record %s was added with content %s", reverse, rrset.Name)
 	}
@@ -70,14 +70,14 @@ func (s *PTR) DelPTR(ctx context.Context, serverID string, zoneID string, rrset
 
 	results, err := s.auth.Search().Search(ctx, serverID, network.DeCanonicalize(rrset.Name), 10, search.ObjectTypeRecord)
 	if err != nil {
-		return errors.Wrapf(err, "searching for zone %s and RR %s", zoneID, rrset.Name)
+		return errors.WrapPDNS(err, "searching for zone %s and RR %s", zoneID, rrset.Name)
 	}
 	for _, result := range results {
 		if strings.ToUpper(result.Type) == "PTR" {
 			s.logger.Infof("Remove old PTR %s", result.Name)
 			err = s.auth.Zones().RemoveRecordSetFromZone(ctx, serverID, "10.in-addr.arpa.", result.Name, result.Type)
 			if err != nil {
-				return errors.Wrapf(err, "deleting RR %s from reverse zone %s", result.Name, LocalReverseZone)
+				return errors.WrapPDNS(err, "deleting RR %s from reverse zone %s", result.Name, LocalReverseZone)
 			}
 		}
 	}
@@ -0,0 +1,136 @@
+package zone
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/miekg/dns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// TemplateStore looks up zone templates in Consul KV and then in config
+type TemplateStore struct {
+	templates map[string]config.ZoneTemplate
+	consul    *api.Client
+	prefix    string
+}
+
+func NewTemplateStore(cfg config.ZoneTemplatesConfig, consul *api.Client) *TemplateStore {
+	templates := make(map[string]config.ZoneTemplate, len(cfg.Templates))
+	for name, tmpl := range cfg.Templates {
+		templates[strings.ToLower(name)] = tmpl
+	}
+	return &TemplateStore{templates: templates, consul: consul, prefix: cfg.ConsulPrefix}
+}
+
+// Get returns the template by name, BadRequest error if there is no such template
+func (s *TemplateStore) Get(name string) (*config.ZoneTemplate, error) {
+	name = strings.ToLower(name)
+	if s.consul != nil && s.prefix != "" {
+		pair, _, err := s.consul.KV().Get(path.Join(s.prefix, name), nil)
+		if err != nil {
+			return nil, errors.Unavailable.Wrapf(err, "reading zone template %s from Consul", name)
+		}
+		if pair != nil {
+			tmpl := new(config.ZoneTemplate)
+			if err := json.Unmarshal(pair.Value, tmpl); err != nil {
+				return nil, errors.Wrapf(err, "decoding zone template %s", name)
+			}
+			return tmpl, nil
+		}
+	}
+	if tmpl, ok := s.templates[name]; ok {
+		return &tmpl, nil
+	}
+	return nil, errors.AddFieldError(
+		errors.BadRequest.Newf("unknown zone template %s", name),
+		"template", "must be a name of a zone template",
+	)
+}
+
+// ApplyTemplate returns the zone with defaults from the template.
+// Kind, nameservers, SOA-EDIT-API, DNSSEC settings and RRsets set in the zone override the template.
+// Slave zones get RRsets from masters, so nameservers and RRsets of the template are not applied to them.
+func ApplyTemplate(tmpl *config.ZoneTemplate, z zones.Zone) (zones.Zone, error) {
+	name := dns.Fqdn(z.Name)
+
+	if z.Kind == 0 && tmpl.Kind != "" {
+		if err := z.Kind.UnmarshalJSON([]byte(fmt.Sprintf("%q", tmpl.Kind))); err != nil {
+			return z, errors.Wrapf(err, "zone template kind")
+		}
+	}
+	if z.SOAEditAPI == "" {
+		z.SOAEditAPI = tmpl.SOAEditAPI
+	}
+	if !z.DNSSec && tmpl.DNSSEC.Enabled {
+		z.DNSSec = true
+		if z.NSec3Param == "" {
+			z.NSec3Param = tmpl.DNSSEC.NSEC3Param
+			z.NSec3Narrow = tmpl.DNSSEC.NSEC3Narrow
+		}
+	}
+
+	if z.Kind == zones.ZoneKindSlave {
+		return z, nil
+	}
+	if len(z.Nameservers) == 0 {
+		z.Nameservers = append(zones.ZoneNameservers(nil), tmpl.Nameservers...)
+	}
+
+	var rrsets []zones.ResourceRecordSet
+	if tmpl.SOA.Primary != "" {
+		soa := tmpl.SOA
+		rrsets = append(rrsets, zones.ResourceRecordSet{
+			Name: name,
+			Type: "SOA",
+			TTL:  soa.TTL,
+			Records: []zones.Record{{
+				Content: fmt.Sprintf("%s %s 1 %d %d %d %d", templateName(soa.Primary, name), templateName(soa.Hostmaster, name), soa.Refresh, soa.Retry, soa.Expire, soa.Minimum),
+			}},
+		})
+	}
+	for _, rec := range tmpl.Records {
+		rrset := zones.ResourceRecordSet{
+			Name: templateName(rec.Name, name),
+			Type: strings.ToUpper(rec.Type),
+			TTL:  rec.TTL,
+		}
+		for _, content := range rec.Records {
+			rrset.Records = append(rrset.Records, zones.Record{Content: content})
+		}
+		rrsets = append(rrsets, rrset)
+	}
+	for _, rrset := range rrsets {
+		if z.GetRecordSet(rrset.Name, rrset.Type) == nil {
+			z.ResourceRecordSets = append(z.ResourceRecordSets, rrset)
+		}
+	}
+
+	return z, nil
+}
+
+// TemplateMetadata returns metadata of the template with upper-case kinds
+func TemplateMetadata(tmpl *config.ZoneTemplate) map[string][]string {
+	metadata := make(map[string][]string, len(tmpl.Metadata))
+	for kind, values := range tmpl.Metadata {
+		metadata[strings.ToUpper(kind)] = values
+	}
+	return metadata
+}
+
+// templateName returns an absolute name for a name relative to the zone
+func templateName(name, zoneName string) string {
+	switch {
+	case name == "" || name == "@":
+		return zoneName
+	case dns.IsFqdn(name):
+		return name
+	default:
+		return name + "." + zoneName
+	}
+}
@@ -0,0 +1,110 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func testTemplate() *config.ZoneTemplate {
+	return &config.ZoneTemplate{
+		Kind:        "Master",
+		Nameservers: []string{"ns1.example.net.", "ns2.example.net."},
+		SOA: config.ZoneTemplateSOA{
+			Primary:    "ns1.example.net.",
+			Hostmaster: "hostmaster",
+			Refresh:    10800,
+			Retry:      3600,
+			Expire:     604800,
+			Minimum:    3600,
+			TTL:        3600,
+		},
+		SOAEditAPI: "DEFAULT",
+		Metadata:   map[string][]string{"allow-axfr-from": {"10.0.0.0/8"}},
+		Records: []config.ZoneTemplateRecord{
+			{Name: "@", Type: "txt", TTL: 300, Records: []string{`"baseline"`}},
+			{Name: "www", Type: "A", TTL: 300, Records: []string{"10.0.0.1"}},
+		},
+		DNSSEC: config.ZoneTemplateDNSSEC{Enabled: true, NSEC3Param: "1 0 0 -"},
+	}
+}
+
+func TestApplyTemplate(t *testing.T) {
+	z, err := ApplyTemplate(testTemplate(), zones.Zone{Name: "example.com."})
+	require.NoError(t, err)
+
+	require.Equal(t, zones.ZoneKindMaster, z.Kind)
+	require.Equal(t, zones.ZoneNameservers{"ns1.example.net.", "ns2.example.net."}, z.Nameservers)
+	require.Equal(t, "DEFAULT", z.SOAEditAPI)
+	require.True(t, z.DNSSec)
+	require.Equal(t, "1 0 0 -", z.NSec3Param)
+
+	soa := z.GetRecordSet("example.com.", "SOA")
+	require.NotNil(t, soa)
+	require.Equal(t, "ns1.example.net. hostmaster.example.com. 1 10800 3600 604800 3600", soa.Records[0].Content)
+	require.NotNil(t, z.GetRecordSet("example.com.", "TXT"))
+	require.NotNil(t, z.GetRecordSet("www.example.com.", "A"))
+
+	// Templates are valid RRsets
+	require.NoError(t, ValidateRRSets(&zones.Zone{Name: z.Name}, replaceAll(z.ResourceRecordSets), rrsetsField))
+}
+
+func TestApplyTemplateOverrides(t *testing.T) {
+	input := zones.Zone{
+		Name:        "example.com.",
+		Kind:        zones.ZoneKindNative,
+		Nameservers: zones.ZoneNameservers{"ns.example.com."},
+		ResourceRecordSets: []zones.ResourceRecordSet{
+			{Name: "www.example.com.", Type: "A", TTL: 60, Records: []zones.Record{{Content: "10.0.0.2"}}},
+		},
+	}
+	z, err := ApplyTemplate(testTemplate(), input)
+	require.NoError(t, err)
+
+	require.Equal(t, zones.ZoneKindNative, z.Kind)
+	require.Equal(t, zones.ZoneNameservers{"ns.example.com."}, z.Nameservers)
+	www := z.GetRecordSet("www.example.com.", "A")
+	require.Equal(t, "10.0.0.2", www.Records[0].Content)
+	require.Len(t, z.ResourceRecordSets, 3)
+}
+
+func TestApplyTemplateBadKind(t *testing.T) {
+	_, err := ApplyTemplate(&config.ZoneTemplate{Kind: "Primary"}, zones.Zone{Name: "example.com."})
+	require.Error(t, err)
+}
+
+func TestTemplateMetadata(t *testing.T) {
+	require.Equal(t, map[string][]string{"ALLOW-AXFR-FROM": {"10.0.0.0/8"}}, TemplateMetadata(testTemplate()))
+}
+
+func TestTemplateStoreGet(t *testing.T) {
+	store := NewTemplateStore(config.ZoneTemplatesConfig{
+		Templates: map[string]config.ZoneTemplate{"internal": *testTemplate()},
+	}, nil)
+
+	tmpl, err := store.Get("Internal")
+	require.NoError(t, err)
+	require.Equal(t, "Master", tmpl.Kind)
+
+	_, err = store.Get("external")
+	require.Equal(t, errors.BadRequest, errors.GetType(err))
+	require.Equal(t, "template", errors.GetFieldErrors(err)[0].Field)
+}
+
+func replaceAll(rrsets []zones.ResourceRecordSet) []zones.ResourceRecordSet {
+	for i := range rrsets {
+		rrsets[i].ChangeType = zones.ChangeTypeReplace
+	}
+	return rrsets
+}
+
+func TestApplyTemplateSlave(t *testing.T) {
+	z, err := ApplyTemplate(testTemplate(), zones.Zone{Name: "example.com.", Kind: zones.ZoneKindSlave, Masters: []string{"192.0.2.1"}})
+	require.NoError(t, err)
+	require.Empty(t, z.Nameservers)
+	require.Empty(t, z.ResourceRecordSets)
+	require.NoError(t, (&NewZone{Zone: z}).Validate())
+}
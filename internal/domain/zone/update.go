@@ -0,0 +1,312 @@
+package zone
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+)
+
+// UpdateError is a failure of DNS UPDATE (RFC 2136) with the response code
+type UpdateError struct {
+	Rcode   int
+	Message string
+}
+
+func (e *UpdateError) Error() string {
+	return e.Message
+}
+
+func updateErrorf(rcode int, format string, args ...interface{}) error {
+	return &UpdateError{Rcode: rcode, Message: fmt.Sprintf(format, args...)}
+}
+
+// updateRRSet is a state of the zone RRset while updates are applied
+type updateRRSet struct {
+	name    string
+	rrType  string
+	ttl     int
+	records []zones.Record
+	// existed is true if the RRset is in the zone before the update
+	existed bool
+	changed bool
+}
+
+// find returns index of the record with content of rr, or -1
+func (s *updateRRSet) find(rr dns.RR) int {
+	content := RecordContent(rr)
+	for i, record := range s.records {
+		if normalizeContent(s.name, s.rrType, record.Content) == content {
+			return i
+		}
+	}
+	return -1
+}
+
+// contents returns normalized content of enabled records
+func (s *updateRRSet) contents() map[string]bool {
+	set := make(map[string]bool, len(s.records))
+	for _, record := range s.records {
+		if !record.Disabled {
+			set[normalizeContent(s.name, s.rrType, record.Content)] = true
+		}
+	}
+	return set
+}
+
+func (s *updateRRSet) exists() bool {
+	return len(s.contents()) > 0
+}
+
+// zoneUpdate applies DNS UPDATE to RRsets of the zone
+type zoneUpdate struct {
+	zone   string
+	rrsets map[string]*updateRRSet
+	// order of RRsets to keep changes in order of updates
+	order []string
+}
+
+func newZoneUpdate(z *zones.Zone) *zoneUpdate {
+	u := &zoneUpdate{zone: strings.ToLower(dns.Fqdn(z.Name)), rrsets: make(map[string]*updateRRSet)}
+	for _, rrset := range z.ResourceRecordSets {
+		name := strings.ToLower(dns.Fqdn(rrset.Name))
+		rrType := strings.ToUpper(rrset.Type)
+		records := make([]zones.Record, len(rrset.Records))
+		copy(records, rrset.Records)
+		u.rrsets[name+"/"+rrType] = &updateRRSet{name: name, rrType: rrType, ttl: rrset.TTL, records: records, existed: true}
+	}
+	return u
+}
+
+// rrset returns the RRset by name and type, a new empty RRset if it doesn't exist
+func (u *zoneUpdate) rrset(name string, rrtype uint16) *updateRRSet {
+	name = strings.ToLower(name)
+	rrType := dns.TypeToString[rrtype]
+	key := name + "/" + rrType
+	s, ok := u.rrsets[key]
+	if !ok {
+		s = &updateRRSet{name: name, rrType: rrType}
+		u.rrsets[key] = s
+	}
+	return s
+}
+
+// atName returns RRsets of the name with records
+func (u *zoneUpdate) atName(name string) []*updateRRSet {
+	name = strings.ToLower(name)
+	var rrsets []*updateRRSet
+	for _, s := range u.rrsets {
+		if s.name == name && s.exists() {
+			rrsets = append(rrsets, s)
+		}
+	}
+	return rrsets
+}
+
+func (u *zoneUpdate) change(s *updateRRSet) {
+	if !s.changed {
+		s.changed = true
+		u.order = append(u.order, s.name+"/"+s.rrType)
+	}
+}
+
+// changes returns changed RRsets in the form of PATCH of PowerDNS API
+func (u *zoneUpdate) changes() []zones.ResourceRecordSet {
+	var rrsets []zones.ResourceRecordSet
+	for _, key := range u.order {
+		s := u.rrsets[key]
+		switch {
+		case len(s.records) > 0:
+			rrsets = append(rrsets, zones.ResourceRecordSet{
+				Name:       s.name,
+				Type:       s.rrType,
+				TTL:        s.ttl,
+				ChangeType: zones.ChangeTypeReplace,
+				Records:    s.records,
+			})
+		case s.existed:
+			rrsets = append(rrsets, zones.ResourceRecordSet{Name: s.name, Type: s.rrType, ChangeType: zones.ChangeTypeDelete})
+		}
+	}
+	return rrsets
+}
+
+// CheckPrerequisites checks prerequisites of DNS UPDATE against the zone, RFC 2136 section 3.2
+func CheckPrerequisites(z *zones.Zone, prereqs []dns.RR) error {
+	u := newZoneUpdate(z)
+	// RRsets of value dependent prerequisites
+	expected := make(map[*updateRRSet]map[string]bool)
+	for _, rr := range prereqs {
+		h := rr.Header()
+		if h.Ttl != 0 {
+			return updateErrorf(dns.RcodeFormatError, "prerequisite %s %s has non-zero TTL", h.Name, dns.TypeToString[h.Rrtype])
+		}
+		if !dns.IsSubDomain(u.zone, h.Name) {
+			return updateErrorf(dns.RcodeNotZone, "prerequisite %s is outside of zone %s", h.Name, u.zone)
+		}
+		switch h.Class {
+		case dns.ClassANY, dns.ClassNONE:
+			if h.Rdlength != 0 {
+				return updateErrorf(dns.RcodeFormatError, "prerequisite %s %s has data", h.Name, dns.TypeToString[h.Rrtype])
+			}
+			inUse := len(u.atName(h.Name)) > 0
+			exists := h.Rrtype != dns.TypeANY && u.rrset(h.Name, h.Rrtype).exists()
+			switch {
+			case h.Class == dns.ClassANY && h.Rrtype == dns.TypeANY && !inUse:
+				return updateErrorf(dns.RcodeNameError, "name %s is not in use", h.Name)
+			case h.Class == dns.ClassANY && h.Rrtype != dns.TypeANY && !exists:
+				return updateErrorf(dns.RcodeNXRrset, "RRset %s %s does not exist", h.Name, dns.TypeToString[h.Rrtype])
+			case h.Class == dns.ClassNONE && h.Rrtype == dns.TypeANY && inUse:
+				return updateErrorf(dns.RcodeYXDomain, "name %s is in use", h.Name)
+			case h.Class == dns.ClassNONE && h.Rrtype != dns.TypeANY && exists:
+				return updateErrorf(dns.RcodeYXRrset, "RRset %s %s exists", h.Name, dns.TypeToString[h.Rrtype])
+			}
+		case dns.ClassINET:
+			s := u.rrset(h.Name, h.Rrtype)
+			if expected[s] == nil {
+				expected[s] = make(map[string]bool)
+			}
+			expected[s][RecordContent(rr)] = true
+		default:
+			return updateErrorf(dns.RcodeFormatError, "prerequisite %s has invalid class %s", h.Name, dns.ClassToString[h.Class])
+		}
+	}
+
+	for s, contents := range expected {
+		if !equalSets(s.contents(), contents) {
+			return updateErrorf(dns.RcodeNXRrset, "RRset %s %s does not match", s.name, s.rrType)
+		}
+	}
+	return nil
+}
+
+// UpdateRRSets translates updates of DNS UPDATE into RRset changes of the zone,
+// RFC 2136 section 3.4. SOA updates are ignored, PowerDNS maintains the serial.
+func UpdateRRSets(z *zones.Zone, updates []dns.RR) ([]zones.ResourceRecordSet, error) {
+	u := newZoneUpdate(z)
+
+	// Prescan, section 3.4.1.3
+	for _, rr := range updates {
+		h := rr.Header()
+		if !dns.IsSubDomain(u.zone, h.Name) {
+			return nil, updateErrorf(dns.RcodeNotZone, "update %s is outside of zone %s", h.Name, u.zone)
+		}
+		var invalid bool
+		switch h.Class {
+		case dns.ClassINET:
+			invalid = isMetaType(h.Rrtype) || h.Rrtype == dns.TypeANY
+		case dns.ClassANY:
+			invalid = h.Ttl != 0 || h.Rdlength != 0 || isMetaType(h.Rrtype)
+		case dns.ClassNONE:
+			invalid = h.Ttl != 0 || isMetaType(h.Rrtype) || h.Rrtype == dns.TypeANY
+		default:
+			invalid = true
+		}
+		if invalid {
+			return nil, updateErrorf(dns.RcodeFormatError, "invalid update %s %s %s", h.Name, dns.ClassToString[h.Class], dns.TypeToString[h.Rrtype])
+		}
+	}
+
+	for _, rr := range updates {
+		h := rr.Header()
+		apex := strings.EqualFold(h.Name, u.zone)
+		switch h.Class {
+		case dns.ClassINET:
+			u.add(rr)
+		case dns.ClassANY:
+			if h.Rrtype == dns.TypeANY {
+				for _, s := range u.atName(h.Name) {
+					if apex && (s.rrType == "SOA" || s.rrType == "NS") {
+						continue
+					}
+					s.records = nil
+					u.change(s)
+				}
+				continue
+			}
+			if apex && (h.Rrtype == dns.TypeSOA || h.Rrtype == dns.TypeNS) {
+				continue
+			}
+			if s := u.rrset(h.Name, h.Rrtype); len(s.records) > 0 {
+				s.records = nil
+				u.change(s)
+			}
+		case dns.ClassNONE:
+			if h.Rrtype == dns.TypeSOA {
+				continue
+			}
+			s := u.rrset(h.Name, h.Rrtype)
+			i := s.find(rr)
+			if i < 0 {
+				continue
+			}
+			// The zone must keep its name servers
+			if apex && h.Rrtype == dns.TypeNS && len(s.records) == 1 {
+				continue
+			}
+			s.records = append(s.records[:i:i], s.records[i+1:]...)
+			u.change(s)
+		}
+	}
+	return u.changes(), nil
+}
+
+// add adds the record to its RRset, section 3.4.2.2
+func (u *zoneUpdate) add(rr dns.RR) {
+	h := rr.Header()
+	if h.Rrtype == dns.TypeSOA {
+		return
+	}
+	// CNAME can't coexist with other data
+	for _, s := range u.atName(h.Name) {
+		if (h.Rrtype == dns.TypeCNAME) != (s.rrType == "CNAME") {
+			return
+		}
+	}
+
+	s := u.rrset(h.Name, h.Rrtype)
+	record := zones.Record{Content: RecordContent(rr)}
+	switch i := s.find(rr); {
+	case h.Rrtype == dns.TypeCNAME:
+		s.records = []zones.Record{record}
+	case i >= 0:
+		if !s.records[i].Disabled && s.ttl == int(h.Ttl) {
+			return
+		}
+		s.records[i].Disabled = false
+	default:
+		s.records = append(s.records, record)
+	}
+	s.ttl = int(h.Ttl)
+	u.change(s)
+}
+
+func isMetaType(t uint16) bool {
+	switch t {
+	case dns.TypeAXFR, dns.TypeIXFR, dns.TypeMAILA, dns.TypeMAILB:
+		return true
+	}
+	return false
+}
+
+// normalizeContent returns content of PowerDNS record as served over DNS
+func normalizeContent(name, rrType, content string) string {
+	rr, err := dns.NewRR(fmt.Sprintf("%s 0 IN %s %s", name, rrType, content))
+	if err != nil || rr == nil {
+		return content
+	}
+	return RecordContent(rr)
+}
+
+func equalSets(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if !b[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/stretchr/testify/require"
+)
+
+func updateZone() *zones.Zone {
+	return &zones.Zone{
+		Name: "example.com.",
+		ResourceRecordSets: []zones.ResourceRecordSet{
+			{Name: "example.com.", Type: "SOA", TTL: 3600, Records: []zones.Record{{Content: "ns1.example.com. hostmaster.example.com. 1 10800 3600 604800 3600"}}},
+			{Name: "example.com.", Type: "NS", TTL: 3600, Records: []zones.Record{{Content: "ns1.example.com."}}},
+			{Name: "example.com.", Type: "TXT", TTL: 3600, Records: []zones.Record{{Content: `"v=spf1 -all"`}}},
+			{Name: "www.example.com.", Type: "A", TTL: 300, Records: []zones.Record{{Content: "192.0.2.1"}, {Content: "192.0.2.2"}}},
+			{Name: "old.example.com.", Type: "CNAME", TTL: 300, Records: []zones.Record{{Content: "www.example.com."}}},
+		},
+	}
+}
+
+// wire returns the message as received from network, RDLENGTH is set by unpacking
+func wire(t *testing.T, m *dns.Msg) *dns.Msg {
+	b, err := m.Pack()
+	require.NoError(t, err)
+	received := new(dns.Msg)
+	require.NoError(t, received.Unpack(b))
+	return received
+}
+
+func rrs(t *testing.T, records ...string) []dns.RR {
+	var result []dns.RR
+	for _, s := range records {
+		rr, err := dns.NewRR(s)
+		require.NoError(t, err)
+		result = append(result, rr)
+	}
+	return result
+}
+
+func rcode(err error) int {
+	if e, ok := err.(*UpdateError); ok {
+		return e.Rcode
+	}
+	return -1
+}
+
+func TestCheckPrerequisites(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		prereqs func(m *dns.Msg)
+		rcode   int
+	}{
+		{"name in use", func(m *dns.Msg) { m.NameUsed(rrs(t, "WWW.example.com. 0 IN A")) }, dns.RcodeSuccess},
+		{"name not in use", func(m *dns.Msg) { m.NameUsed(rrs(t, "new.example.com. 0 IN A")) }, dns.RcodeNameError},
+		{"RRset exists", func(m *dns.Msg) { m.RRsetUsed(rrs(t, "www.example.com. 0 IN A")) }, dns.RcodeSuccess},
+		{"RRset does not exist", func(m *dns.Msg) { m.RRsetUsed(rrs(t, "www.example.com. 0 IN AAAA")) }, dns.RcodeNXRrset},
+		{"name is free", func(m *dns.Msg) { m.NameNotUsed(rrs(t, "new.example.com. 0 IN A")) }, dns.RcodeSuccess},
+		{"name is taken", func(m *dns.Msg) { m.NameNotUsed(rrs(t, "old.example.com. 0 IN A")) }, dns.RcodeYXDomain},
+		{"RRset is free", func(m *dns.Msg) { m.RRsetNotUsed(rrs(t, "www.example.com. 0 IN AAAA")) }, dns.RcodeSuccess},
+		{"RRset is taken", func(m *dns.Msg) { m.RRsetNotUsed(rrs(t, "www.example.com. 0 IN A")) }, dns.RcodeYXRrset},
+		{"RRset matches", func(m *dns.Msg) {
+			m.Used(rrs(t, "www.example.com. 0 IN A 192.0.2.2", "www.example.com. 0 IN A 192.0.2.1", "example.com. 0 IN TXT \"v=spf1 -all\""))
+		}, dns.RcodeSuccess},
+		{"RRset differs", func(m *dns.Msg) { m.Used(rrs(t, "www.example.com. 0 IN A 192.0.2.1")) }, dns.RcodeNXRrset},
+		{"outside of zone", func(m *dns.Msg) { m.NameUsed(rrs(t, "www.example.org. 0 IN A")) }, dns.RcodeNotZone},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(dns.Msg)
+			m.SetUpdate("example.com.")
+			tc.prereqs(m)
+			err := CheckPrerequisites(updateZone(), wire(t, m).Answer)
+			if tc.rcode == dns.RcodeSuccess {
+				require.NoError(t, err)
+				return
+			}
+			require.Equal(t, tc.rcode, rcode(err), "%v", err)
+		})
+	}
+}
+
+func TestUpdateRRSets(t *testing.T) {
+	m := new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Insert(rrs(t,
+		"host.example.com. 60 IN A 192.0.2.10",
+		"host.example.com. 60 IN A 192.0.2.11",
+		// Already exists
+		"www.example.com. 300 IN A 192.0.2.1",
+		// Conflicts with CNAME
+		"old.example.com. 60 IN A 192.0.2.12",
+		"example.com. 60 IN SOA ns1.example.com. hostmaster.example.com. 100 10800 3600 604800 3600",
+	))
+	m.Remove(rrs(t, "www.example.com. 0 IN A 192.0.2.2", "example.com. 0 IN NS ns1.example.com."))
+	m.RemoveRRset(rrs(t, "old.example.com. 0 IN CNAME", "example.com. 0 IN NS"))
+	m.RemoveName(rrs(t, "example.com. 0 IN ANY"))
+
+	rrsets, err := UpdateRRSets(updateZone(), wire(t, m).Ns)
+	require.NoError(t, err)
+	require.Equal(t, []zones.ResourceRecordSet{
+		{Name: "host.example.com.", Type: "A", TTL: 60, ChangeType: zones.ChangeTypeReplace, Records: []zones.Record{{Content: "192.0.2.10"}, {Content: "192.0.2.11"}}},
+		{Name: "www.example.com.", Type: "A", TTL: 300, ChangeType: zones.ChangeTypeReplace, Records: []zones.Record{{Content: "192.0.2.1"}}},
+		{Name: "old.example.com.", Type: "CNAME", ChangeType: zones.ChangeTypeDelete},
+		{Name: "example.com.", Type: "TXT", ChangeType: zones.ChangeTypeDelete},
+	}, rrsets)
+}
+
+func TestUpdateRRSetsErrors(t *testing.T) {
+	m := new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Insert(rrs(t, "www.example.org. 60 IN A 192.0.2.10"))
+	_, err := UpdateRRSets(updateZone(), wire(t, m).Ns)
+	require.Equal(t, dns.RcodeNotZone, rcode(err))
+
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Insert(rrs(t, "www.example.com. 60 IN A 192.0.2.10"))
+	m.Ns = append(m.Ns, &dns.ANY{Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeAXFR, Class: dns.ClassANY}})
+	_, err = UpdateRRSets(updateZone(), wire(t, m).Ns)
+	require.Equal(t, dns.RcodeFormatError, rcode(err))
+}
@@ -0,0 +1,197 @@
+package zone
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// TTL bounds, RFC 2181 section 8
+const (
+	MinTTL = 0
+	MaxTTL = math.MaxInt32
+)
+
+// pdnsTypes are record types of PowerDNS unknown to miekg/dns, their content is not parsed
+var pdnsTypes = map[string]bool{
+	"ALIAS": true,
+	"LUA":   true,
+}
+
+// targetTypes have a domain name as the last field of content, it must be absolute
+var targetTypes = map[string]bool{
+	"CNAME": true,
+	"DNAME": true,
+	"MX":    true,
+	"NS":    true,
+	"PTR":   true,
+	"SRV":   true,
+}
+
+// ValidateRRSets checks RRsets changes of the zone before sending them to PowerDNS.
+// current is the zone before the changes, it is used to find CNAME and other data conflicts.
+// field returns a prefix of field names for the i-th RRset, e.g. "rrsets.0.".
+// Returns BadRequest error with a field error for every invalid record.
+func ValidateRRSets(current *zones.Zone, rrsets []zones.ResourceRecordSet, field func(i int) string) error {
+	zoneName := strings.ToLower(dns.Fqdn(current.Name))
+	var fes []errors.FieldError
+
+	// Types of every name after the changes
+	types := make(map[string]map[string]bool)
+	for _, rrset := range current.ResourceRecordSets {
+		addType(types, rrset.Name, rrset.Type)
+	}
+	for _, rrset := range rrsets {
+		name := strings.ToLower(rrset.Name)
+		switch rrset.ChangeType {
+		case zones.ChangeTypeReplace:
+			addType(types, name, rrset.Type)
+		case zones.ChangeTypeDelete:
+			delete(types[name], rrset.Type)
+		}
+	}
+
+	for i, rrset := range rrsets {
+		prefix := field(i)
+		name := strings.ToLower(rrset.Name)
+
+		if err := validateName(name, zoneName); err != "" {
+			fes = append(fes, errors.FieldError{Field: prefix + "name", Message: err})
+		}
+		_, known := dns.StringToType[rrset.Type]
+		if !known && !pdnsTypes[rrset.Type] {
+			fes = append(fes, errors.FieldError{Field: prefix + "type", Message: fmt.Sprintf("unknown record type %q", rrset.Type)})
+			continue
+		}
+		if rrset.ChangeType != zones.ChangeTypeReplace {
+			continue
+		}
+
+		if rrset.TTL < MinTTL || rrset.TTL > MaxTTL {
+			fes = append(fes, errors.FieldError{Field: prefix + "ttl", Message: fmt.Sprintf("must be between %d and %d", MinTTL, MaxTTL)})
+		}
+		if rrset.Type == "CNAME" && len(rrset.Records) > 1 {
+			fes = append(fes, errors.FieldError{Field: prefix + "records", Message: "CNAME RRset must have a single record"})
+		}
+		if conflict := cnameConflict(types[name], rrset.Type); conflict != "" {
+			fes = append(fes, errors.FieldError{Field: prefix + "type", Message: conflict})
+		}
+
+		seen := make(map[string]bool, len(rrset.Records))
+		for j, record := range rrset.Records {
+			recordField := fmt.Sprintf("%srecords.%d.content", prefix, j)
+			if seen[record.Content] {
+				fes = append(fes, errors.FieldError{Field: recordField, Message: "duplicate record"})
+				continue
+			}
+			seen[record.Content] = true
+			if !pdnsTypes[rrset.Type] {
+				if err := validateContent(rrset.Type, record.Content); err != "" {
+					fes = append(fes, errors.FieldError{Field: recordField, Message: err})
+				}
+			}
+		}
+	}
+
+	if len(fes) == 0 {
+		return nil
+	}
+	err := errors.BadRequest.Newf("invalid RRsets for zone %s", zoneName)
+	for _, fe := range fes {
+		err = errors.AddFieldError(err, fe.Field, fe.Message)
+	}
+	return err
+}
+
+func addType(types map[string]map[string]bool, name, rrType string) {
+	name = strings.ToLower(name)
+	if types[name] == nil {
+		types[name] = make(map[string]bool)
+	}
+	types[name][rrType] = true
+}
+
+// validateName returns a message if the name is not a canonical name inside the zone
+func validateName(name, zoneName string) string {
+	if !dns.IsFqdn(name) {
+		return "must be a canonical name ending with a dot"
+	}
+	if _, ok := dns.IsDomainName(name); !ok {
+		return "must be a valid domain name"
+	}
+	if !dns.IsSubDomain(zoneName, name) {
+		return fmt.Sprintf("is out of zone %s", zoneName)
+	}
+	return ""
+}
+
+// cnameConflict returns a message if CNAME shares the name with other data, RFC 1034 section 3.6.2.
+// DNSSEC records are allowed next to CNAME.
+func cnameConflict(types map[string]bool, rrType string) string {
+	if rrType == "CNAME" {
+		for t := range types {
+			if t != "CNAME" && !dnssecType(t) {
+				return fmt.Sprintf("CNAME conflicts with %s RRset of the same name", t)
+			}
+		}
+		return ""
+	}
+	if types["CNAME"] && !dnssecType(rrType) {
+		return fmt.Sprintf("%s conflicts with CNAME RRset of the same name", rrType)
+	}
+	return ""
+}
+
+func dnssecType(rrType string) bool {
+	switch rrType {
+	case "RRSIG", "NSEC", "NSEC3":
+		return true
+	}
+	return false
+}
+
+// validateContent returns a message if the content is not valid presentation format of the type
+func validateContent(rrType, content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "must not be empty"
+	}
+	if strings.ContainsAny(content, "\n\r") {
+		return "must be a single line"
+	}
+	switch rrType {
+	case "TXT", "SPF":
+		if !strings.HasPrefix(content, `"`) {
+			return "must be quoted"
+		}
+	}
+	if targetTypes[rrType] {
+		fields := strings.Fields(content)
+		if !dns.IsFqdn(fields[len(fields)-1]) {
+			return "target must be a canonical name ending with a dot"
+		}
+	}
+
+	// Parse as a zone file line, names in content have no origin to be relative to
+	rr, err := dns.NewRR(fmt.Sprintf(". 0 IN %s %s", rrType, content))
+	if err != nil {
+		return fmt.Sprintf("invalid %s record: %s", rrType, parseErrorMessage(err))
+	}
+	if rr == nil {
+		return fmt.Sprintf("invalid %s record", rrType)
+	}
+	return ""
+}
+
+func parseErrorMessage(err error) string {
+	msg := strings.TrimPrefix(err.Error(), "dns: ")
+	// Drop position in the synthetic line
+	if i := strings.Index(msg, " at line: "); i >= 0 {
+		msg = msg[:i]
+	}
+	return msg
+}
@@ -0,0 +1,106 @@
+package zone
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func testZone() *zones.Zone {
+	return &zones.Zone{
+		Name: "example.com.",
+		ResourceRecordSets: []zones.ResourceRecordSet{
+			{Name: "example.com.", Type: "SOA"},
+			{Name: "example.com.", Type: "NS"},
+			{Name: "www.example.com.", Type: "A"},
+			{Name: "alias.example.com.", Type: "CNAME"},
+		},
+	}
+}
+
+func rrsetsField(i int) string {
+	return fmt.Sprintf("rrsets.%d.", i)
+}
+
+func replace(name, rrType string, ttl int, contents ...string) zones.ResourceRecordSet {
+	rrset := zones.ResourceRecordSet{Name: name, Type: rrType, TTL: ttl, ChangeType: zones.ChangeTypeReplace}
+	for _, content := range contents {
+		rrset.Records = append(rrset.Records, zones.Record{Content: content})
+	}
+	return rrset
+}
+
+func TestValidateRRSetsValid(t *testing.T) {
+	rrsets := []zones.ResourceRecordSet{
+		replace("host.example.com.", "A", 300, "10.0.0.1", "10.0.0.2"),
+		replace("host.example.com.", "AAAA", 300, "2001:db8::1"),
+		replace("example.com.", "MX", 3600, "10 mail.example.com."),
+		replace("_sip._tcp.example.com.", "SRV", 3600, "10 60 5060 sip.example.com."),
+		replace("example.com.", "TXT", 3600, `"v=spf1 -all"`),
+		replace("example.com.", "CAA", 3600, `0 issue "letsencrypt.org"`),
+		replace("*.example.com.", "CNAME", 60, "www.example.com."),
+		replace("lua.example.com.", "LUA", 60, `A "ifportup(443, {'10.0.0.1'})"`),
+		// The CNAME is replaced by A
+		{Name: "alias.example.com.", Type: "CNAME", ChangeType: zones.ChangeTypeDelete},
+		replace("alias.example.com.", "A", 60, "10.0.0.3"),
+	}
+
+	require.NoError(t, ValidateRRSets(testZone(), rrsets, rrsetsField))
+}
+
+func TestValidateRRSetsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rrset zones.ResourceRecordSet
+		field string
+	}{
+		{"bad A", replace("host.example.com.", "A", 300, "10.0.0.256"), "rrsets.0.records.0.content"},
+		{"AAAA with IPv4", replace("host.example.com.", "AAAA", 300, "10.0.0.1"), "rrsets.0.records.0.content"},
+		{"empty content", replace("host.example.com.", "A", 300, ""), "rrsets.0.records.0.content"},
+		{"relative CNAME", replace("host.example.com.", "CNAME", 300, "www"), "rrsets.0.records.0.content"},
+		{"MX without preference", replace("example.com.", "MX", 300, "mail.example.com."), "rrsets.0.records.0.content"},
+		{"bad SRV", replace("_sip._tcp.example.com.", "SRV", 300, "10 60 sip.example.com."), "rrsets.0.records.0.content"},
+		{"unquoted TXT", replace("example.com.", "TXT", 300, "v=spf1 -all"), "rrsets.0.records.0.content"},
+		{"bad CAA", replace("example.com.", "CAA", 300, "issue letsencrypt.org"), "rrsets.0.records.0.content"},
+		{"duplicate", replace("host.example.com.", "A", 300, "10.0.0.1", "10.0.0.1"), "rrsets.0.records.1.content"},
+		{"negative TTL", replace("host.example.com.", "A", -1, "10.0.0.1"), "rrsets.0.ttl"},
+		{"unknown type", replace("host.example.com.", "FOO", 300, "bar"), "rrsets.0.type"},
+		{"out of zone", replace("host.example.org.", "A", 300, "10.0.0.1"), "rrsets.0.name"},
+		{"not canonical", replace("host.example.com", "A", 300, "10.0.0.1"), "rrsets.0.name"},
+		{"CNAME with other data", replace("www.example.com.", "CNAME", 300, "host.example.com."), "rrsets.0.type"},
+		{"other data with CNAME", replace("alias.example.com.", "TXT", 300, `"text"`), "rrsets.0.type"},
+		{"CNAME at apex", replace("example.com.", "CNAME", 300, "host.example.com."), "rrsets.0.type"},
+		{"several CNAME records", replace("cname.example.com.", "CNAME", 300, "a.example.com.", "b.example.com."), "rrsets.0.records"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRRSets(testZone(), []zones.ResourceRecordSet{tt.rrset}, rrsetsField)
+			require.Error(t, err)
+			require.Equal(t, errors.BadRequest, errors.GetType(err))
+			var fields []string
+			for _, fe := range errors.GetFieldErrors(err) {
+				fields = append(fields, fe.Field)
+			}
+			require.Contains(t, fields, tt.field)
+		})
+	}
+}
+
+func TestValidateRRSetsFieldPrefix(t *testing.T) {
+	rrset := replace("host.example.com.", "A", 300, "10.0.0.1", "bad")
+	err := ValidateRRSets(testZone(), []zones.ResourceRecordSet{rrset}, func(int) string { return "" })
+	require.Equal(t, []errors.FieldError{{Field: "records.1.content", Message: `invalid A record: bad A A: "bad"`}}, errors.GetFieldErrors(err))
+}
+
+func TestValidateRRSetsDelete(t *testing.T) {
+	// Content is not checked for deletes, names are
+	rrsets := []zones.ResourceRecordSet{{Name: "www.example.com.", Type: "A", ChangeType: zones.ChangeTypeDelete}}
+	require.NoError(t, ValidateRRSets(testZone(), rrsets, rrsetsField))
+
+	rrsets = []zones.ResourceRecordSet{{Name: "www.example.org.", Type: "A", ChangeType: zones.ChangeTypeDelete}}
+	require.Error(t, ValidateRRSets(testZone(), rrsets, rrsetsField))
+}
@@ -0,0 +1,112 @@
+package zone
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// Servers of a node checked by verification
+const (
+	ServerAuth     = "auth"
+	ServerRecursor = "recursor"
+)
+
+// Expectation is an RRset expected to be served over DNS
+type Expectation struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Records is expected content of records in any order, empty means the RRset must not exist
+	Records []string `json:"records"`
+}
+
+// Check is a result of checking the expectation on a single server
+type Check struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Server string `json:"server"`
+	// OK is true if the server answers with expected records
+	OK    bool   `json:"ok"`
+	Rcode string `json:"rcode,omitempty"`
+	// Records is content of records the server answers with
+	Records []string `json:"records"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// PrepareExpectations checks that expectations are inside the zone, makes names canonical
+// and records content normalized as served over DNS. Returns BadRequest error with field errors.
+func PrepareExpectations(zoneName string, exps []Expectation) error {
+	zoneName = strings.ToLower(dns.Fqdn(zoneName))
+	var fes []errors.FieldError
+	for i := range exps {
+		e := &exps[i]
+		prefix := fmt.Sprintf("rrsets.%d.", i)
+		e.Name = strings.ToLower(dns.Fqdn(e.Name))
+		e.Type = strings.ToUpper(e.Type)
+
+		if err := validateName(e.Name, zoneName); err != "" {
+			fes = append(fes, errors.FieldError{Field: prefix + "name", Message: err})
+			continue
+		}
+		if _, ok := dns.StringToType[e.Type]; !ok {
+			fes = append(fes, errors.FieldError{Field: prefix + "type", Message: fmt.Sprintf("unknown record type %q", e.Type)})
+			continue
+		}
+		for j, content := range e.Records {
+			rr, err := dns.NewRR(fmt.Sprintf("%s 0 IN %s %s", e.Name, e.Type, content))
+			if err != nil || rr == nil {
+				msg := fmt.Sprintf("invalid %s record", e.Type)
+				if err != nil {
+					msg += ": " + parseErrorMessage(err)
+				}
+				fes = append(fes, errors.FieldError{Field: fmt.Sprintf("%srecords.%d", prefix, j), Message: msg})
+				continue
+			}
+			e.Records[j] = RecordContent(rr)
+		}
+		sort.Strings(e.Records)
+	}
+	return fieldErrors(errors.BadRequest.Newf("invalid expected RRsets of zone %s", zoneName), fes)
+}
+
+// CheckAnswer compares records of the name and type in the answer with the expected ones
+func (e Expectation) CheckAnswer(server string, answer *dns.Msg) Check {
+	c := Check{
+		Name:    e.Name,
+		Type:    e.Type,
+		Server:  server,
+		Rcode:   dns.RcodeToString[answer.Rcode],
+		Records: []string{},
+	}
+	qtype := dns.StringToType[e.Type]
+	for _, rr := range answer.Answer {
+		h := rr.Header()
+		if h.Rrtype == qtype && strings.EqualFold(h.Name, e.Name) {
+			c.Records = append(c.Records, RecordContent(rr))
+		}
+	}
+	sort.Strings(c.Records)
+
+	switch answer.Rcode {
+	case dns.RcodeSuccess, dns.RcodeNameError:
+		c.OK = equalStrings(c.Records, e.Records)
+	default:
+		c.OK = false
+	}
+	return c
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
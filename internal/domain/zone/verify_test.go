@@ -0,0 +1,68 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareExpectations(t *testing.T) {
+	exps := []Expectation{
+		{Name: "WWW.example.com", Type: "a", Records: []string{"192.0.2.2", "192.0.2.1"}},
+		{Name: "example.com.", Type: "MX", Records: []string{"10  MAIL.example.com."}},
+		{Name: "old.example.com.", Type: "CNAME"},
+	}
+	require.NoError(t, PrepareExpectations("example.com", exps))
+	require.Equal(t, []Expectation{
+		{Name: "www.example.com.", Type: "A", Records: []string{"192.0.2.1", "192.0.2.2"}},
+		{Name: "example.com.", Type: "MX", Records: []string{"10 MAIL.example.com."}},
+		{Name: "old.example.com.", Type: "CNAME"},
+	}, exps)
+
+	err := PrepareExpectations("example.com.", []Expectation{
+		{Name: "www.example.org.", Type: "A"},
+		{Name: "www.example.com.", Type: "BOGUS"},
+		{Name: "www.example.com.", Type: "A", Records: []string{"192.0.2.1", "not an address"}},
+	})
+	require.Equal(t, errors.BadRequest, errors.GetType(err))
+	fes := errors.GetFieldErrors(err)
+	require.Len(t, fes, 3)
+	require.Equal(t, errors.FieldError{Field: "rrsets.0.name", Message: "is out of zone example.com."}, fes[0])
+	require.Equal(t, "rrsets.1.type", fes[1].Field)
+	require.Equal(t, "rrsets.2.records.1", fes[2].Field)
+}
+
+func TestCheckAnswer(t *testing.T) {
+	e := Expectation{Name: "www.example.com.", Type: "A", Records: []string{"192.0.2.1"}}
+	req := new(dns.Msg)
+	req.SetQuestion(e.Name, dns.TypeA)
+
+	answer := new(dns.Msg)
+	answer.SetReply(req)
+	cname, err := dns.NewRR("www.example.com. 300 IN CNAME web.example.com.")
+	require.NoError(t, err)
+	a, err := dns.NewRR("web.example.com. 300 IN A 192.0.2.1")
+	require.NoError(t, err)
+	answer.Answer = []dns.RR{cname, a}
+	c := e.CheckAnswer(ServerRecursor, answer)
+	require.False(t, c.OK)
+	require.Equal(t, []string{}, c.Records)
+	require.Equal(t, "NOERROR", c.Rcode)
+
+	a, err = dns.NewRR("WWW.example.com. 300 IN A 192.0.2.1")
+	require.NoError(t, err)
+	answer.Answer = []dns.RR{a}
+	c = e.CheckAnswer(ServerAuth, answer)
+	require.True(t, c.OK)
+	require.Equal(t, []string{"192.0.2.1"}, c.Records)
+
+	// Expected absence
+	e.Records = nil
+	answer.Answer = nil
+	answer.Rcode = dns.RcodeNameError
+	require.True(t, e.CheckAnswer(ServerAuth, answer).OK)
+	answer.Rcode = dns.RcodeServerFailure
+	require.False(t, e.CheckAnswer(ServerAuth, answer).OK)
+}
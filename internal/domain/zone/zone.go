@@ -0,0 +1,139 @@
+package zone
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/miekg/dns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// TSIGKeys are TSIG keys referenced by the zone in its AXFR metadata
+type TSIGKeys struct {
+	// MasterTSIGKeyIDs are TSIG keys allowed to transfer the zone from this server
+	MasterTSIGKeyIDs []string `json:"master_tsig_key_ids"`
+	// SlaveTSIGKeyIDs are TSIG keys to transfer the Slave zone from masters, PowerDNS supports a single key
+	SlaveTSIGKeyIDs []string `json:"slave_tsig_key_ids"`
+}
+
+// TSIG metadata kinds of PowerDNS
+const (
+	MetadataTSIGAllowAXFR  = "TSIG-ALLOW-AXFR"
+	MetadataAXFRMasterTSIG = "AXFR-MASTER-TSIG"
+	// MetadataTSIGAllowDNSUpdate lists TSIG keys allowed to update the zone by DNS UPDATE
+	MetadataTSIGAllowDNSUpdate = "TSIG-ALLOW-DNSUPDATE"
+)
+
+// Validate checks TSIG keys can be referenced by the zone of the kind
+func (t *TSIGKeys) Validate(zoneName string, kind zones.ZoneKind) error {
+	return fieldErrors(errors.BadRequest.Newf("invalid TSIG keys of zone %s", zoneName), t.fieldErrors(kind))
+}
+
+func (t *TSIGKeys) fieldErrors(kind zones.ZoneKind) []errors.FieldError {
+	var fes []errors.FieldError
+	if kind == zones.ZoneKindSlave {
+		if len(t.SlaveTSIGKeyIDs) > 1 {
+			fes = append(fes, errors.FieldError{Field: "slave_tsig_key_ids", Message: "must have a single key"})
+		}
+	} else if len(t.SlaveTSIGKeyIDs) > 0 {
+		fes = append(fes, errors.FieldError{Field: "slave_tsig_key_ids", Message: "is allowed for Slave zone only"})
+	}
+	for field, keys := range map[string][]string{"master_tsig_key_ids": t.MasterTSIGKeyIDs, "slave_tsig_key_ids": t.SlaveTSIGKeyIDs} {
+		for i, key := range keys {
+			if _, ok := dns.IsDomainName(key); !ok || key == "" {
+				fes = append(fes, errors.FieldError{Field: fmt.Sprintf("%s.%d", field, i), Message: "must be a TSIG key name"})
+			}
+		}
+	}
+	return fes
+}
+
+// TSIGMetadata returns zone metadata for TSIG keys, kinds without keys are omitted
+func (t *TSIGKeys) TSIGMetadata() map[string][]string {
+	metadata := make(map[string][]string)
+	if len(t.MasterTSIGKeyIDs) > 0 {
+		metadata[MetadataTSIGAllowAXFR] = t.MasterTSIGKeyIDs
+	}
+	if len(t.SlaveTSIGKeyIDs) > 0 {
+		metadata[MetadataAXFRMasterTSIG] = t.SlaveTSIGKeyIDs
+	}
+	return metadata
+}
+
+// NewZone is a zone to create. TSIG keys are not supported by go-powerdns,
+// they are set as zone metadata after creation.
+type NewZone struct {
+	zones.Zone
+	// MasterTSIGKeyIDs and SlaveTSIGKeyIDs are the same as in TSIGKeys
+	MasterTSIGKeyIDs []string `json:"master_tsig_key_ids,omitempty"`
+	SlaveTSIGKeyIDs  []string `json:"slave_tsig_key_ids,omitempty"`
+}
+
+// TSIGKeys returns TSIG keys referenced by the zone
+func (z *NewZone) TSIGKeys() *TSIGKeys {
+	return &TSIGKeys{MasterTSIGKeyIDs: z.MasterTSIGKeyIDs, SlaveTSIGKeyIDs: z.SlaveTSIGKeyIDs}
+}
+
+// Validate checks masters, RRsets and TSIG keys of the zone depending on its kind.
+// Slave zones require masters and get RRsets from them, other zones can't have masters.
+func (z *NewZone) Validate() error {
+	var fes []errors.FieldError
+	if z.Kind == zones.ZoneKindSlave {
+		if len(z.Masters) == 0 {
+			fes = append(fes, errors.FieldError{Field: "masters", Message: "is required for Slave zone"})
+		}
+		if len(z.ResourceRecordSets) > 0 {
+			fes = append(fes, errors.FieldError{Field: "rrsets", Message: "must be empty for Slave zone"})
+		}
+		if len(z.Nameservers) > 0 {
+			fes = append(fes, errors.FieldError{Field: "nameservers", Message: "must be empty for Slave zone"})
+		}
+	} else {
+		if len(z.Masters) > 0 {
+			fes = append(fes, errors.FieldError{Field: "masters", Message: "is allowed for Slave zone only"})
+		}
+		// RRsets of a new zone have no change type, all of them are added
+		rrsets := make([]zones.ResourceRecordSet, len(z.ResourceRecordSets))
+		for i, rrset := range z.ResourceRecordSets {
+			rrset.ChangeType = zones.ChangeTypeReplace
+			rrsets[i] = rrset
+		}
+		err := ValidateRRSets(&zones.Zone{Name: z.Name}, rrsets, func(i int) string { return fmt.Sprintf("rrsets.%d.", i) })
+		fes = append(fes, errors.GetFieldErrors(err)...)
+	}
+	for i, master := range z.Masters {
+		if !validMaster(master) {
+			fes = append(fes, errors.FieldError{Field: fmt.Sprintf("masters.%d", i), Message: "must be an IP address with optional port"})
+		}
+	}
+	fes = append(fes, z.TSIGKeys().fieldErrors(z.Kind)...)
+	return fieldErrors(errors.BadRequest.Newf("invalid zone %s", z.Name), fes)
+}
+
+// fieldErrors returns err with sorted field errors or nil if there are no field errors
+func fieldErrors(err error, fes []errors.FieldError) error {
+	if len(fes) == 0 {
+		return nil
+	}
+	sort.Slice(fes, func(i, j int) bool { return fes[i].Field < fes[j].Field })
+	for _, fe := range fes {
+		err = errors.AddFieldError(err, fe.Field, fe.Message)
+	}
+	return err
+}
+
+// validMaster reports whether the master is IP or IP:port, IPv6 with port is in brackets
+func validMaster(master string) bool {
+	if net.ParseIP(master) != nil {
+		return true
+	}
+	host, port, err := net.SplitHostPort(master)
+	if err != nil || net.ParseIP(host) == nil {
+		return false
+	}
+	p, err := strconv.Atoi(port)
+	return err == nil && p > 0 && p <= 65535
+}
@@ -0,0 +1,56 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewZoneValidate(t *testing.T) {
+	slave := NewZone{
+		Zone:            zones.Zone{Name: "example.com.", Kind: zones.ZoneKindSlave, Masters: []string{"192.0.2.1", "192.0.2.2:5300", "[2001:db8::1]:53"}},
+		SlaveTSIGKeyIDs: []string{"transfer."},
+	}
+	require.NoError(t, slave.Validate())
+	require.Equal(t, map[string][]string{"AXFR-MASTER-TSIG": {"transfer."}}, slave.TSIGKeys().TSIGMetadata())
+
+	master := NewZone{Zone: zones.Zone{Name: "example.com.", Kind: zones.ZoneKindMaster}, MasterTSIGKeyIDs: []string{"transfer"}}
+	require.NoError(t, master.Validate())
+	require.Equal(t, map[string][]string{"TSIG-ALLOW-AXFR": {"transfer"}}, master.TSIGKeys().TSIGMetadata())
+
+	tests := []struct {
+		name   string
+		zone   NewZone
+		fields []string
+	}{
+		{"slave without masters", NewZone{Zone: zones.Zone{Kind: zones.ZoneKindSlave}}, []string{"masters"}},
+		{"slave with RRsets and nameservers", NewZone{Zone: zones.Zone{
+			Kind:               zones.ZoneKindSlave,
+			Masters:            []string{"192.0.2.1"},
+			Nameservers:        zones.ZoneNameservers{"ns1.example.com."},
+			ResourceRecordSets: []zones.ResourceRecordSet{{Name: "example.com.", Type: "NS"}},
+		}}, []string{"nameservers", "rrsets"}},
+		{"bad masters", NewZone{Zone: zones.Zone{Kind: zones.ZoneKindSlave, Masters: []string{"ns1.example.com", "192.0.2.1:0"}}}, []string{"masters.0", "masters.1"}},
+		{"several slave keys", NewZone{Zone: zones.Zone{Kind: zones.ZoneKindSlave, Masters: []string{"192.0.2.1"}}, SlaveTSIGKeyIDs: []string{"a", "b"}}, []string{"slave_tsig_key_ids"}},
+		{"native with masters", NewZone{Zone: zones.Zone{Kind: zones.ZoneKindNative, Masters: []string{"192.0.2.1"}}, SlaveTSIGKeyIDs: []string{"a"}}, []string{"masters", "slave_tsig_key_ids"}},
+		{"bad key name", NewZone{Zone: zones.Zone{Kind: zones.ZoneKindMaster}, MasterTSIGKeyIDs: []string{""}}, []string{"master_tsig_key_ids.0"}},
+		{"bad RRsets", NewZone{Zone: zones.Zone{Name: "example.com.", Kind: zones.ZoneKindNative, ResourceRecordSets: []zones.ResourceRecordSet{
+			{Name: "www.example.com.", Type: "A", TTL: 60, Records: []zones.Record{{Content: "10.0.0.1"}}},
+			{Name: "mail.example.com.", Type: "MX", TTL: 60, Records: []zones.Record{{Content: "10 mail"}}},
+			{Name: "www.example.org.", Type: "A", TTL: 60, Records: []zones.Record{{Content: "10.0.0.2"}}},
+		}}}, []string{"rrsets.1.records.0.content", "rrsets.2.name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.zone.Validate()
+			require.Equal(t, errors.BadRequest, errors.GetType(err))
+			var fields []string
+			for _, fe := range errors.GetFieldErrors(err) {
+				fields = append(fields, fe.Field)
+			}
+			require.Equal(t, tt.fields, fields)
+		})
+	}
+}
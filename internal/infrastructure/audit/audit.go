@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/sirupsen/logrus"
+)
+
+// Filter holds criteria for audit log query. Empty fields match any event.
+type Filter struct {
+	Zone   string
+	Actor  string
+	Action string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+// Match reports whether the event matches the filter
+func (f Filter) Match(e *Event) bool {
+	if f.Zone != "" && network.Canonicalize(f.Zone) != network.Canonicalize(e.Zone) {
+		return false
+	}
+	if f.Actor != "" && f.Actor != e.Actor {
+		return false
+	}
+	if f.Action != "" && f.Action != e.Action {
+		return false
+	}
+	if !f.From.IsZero() && e.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && e.Time.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// Auditor writes audit events to all configured sinks
+type Auditor struct {
+	logger *logrus.Logger
+	sinks  []Sink
+	file   *fileSink
+	// trustedProxies may set X-Forwarded-For header of requests
+	trustedProxies []*net.IPNet
+}
+
+// NewAuditor creates sinks from config. Returns Auditor without sinks if audit is disabled.
+func NewAuditor(cfg config.Config, logger *logrus.Logger) (*Auditor, error) {
+	a := &Auditor{logger: logger}
+	if !cfg.Audit.Enabled {
+		return a, nil
+	}
+	trustedProxies, err := ParseTrustedProxies(cfg.Audit.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	a.trustedProxies = trustedProxies
+
+	if cfg.Audit.File.Enabled {
+		file, err := newFileSink(cfg.Audit.File.Path)
+		if err != nil {
+			return nil, err
+		}
+		a.file = file
+		a.sinks = append(a.sinks, file)
+	}
+	if cfg.Audit.Syslog.Enabled {
+		sl, err := newSyslogSink(cfg.Audit.Syslog.Network, cfg.Audit.Syslog.Address, cfg.Audit.Syslog.Tag)
+		if err != nil {
+			a.Close()
+			return nil, err
+		}
+		a.sinks = append(a.sinks, sl)
+	}
+	if cfg.Audit.Webhook.Enabled {
+		wh := newWebhookSink(
+			cfg.Audit.Webhook.URL,
+			time.Duration(cfg.Audit.Webhook.Timeout)*time.Second,
+			cfg.Audit.Webhook.QueueSize,
+			func(err error) {
+				logger.WithFields(logrus.Fields{
+					"action": log.ActionAudit,
+				}).Error(err.Error())
+			},
+		)
+		a.sinks = append(a.sinks, wh)
+	}
+
+	return a, nil
+}
+
+// NewEvent creates an Event from HTTP request, the source IP is taken from X-Forwarded-For
+// header only for requests from trusted proxies
+func (a *Auditor) NewEvent(r *http.Request) *Event {
+	return NewEvent(r, a.trustedProxies)
+}
+
+// Enabled reports whether events are written anywhere
+func (a *Auditor) Enabled() bool {
+	return len(a.sinks) > 0
+}
+
+// Record writes event to all sinks. Sink errors are logged and don't stop others.
+func (a *Auditor) Record(e *Event) {
+	e.Node = network.GetHostname()
+	for _, sink := range a.sinks {
+		if err := sink.Write(e); err != nil {
+			a.logger.WithFields(logrus.Fields{
+				"action":     log.ActionAudit,
+				"request_id": e.RequestID,
+			}).Errorf("Failed to write audit event: %v", err)
+		}
+	}
+}
+
+// Query returns events matching the filter, newest first.
+// Querying requires the file sink.
+func (a *Auditor) Query(f Filter) ([]Event, error) {
+	if a.file == nil {
+		return nil, errors.Unavailable.New("audit log query requires audit file sink")
+	}
+	return a.file.Query(f)
+}
+
+// Close flushes and closes all sinks
+func (a *Auditor) Close() {
+	for _, sink := range a.sinks {
+		if err := sink.Close(); err != nil {
+			a.logger.WithFields(logrus.Fields{
+				"action": log.ActionAudit,
+			}).Errorf("Failed to close audit sink: %v", err)
+		}
+	}
+}
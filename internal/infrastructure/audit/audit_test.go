@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mixanemca/pdns-api/internal/domain/tsigkey"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSinkQuery(t *testing.T) {
+	s, err := newFileSink(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	now := time.Now().UTC()
+	events := []Event{
+		{Time: now.Add(-2 * time.Hour), Actor: "alice", Action: "zone create", Zone: "example.com."},
+		{Time: now.Add(-time.Hour), Actor: "bob", Action: "zone update", Zone: "example.com."},
+		{Time: now, Actor: "alice", Action: "zone update", Zone: "example.org."},
+	}
+	for i := range events {
+		require.NoError(t, s.Write(&events[i]))
+	}
+
+	got, err := s.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	// Newest first
+	require.Equal(t, "example.org.", got[0].Zone)
+
+	// Zone matches with or without trailing dot
+	got, err = s.Query(Filter{Zone: "example.com"})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	got, err = s.Query(Filter{Actor: "alice", Action: "zone update"})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+
+	got, err = s.Query(Filter{From: now.Add(-90 * time.Minute), To: now.Add(-30 * time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "bob", got[0].Actor)
+
+	got, err = s.Query(Filter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+}
+
+func TestFileSinkQueryLargeFile(t *testing.T) {
+	s, err := newFileSink(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Events take several chunks read backwards
+	now := time.Now().UTC()
+	const count = 2000
+	for i := 0; i < count; i++ {
+		require.NoError(t, s.Write(&Event{Time: now.Add(time.Duration(i) * time.Second), RequestID: strconv.Itoa(i), Zone: "example.com."}))
+	}
+
+	got, err := s.Query(Filter{Limit: 3})
+	require.NoError(t, err)
+	require.Equal(t, []string{"1999", "1998", "1997"}, []string{got[0].RequestID, got[1].RequestID, got[2].RequestID})
+
+	got, err = s.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, got, count)
+	for i, e := range got {
+		require.Equal(t, strconv.Itoa(count-1-i), e.RequestID)
+	}
+}
+
+func TestFileSinkReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	s, err := newFileSink(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Write(&Event{RequestID: "before"}))
+	// Rotated like logrotate without copytruncate
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, s.Write(&Event{RequestID: "after"}))
+
+	got, err := s.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "after", got[0].RequestID)
+}
+
+func TestNewEventSourceIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"192.0.2.1", "10.0.0.0/8"})
+	require.NoError(t, err)
+	_, err = ParseTrustedProxies([]string{"proxy"})
+	require.Error(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"no header", "198.51.100.1:1234", "", "198.51.100.1"},
+		{"untrusted peer", "198.51.100.1:1234", "203.0.113.1", "198.51.100.1"},
+		{"trusted proxy", "192.0.2.1:1234", "203.0.113.1", "203.0.113.1"},
+		{"forged by client", "192.0.2.1:1234", "203.0.113.66, 203.0.113.1", "203.0.113.1"},
+		{"chain of proxies", "10.0.0.2:1234", "203.0.113.1, 10.0.0.1", "203.0.113.1"},
+		{"trusted without header", "192.0.2.1:1234", "", "192.0.2.1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/servers/localhost/zones", nil)
+			r.RemoteAddr = tc.remote
+			if tc.xff != "" {
+				r.Header.Set("X-Forwarded-For", tc.xff)
+			}
+			require.Equal(t, tc.want, NewEvent(r, proxies).SourceIP)
+		})
+	}
+}
+
+func TestEventSetTSIGKeyRedactsSecret(t *testing.T) {
+	e := &Event{}
+	e.SetTSIGKey(tsigkey.TSIGKey{Name: "transfer", Algorithm: "hmac-sha256", Key: "c2VjcmV0"})
+	require.Equal(t, &tsigkey.TSIGKey{Name: "transfer", Algorithm: "hmac-sha256"}, e.TSIGKey)
+
+	b, err := json.Marshal(e)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "c2VjcmV0")
+
+	// Setters are safe on nil
+	var nilEvent *Event
+	nilEvent.SetTSIGKey(tsigkey.TSIGKey{Name: "transfer"})
+}
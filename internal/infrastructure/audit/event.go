@@ -0,0 +1,246 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/domain/forwardzone"
+	"github.com/mixanemca/pdns-api/internal/domain/tsigkey"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/client"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/requestid"
+)
+
+// Results of audited operations
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultDenied  = "denied"
+)
+
+type contextKey struct{}
+
+// Event represents a single mutating operation.
+// Handlers fill it sequentially, so it is not safe for concurrent use.
+type Event struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	// Node is a hostname of pdns-api instance handled the request
+	Node     string `json:"node"`
+	Actor    string `json:"actor,omitempty"`
+	SourceIP string `json:"source_ip,omitempty"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Action   string `json:"action"`
+	ServerID string `json:"server_id,omitempty"`
+	Zone     string `json:"zone,omitempty"`
+	// Changes holds RRsets diff for authoritative zones
+	Changes []RRSetChange `json:"changes,omitempty"`
+	// ForwardZones holds forward zones from request
+	ForwardZones []forwardzone.ForwardZone `json:"forward_zones,omitempty"`
+	// TSIGKey holds TSIG key from request, its secret is always redacted
+	TSIGKey *tsigkey.TSIGKey `json:"tsig_key,omitempty"`
+	Result  string           `json:"result"`
+	Status  int              `json:"status"`
+	Error   string           `json:"error,omitempty"`
+	// Nodes holds per-node outcome of requests via internal API
+	Nodes []client.NodeResult `json:"nodes,omitempty"`
+}
+
+// RRSetChange represents RRSet state before and after the change
+type RRSetChange struct {
+	Name       string                   `json:"name"`
+	Type       string                   `json:"type"`
+	ChangeType string                   `json:"changetype"`
+	Before     *zones.ResourceRecordSet `json:"before,omitempty"`
+	After      *zones.ResourceRecordSet `json:"after,omitempty"`
+}
+
+// NewEvent creates an Event from HTTP request. X-Forwarded-For header is used for the source IP
+// only if the request came from one of trusted proxies.
+func NewEvent(r *http.Request, trustedProxies []*net.IPNet) *Event {
+	return &Event{
+		Time:      time.Now().UTC(),
+		RequestID: requestid.FromContext(r.Context()),
+		Actor:     r.Header.Get("X-PDNS-Client-UID"),
+		SourceIP:  sourceIP(r, trustedProxies),
+		Method:    r.Method,
+		Path:      r.URL.Path,
+	}
+}
+
+// MethodDNSUpdate is a method of events created by DNS UPDATE (RFC 2136) messages
+const MethodDNSUpdate = "UPDATE"
+
+// NewUpdateEvent creates an Event from DNS UPDATE message, the actor is TSIG key name
+func NewUpdateEvent(ctx context.Context, keyName string, remote net.Addr) *Event {
+	e := &Event{
+		Time:      time.Now().UTC(),
+		RequestID: requestid.FromContext(ctx),
+		Actor:     strings.TrimSuffix(keyName, "."),
+		Method:    MethodDNSUpdate,
+	}
+	if remote != nil {
+		e.SourceIP = remote.String()
+		if host, _, err := net.SplitHostPort(e.SourceIP); err == nil {
+			e.SourceIP = host
+		}
+	}
+	return e
+}
+
+// NewContext returns a copy of ctx with the Event
+func NewContext(ctx context.Context, e *Event) context.Context {
+	return context.WithValue(ctx, contextKey{}, e)
+}
+
+// FromContext returns the Event from ctx or nil.
+// All Event setters are safe to call on nil.
+func FromContext(ctx context.Context) *Event {
+	e, _ := ctx.Value(contextKey{}).(*Event)
+	return e
+}
+
+// SetAction sets action, like a log.ActionZoneUpdate
+func (e *Event) SetAction(action string) {
+	if e == nil {
+		return
+	}
+	e.Action = action
+}
+
+// SetZone sets server ID and zone name of the operation
+func (e *Event) SetZone(serverID, zone string) {
+	if e == nil {
+		return
+	}
+	e.ServerID = serverID
+	e.Zone = zone
+}
+
+// AddChange adds RRSet diff. Before is nil for a new RRSet.
+func (e *Event) AddChange(before *zones.ResourceRecordSet, after zones.ResourceRecordSet) {
+	if e == nil {
+		return
+	}
+	c := RRSetChange{
+		Name:   after.Name,
+		Type:   after.Type,
+		Before: before,
+	}
+	switch after.ChangeType {
+	case zones.ChangeTypeDelete:
+		c.ChangeType = "DELETE"
+	default:
+		c.ChangeType = "REPLACE"
+		after.ChangeType = 0
+		c.After = &after
+	}
+	e.Changes = append(e.Changes, c)
+}
+
+// AddForwardZones adds forward zones from request
+func (e *Event) AddForwardZones(fzs ...forwardzone.ForwardZone) {
+	if e == nil {
+		return
+	}
+	e.ForwardZones = append(e.ForwardZones, fzs...)
+}
+
+// SetTSIGKey sets TSIG key of the operation without its secret
+func (e *Event) SetTSIGKey(key tsigkey.TSIGKey) {
+	if e == nil {
+		return
+	}
+	key = key.Redacted()
+	e.TSIGKey = &key
+}
+
+// AddNodes adds outcome of requests via internal API
+func (e *Event) AddNodes(nodes []client.NodeResult) {
+	if e == nil {
+		return
+	}
+	e.Nodes = append(e.Nodes, nodes...)
+}
+
+// Finish sets result of the operation by HTTP response status
+func (e *Event) Finish(status int, errMsg string) {
+	e.Status = status
+	e.Error = errMsg
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		e.Result = ResultDenied
+	case status >= http.StatusBadRequest:
+		e.Result = ResultFailure
+	default:
+		e.Result = ResultSuccess
+	}
+}
+
+// sourceIP returns address of the peer or, if the peer is a trusted proxy, the rightmost address
+// of X-Forwarded-For header not added by trusted proxies. Addresses left of it may be forged by the client.
+func sourceIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	if !trusted(ip, trustedProxies) {
+		return ip
+	}
+	addrs := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(addrs) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(addrs[i])
+		if addr == "" {
+			continue
+		}
+		ip = addr
+		if !trusted(ip, trustedProxies) {
+			break
+		}
+	}
+	return ip
+}
+
+func trusted(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range trustedProxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies parses IP addresses and CIDR networks of trusted proxies
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, errors.Newf("invalid trusted proxy address %q", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy network %q", p)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/syslog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// Sink writes audit events somewhere
+type Sink interface {
+	Write(e *Event) error
+	Close() error
+}
+
+const (
+	// queryChunkSize is a size of blocks the audit log file is read backwards by
+	queryChunkSize = 64 * 1024
+	// maxEventSize limits length of lines of the audit log file, events with large diffs may be long
+	maxEventSize = 16 * 1024 * 1024
+)
+
+// fileSink writes events to a file as JSON lines.
+// The file is reopened when log rotation moves or removes it.
+type fileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func newFileSink(path string) (*fileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening audit log file %s", path)
+	}
+	return &fileSink{path: path, file: file}, nil
+}
+
+func (s *fileSink) Write(e *Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reopen(); err != nil {
+		return err
+	}
+	_, err = s.file.Write(b)
+	return err
+}
+
+// reopen opens the file again if the path no longer refers to it after log rotation, s.mu must be held
+func (s *fileSink) reopen() error {
+	info, err := os.Stat(s.path)
+	switch {
+	case err == nil:
+		current, err := s.file.Stat()
+		if err == nil && os.SameFile(info, current) {
+			return nil
+		}
+	case !os.IsNotExist(err):
+		return errors.Wrapf(err, "checking audit log file %s", s.path)
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return errors.Wrapf(err, "reopening audit log file %s", s.path)
+	}
+	_ = s.file.Close()
+	s.file = file
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// Query reads events matching the filter, newest first. The file is read backwards,
+// so with a limit only its tail is read.
+func (s *fileSink) Query(f Filter) ([]Event, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening audit log file %s", s.path)
+	}
+	defer file.Close()
+
+	events := make([]Event, 0)
+	err = readLinesBackward(file, func(line []byte) bool {
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			// Skip broken lines, e.g. partially written on crash
+			return true
+		}
+		if f.Match(&e) {
+			events = append(events, e)
+		}
+		return f.Limit <= 0 || len(events) < f.Limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// readLinesBackward calls fn for lines of the file from the last one until fn returns false
+func readLinesBackward(file *os.File, fn func(line []byte) bool) error {
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Wrap(err, "reading audit log file")
+	}
+	chunk := make([]byte, queryChunkSize)
+	// partial is the beginning of the line continued in the chunks read before
+	var partial []byte
+	for off := info.Size(); off > 0; {
+		n := int64(queryChunkSize)
+		if off < n {
+			n = off
+		}
+		off -= n
+		if _, err := file.ReadAt(chunk[:n], off); err != nil {
+			return errors.Wrap(err, "reading audit log file")
+		}
+		data := append(append(make([]byte, 0, int(n)+len(partial)), chunk[:n]...), partial...)
+		for i := bytes.LastIndexByte(data, '\n'); i >= 0; i = bytes.LastIndexByte(data, '\n') {
+			if line := data[i+1:]; len(line) > 0 && !fn(line) {
+				return nil
+			}
+			data = data[:i]
+		}
+		partial = data
+		if len(partial) > maxEventSize {
+			return errors.Newf("reading audit log file: line is longer than %d bytes", maxEventSize)
+		}
+	}
+	if len(partial) > 0 {
+		fn(partial)
+	}
+	return nil
+}
+
+// syslogSink writes events to syslog as JSON
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func newSyslogSink(network, address, tag string) (*syslogSink, error) {
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, errors.Wrap(err, "connecting to syslog")
+	}
+	return &syslogSink{writer: w}, nil
+}
+
+func (s *syslogSink) Write(e *Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.writer.Info(string(b))
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
+
+// webhookSink sends events as JSON with POST request.
+// Events are queued and sent in background, so slow webhook doesn't slow down API.
+type webhookSink struct {
+	url     string
+	client  *http.Client
+	queue   chan []byte
+	done    chan struct{}
+	onError func(err error)
+}
+
+func newWebhookSink(url string, timeout time.Duration, queueSize int, onError func(err error)) *webhookSink {
+	s := &webhookSink{
+		url:     url,
+		client:  &http.Client{Timeout: timeout},
+		queue:   make(chan []byte, queueSize),
+		done:    make(chan struct{}),
+		onError: onError,
+	}
+	go s.run()
+	return s
+}
+
+func (s *webhookSink) Write(e *Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	select {
+	case s.queue <- b:
+		return nil
+	default:
+		return errors.New("audit webhook queue is full, event dropped")
+	}
+}
+
+func (s *webhookSink) run() {
+	defer close(s.done)
+	for b := range s.queue {
+		if err := s.send(b); err != nil {
+			s.onError(err)
+		}
+	}
+}
+
+func (s *webhookSink) send(b []byte) error {
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "sending audit event to webhook")
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Newf("sending audit event to webhook: unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close sends queued events and stops background sender
+func (s *webhookSink) Close() error {
+	close(s.queue)
+	<-s.done
+	return nil
+}
@@ -21,84 +21,131 @@ import (
 	"net/http"
 
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"golang.org/x/net/context"
 )
 
 // FlushAllCache Flush a cache-entry by name for all available services
-func (s *client) FlushAllCache(serverID, name string) error {
+func (s *client) FlushAllCache(ctx context.Context, serverID, name string) ([]NodeResult, error) {
 	// Make an InternalRequest and send it to all alive services
 	path := fmt.Sprintf("/api/v1/internal/%s/cache/flush?domain=%s", serverID, name)
 	ireq := NewInternalRequest(
+		ctx,
 		http.MethodPut,
 		path,
 		nil,
 	)
-	if err := s.DoInternalRequest(ireq); err != nil {
-		return errors.Wrap(err, "flushing caches")
+	nodes, err := s.DoInternalRequest(ctx, ireq)
+	if err != nil {
+		return nodes, errors.Wrap(err, "flushing caches")
 	}
 
-	return nil
+	return nodes, nil
 }
 
 // AddZone Add a new zone by name for all available services
-func (s *client) AddZone(serverID, zoneType string, bodyBytes []byte) error {
+func (s *client) AddZone(ctx context.Context, serverID, zoneType string, bodyBytes []byte) ([]NodeResult, error) {
 	// Make an InternalRequest and send it to all alive services
 	path := fmt.Sprintf("/api/v1/internal/%s/%s", serverID, zoneType)
 	ireq := NewInternalRequest(
+		ctx,
 		http.MethodPost,
 		path,
 		bodyBytes,
 	)
-	if err := s.DoInternalRequest(ireq); err != nil {
-		return errors.Wrap(err, "add zone")
+	nodes, err := s.DoInternalRequest(ctx, ireq)
+	if err != nil {
+		return nodes, errors.Wrap(err, "add zone")
 	}
 
-	return nil
+	return nodes, nil
 }
 
 // PatchZone Update zone by name from all available services
-func (s *client) PatchZone(serverID, zoneType, zoneID string, bodyBytes []byte) error {
+func (s *client) PatchZone(ctx context.Context, serverID, zoneType, zoneID string, bodyBytes []byte) ([]NodeResult, error) {
 	// Make an InternalRequest and send it to all alive services
 	path := fmt.Sprintf("/api/v1/internal/%s/%s/%s", serverID, zoneType, zoneID)
 	ireq := NewInternalRequest(
+		ctx,
 		http.MethodPatch,
 		path,
 		bodyBytes,
 	)
-	if err := s.DoInternalRequest(ireq); err != nil {
-		return errors.Wrap(err, "update zone")
+	nodes, err := s.DoInternalRequest(ctx, ireq)
+	if err != nil {
+		return nodes, errors.Wrap(err, "update zone")
 	}
 
-	return nil
+	return nodes, nil
 }
 
 // DelZones Removes zones by zone type from all available services
-func (s *client) DelZones(serverID, zoneType string, bodyBytes []byte) error {
+func (s *client) DelZones(ctx context.Context, serverID, zoneType string, bodyBytes []byte) ([]NodeResult, error) {
 	// Make an InternalRequest and send it to all alive services
 	path := fmt.Sprintf("/api/v1/internal/%s/%s", serverID, zoneType)
 	ireq := NewInternalRequest(
+		ctx,
 		http.MethodDelete,
 		path,
 		bodyBytes,
 	)
-	if err := s.DoInternalRequest(ireq); err != nil {
-		return errors.Wrap(err, "delete zone")
+	nodes, err := s.DoInternalRequest(ctx, ireq)
+	if err != nil {
+		return nodes, errors.Wrap(err, "delete zone")
 	}
 
-	return nil
+	return nodes, nil
 }
 
 // DelZone Removes zone by zone id from all available services
-func (s *client) DelZone(serverID, zoneType string, zoneID string) error {
+func (s *client) DelZone(ctx context.Context, serverID, zoneType string, zoneID string) ([]NodeResult, error) {
 	// Make an InternalRequest and send it to all alive services
 	path := fmt.Sprintf("/api/v1/internal/%s/%s/%s", serverID, zoneType, zoneID)
 	ireq := NewInternalRequest(
+		ctx,
 		http.MethodDelete,
 		path,
 		nil,
 	)
-	if err := s.DoInternalRequest(ireq); err != nil {
-		return errors.Wrap(err, "delete zone")
+	nodes, err := s.DoInternalRequest(ctx, ireq)
+	if err != nil {
+		return nodes, errors.Wrap(err, "delete zone")
 	}
 
-	return nil
+	return nodes, nil
+}
+
+// ZoneState Get zone state from all available services, bodies of results hold zone.State
+func (s *client) ZoneState(ctx context.Context, serverID, zoneID string, rrsets bool) ([]NodeResult, error) {
+	// Make an InternalRequest and send it to all alive services
+	path := fmt.Sprintf("/api/v1/internal/%s/zones/%s/state?rrsets=%t", serverID, zoneID, rrsets)
+	ireq := NewInternalRequest(
+		ctx,
+		http.MethodGet,
+		path,
+		nil,
+	)
+	nodes, err := s.DoInternalRequest(ctx, ireq)
+	if err != nil {
+		return nodes, errors.Wrap(err, "getting zone state")
+	}
+
+	return nodes, nil
+}
+
+// VerifyZone Check expected RRsets over DNS on all available services, bodies of results hold zone.Check list
+func (s *client) VerifyZone(ctx context.Context, serverID, zoneID string, bodyBytes []byte) ([]NodeResult, error) {
+	// Make an InternalRequest and send it to all alive services
+	path := fmt.Sprintf("/api/v1/internal/%s/zones/%s/verify", serverID, zoneID)
+	ireq := NewInternalRequest(
+		ctx,
+		http.MethodPost,
+		path,
+		bodyBytes,
+	)
+	nodes, err := s.DoInternalRequest(ctx, ireq)
+	if err != nil {
+		return nodes, errors.Wrap(err, "verifying zone")
+	}
+
+	return nodes, nil
 }
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -14,6 +15,11 @@ import (
 	"github.com/hashicorp/consul/connect"
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/requestid"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 	"golang.org/x/net/http2"
 	"golang.org/x/sync/errgroup"
@@ -24,6 +30,8 @@ const (
 	PDNSInternalServiceName string = "pdns-api-internal"
 	consulDC                string = "dc1"
 	consulNamespace         string = "default"
+	// maxResponseSize limits the body of internal API response read from a node
+	maxResponseSize int64 = 1 << 20
 )
 
 // InternalRequest holds params for do requests via internal API
@@ -32,6 +40,8 @@ type InternalRequest struct {
 	path   string
 	data   []byte
 	// data   io.Reader
+	// requestID links logs of public request and internal requests it causes
+	requestID string
 }
 
 type connDialer struct {
@@ -42,12 +52,13 @@ func (cd connDialer) Dial(network, addr string) (net.Conn, error) {
 	return cd.c, nil
 }
 
-// NewInternalRequest creates a new InternalRequest
-func NewInternalRequest(method, path string, data []byte) *InternalRequest {
+// NewInternalRequest creates a new InternalRequest with request ID from ctx
+func NewInternalRequest(ctx context.Context, method, path string, data []byte) *InternalRequest {
 	return &InternalRequest{
-		method: method,
-		path:   path,
-		data:   data,
+		method:    method,
+		path:      path,
+		data:      data,
+		requestID: requestid.FromContext(ctx),
 	}
 }
 
@@ -62,19 +73,47 @@ func NewClient(config config.Config, consulClient *api.Client, internalService *
 	return &client{config: config, consulClient: consulClient, internalService: internalService}
 }
 
+// NodeResult holds outcome of internal request to a single node
+type NodeResult struct {
+	Node   string `json:"node"`
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Body is the response body of the node
+	Body []byte `json:"-"`
+}
+
 // InternalRequest do requests via internal API to healthy services.
-func (s *client) DoInternalRequest(ireq *InternalRequest) error {
-	// Get healthy service entries fom Consul
-	serviceEntries, _, err := s.consulClient.Health().Service(PDNSServiceName, "", true, &api.QueryOptions{})
+// Returns outcome for every node, in the order of Consul service entries.
+func (s *client) DoInternalRequest(ctx context.Context, ireq *InternalRequest) ([]NodeResult, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "DoInternalRequest", trace.WithAttributes(
+		attribute.String("http.method", ireq.method),
+		attribute.String("pdns_api.internal.path", ireq.path),
+	))
+	defer span.End()
+
+	// Get healthy service entries fom Consul, the check of internal service reflects worker dependencies only
+	serviceEntries, _, err := s.consulClient.Health().Service(PDNSInternalServiceName, "", true, &api.QueryOptions{})
 	if err != nil {
-		return errors.Wrapf(err, "failed to get healthy service %s entries from Consul", PDNSServiceName)
+		err = errors.Wrapf(err, "failed to get healthy service %s entries from Consul", PDNSInternalServiceName)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("pdns_api.internal.nodes", len(serviceEntries)))
+	if len(serviceEntries) == 0 {
+		err = errors.Unavailable.Newf("no healthy %s nodes", PDNSInternalServiceName)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
 	}
 
 	// Creating an errgroup.Group for doing requests into internal API
 	g := new(errgroup.Group)
+	results := make([]NodeResult, len(serviceEntries))
 
-	for _, entry := range serviceEntries {
+	for i, entry := range serviceEntries {
 		// https://golang.org/doc/faq#closures_and_goroutines
+		result := &results[i]
 		addr := entry.Service.Address
 		port := s.config.InternalHTTP.Port
 		p := ireq.path
@@ -82,56 +121,125 @@ func (s *client) DoInternalRequest(ireq *InternalRequest) error {
 		if ireq.data != nil {
 			_, _ = io.Copy(&buf, ioutil.NopCloser(bytes.NewReader(ireq.data)))
 		}
+		result.Node = entry.Node.Node
 
 		g.Go(func() error {
-			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.InternalHTTP.Timeout.Read)*time.Second)
-			defer cancel()
-
-			// https://www.consul.io/docs/connect/native/go
-			// connect.HTTPClient() internally do resolve single node by service or query,
-			// and do request to only one this node.
-			// Instead of this we use raw TLS Connection.
-			// todo move to config
-			conn, _ := s.internalService.Dial(ctx, &connect.StaticResolver{
-				Addr: net.JoinHostPort(addr, port),
-				CertURI: &agConnect.SpiffeIDService{
-					Namespace:  consulNamespace,
-					Datacenter: consulDC,
-					Service:    PDNSInternalServiceName,
-				},
-			})
-			defer conn.Close()
-
-			t := &http.Transport{
-				DialTLS: connDialer{conn}.Dial,
+			status, body, err := s.doNodeRequest(ctx, ireq, result.Node, addr, port, p, &buf)
+			result.Status = status
+			result.Body = body
+			if err != nil {
+				result.Error = err.Error()
 			}
-			// Configures a net/http HTTP/1 Transport to use HTTP/2.
-			_ = http2.ConfigureTransport(t)
+			return err
+		})
+	}
+	err = g.Wait()
+	if err != nil {
+		err = errors.Wrap(err, "failed to do requests via internal API")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return results, err
+	}
 
-			httpClient := &http.Client{
-				Transport: t,
-				Timeout:   time.Duration(s.config.InternalHTTP.Timeout.Read) * time.Second,
-			}
-			url := fmt.Sprintf("https://%s:%s%s", addr, port, p)
+	return results, nil
+}
 
-			req, err := http.NewRequest(ireq.method, url, &buf)
-			if err != nil {
-				return err
-			}
+// doNodeRequest does request via internal API to the single node and returns response status and body
+func (s *client) doNodeRequest(ctx context.Context, ireq *InternalRequest, node, addr, port, path string, body io.Reader) (status int, respBody []byte, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "internal request", trace.WithAttributes(
+		attribute.String("pdns_api.internal.node", node),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// Changes should be applied on all nodes even if the public request is canceled
+	ctx, cancel := context.WithTimeout(tracing.Detach(ctx), time.Duration(s.config.InternalHTTP.Timeout.Read)*time.Second)
+	defer cancel()
+
+	// https://www.consul.io/docs/connect/native/go
+	// connect.HTTPClient() internally do resolve single node by service or query,
+	// and do request to only one this node.
+	// Instead of this we use raw TLS Connection.
+	// todo move to config
+	conn, err := s.internalService.Dial(ctx, &connect.StaticResolver{
+		Addr: net.JoinHostPort(addr, port),
+		CertURI: &agConnect.SpiffeIDService{
+			Namespace:  consulNamespace,
+			Datacenter: consulDC,
+			Service:    PDNSInternalServiceName,
+		},
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	defer conn.Close()
 
-			resp, err := httpClient.Do(req)
-			if err != nil {
-				return err
-			}
-			resp.Body.Close()
+	t := &http.Transport{
+		DialTLS: connDialer{conn}.Dial,
+	}
+	// Configures a net/http HTTP/1 Transport to use HTTP/2.
+	_ = http2.ConfigureTransport(t)
 
-			return nil
-		})
+	httpClient := &http.Client{
+		// Trace context is propagated to the worker in request headers
+		Transport: tracing.NewTransport(t),
+		Timeout:   time.Duration(s.config.InternalHTTP.Timeout.Read) * time.Second,
 	}
-	err = g.Wait()
+	url := fmt.Sprintf("https://%s:%s%s", addr, port, path)
+
+	req, err := http.NewRequestWithContext(ctx, ireq.method, url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if ireq.requestID != "" {
+		req.Header.Set(requestid.Header, ireq.requestID)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 	if err != nil {
-		return errors.Wrap(err, "failed to do requests via internal API")
+		return resp.StatusCode, nil, err
 	}
 
-	return nil
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, respBody, nodeStatusType(resp.StatusCode).Newf("node %s: %s", node, nodeErrorMessage(resp.StatusCode, respBody))
+	}
+
+	return resp.StatusCode, respBody, nil
+}
+
+// nodeStatusType returns error type for the failed response status of a node
+func nodeStatusType(status int) errors.ErrorType {
+	switch status {
+	case http.StatusBadRequest:
+		return errors.BadRequest
+	case http.StatusNotFound:
+		return errors.NotFound
+	case http.StatusConflict:
+		return errors.Conflict
+	case http.StatusServiceUnavailable:
+		return errors.Unavailable
+	default:
+		return errors.UpstreamError
+	}
+}
+
+// nodeErrorMessage returns the message of JSON error response of a node, or the status if there is none
+func nodeErrorMessage(status int, body []byte) string {
+	var errResp struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Message == "" {
+		return fmt.Sprintf("unexpected status %d", status)
+	}
+	return errResp.Message
 }
@@ -23,7 +23,7 @@ var consulAgentsForInteralService = []consulAgent{
 		Addres:   network.GetHostname(),
 		ID:       pdnsServiceName,
 		Port:     8080,
-		Url:      "http://127.0.0.1:8080/api/v1/health",
+		Url:      "http://127.0.0.1:8080/api/v1/health/ready",
 		Interval: "2s",
 		Timeout:  "1s",
 		IsNative: true,
@@ -33,7 +33,7 @@ var consulAgentsForInteralService = []consulAgent{
 		Addres:   network.GetHostname(),
 		ID:       pdnsInternalServiceName,
 		Port:     8090,
-		Url:      "http://127.0.0.1:8080/api/v1/health",
+		Url:      "http://127.0.0.1:8080/api/v1/health/internal",
 		Interval: "2s",
 		Timeout:  "1s",
 		IsNative: true,
@@ -0,0 +1,237 @@
+// Package ddns implements DNS UPDATE (RFC 2136) listener for zones of PowerDNS Authoritative,
+// used by DHCP servers and other legacy clients. Updates must be signed by a TSIG key of PowerDNS
+// listed in TSIG-ALLOW-DNSUPDATE metadata of the zone. They are applied like PATCH of the zone.
+package ddns
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	pdnsApi "github.com/mittwald/go-powerdns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/tsigkey"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/requestid"
+	"github.com/sirupsen/logrus"
+)
+
+// serverID is PowerDNS server of updated zones
+const serverID = "localhost"
+
+// tsigFudge is allowed clock skew of TSIG in seconds, RFC 8945 recommends 300
+const tsigFudge = 300
+
+type updater interface {
+	LockZone(zoneID string) (unlock func())
+	UpdateRRSets(ctx context.Context, serverID, zoneID string, rrsets []zones.ResourceRecordSet) error
+}
+
+type metadataClient interface {
+	GetMetadata(ctx context.Context, serverID, zoneID, kind string) ([]string, error)
+}
+
+type tsigKeyClient interface {
+	ListTSIGKeys(ctx context.Context, serverID string) ([]tsigkey.TSIGKey, error)
+	GetTSIGKey(ctx context.Context, serverID, keyID string) (*tsigkey.TSIGKey, error)
+}
+
+type auditRecorder interface {
+	Enabled() bool
+	Record(e *audit.Event)
+}
+
+// Handler applies DNS UPDATE messages to zones
+type Handler struct {
+	config   config.Config
+	logger   *logrus.Logger
+	auth     pdnsApi.Client
+	metadata metadataClient
+	updater  updater
+	recorder auditRecorder
+	keys     *Keys
+}
+
+func NewHandler(config config.Config, logger *logrus.Logger, auth pdnsApi.Client, metadata metadataClient, updater updater, recorder auditRecorder, keys *Keys) *Handler {
+	return &Handler{config: config, logger: logger, auth: auth, metadata: metadata, updater: updater, recorder: recorder, keys: keys}
+}
+
+// AcceptUpdate is dns.MsgAcceptFunc accepting UPDATE messages only
+func AcceptUpdate(dh dns.Header) dns.MsgAcceptAction {
+	if isResponse := dh.Bits&(1<<15) != 0; isResponse {
+		return dns.MsgIgnore
+	}
+	if opcode := int(dh.Bits>>11) & 0xF; opcode != dns.OpcodeUpdate {
+		return dns.MsgRejectNotImplemented
+	}
+	// Zone section has a single zone
+	if dh.Qdcount != 1 {
+		return dns.MsgReject
+	}
+	return dns.MsgAccept
+}
+
+func (h *Handler) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	ctx := requestid.NewContext(context.Background(), requestid.New())
+	var key string
+	t := req.IsTsig()
+	if t != nil {
+		key = t.Hdr.Name
+	}
+	event := audit.NewUpdateEvent(ctx, key, w.RemoteAddr())
+	event.SetAction(log.ActionDDNSUpdate)
+	ctx = audit.NewContext(ctx, event)
+
+	zoneName := req.Question[0].Name
+	err := h.update(ctx, w, req)
+	if err != nil {
+		h.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"action": log.ActionDDNSUpdate,
+			"zone":   zoneName,
+		}).Warnf("Update of zone %s from %s by key %s failed: %v", zoneName, w.RemoteAddr(), key, err)
+	} else {
+		h.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"action": log.ActionDDNSUpdate,
+			"zone":   zoneName,
+		}).Infof("Zone %s was updated from %s by key %s", zoneName, w.RemoteAddr(), key)
+	}
+
+	if h.recorder.Enabled() {
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		event.Finish(status(err), errMsg)
+		h.recorder.Record(event)
+	}
+
+	resp := new(dns.Msg)
+	resp.SetRcode(req, rcode(err))
+	// Response is signed by dns.Server with the key of valid request
+	if t != nil && w.TsigStatus() == nil {
+		resp.SetTsig(t.Hdr.Name, t.Algorithm, tsigFudge, time.Now().Unix())
+	}
+	_ = w.WriteMsg(resp)
+}
+
+// update checks TSIG and authorization of the key, prerequisites and applies the updates to the zone
+func (h *Handler) update(ctx context.Context, w dns.ResponseWriter, req *dns.Msg) error {
+	event := audit.FromContext(ctx)
+	q := req.Question[0]
+	if q.Qtype != dns.TypeSOA || q.Qclass != dns.ClassINET {
+		return &zone.UpdateError{Rcode: dns.RcodeFormatError, Message: "zone section must have SOA type and IN class"}
+	}
+	t := req.IsTsig()
+	if t == nil {
+		return &zone.UpdateError{Rcode: dns.RcodeRefused, Message: "update is not signed by TSIG key"}
+	}
+	// Key may be deleted or rotated after dns.Server verified the request with its secrets
+	key, ok := h.keys.Get(t.Hdr.Name)
+	if err := w.TsigStatus(); err != nil || !ok || tsigkey.DNSAlgorithm(key.Algorithm) != strings.ToLower(t.Algorithm) {
+		return &zone.UpdateError{Rcode: dns.RcodeNotAuth, Message: "TSIG verification failed"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.config.PDNS.AuthConfig.Timeout)*time.Second)
+	defer cancel()
+
+	// Other writers of the zone on this node wait until the update is applied,
+	// so prerequisites are checked against the state the update is applied to
+	unlock := h.updater.LockZone(q.Name)
+	defer unlock()
+
+	z, err := h.auth.Zones().GetZone(ctx, serverID, q.Name)
+	if err != nil {
+		return errors.WrapPDNS(err, "getting zone %s", q.Name)
+	}
+	event.SetZone(serverID, z.Name)
+	if z.Kind == zones.ZoneKindSlave {
+		return &zone.UpdateError{Rcode: dns.RcodeRefused, Message: "zone " + z.Name + " is Slave"}
+	}
+	allowed, err := h.metadata.GetMetadata(ctx, serverID, z.ID, zone.MetadataTSIGAllowDNSUpdate)
+	if err != nil {
+		return errors.WrapPDNS(err, "getting metadata %s of zone %s", zone.MetadataTSIGAllowDNSUpdate, z.Name)
+	}
+	if !contains(allowed, key.Name) {
+		return &zone.UpdateError{Rcode: dns.RcodeRefused, Message: "TSIG key " + key.Name + " is not allowed to update zone " + z.Name}
+	}
+
+	err = zone.CheckPrerequisites(z, req.Answer)
+	if err != nil {
+		return err
+	}
+	rrsets, err := zone.UpdateRRSets(z, req.Ns)
+	if err != nil {
+		return err
+	}
+	if len(rrsets) == 0 {
+		return nil
+	}
+	if h.config.DDNS.SetPTR {
+		for _, rrset := range rrsets {
+			for i := range rrset.Records {
+				rrset.Records[i].SetPTR = rrset.ChangeType == zones.ChangeTypeReplace
+			}
+		}
+	}
+	return h.updater.UpdateRRSets(ctx, serverID, z.ID, rrsets)
+}
+
+// rcode returns response code for the error of update
+func rcode(err error) int {
+	if err == nil {
+		return dns.RcodeSuccess
+	}
+	if ue, ok := err.(*zone.UpdateError); ok {
+		return ue.Rcode
+	}
+	switch errors.GetType(err) {
+	case errors.NotFound:
+		// Not a zone of the server
+		return dns.RcodeNotAuth
+	case errors.BadRequest, errors.Conflict, errors.Forbidden:
+		return dns.RcodeRefused
+	default:
+		return dns.RcodeServerFailure
+	}
+}
+
+// status returns HTTP status for the error of update in audit event
+func status(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if ue, ok := err.(*zone.UpdateError); ok {
+		switch ue.Rcode {
+		case dns.RcodeNotAuth:
+			return http.StatusUnauthorized
+		case dns.RcodeRefused:
+			return http.StatusForbidden
+		case dns.RcodeNameError, dns.RcodeYXDomain, dns.RcodeNXRrset, dns.RcodeYXRrset:
+			// Prerequisites are not satisfied
+			return http.StatusPreconditionFailed
+		default:
+			return http.StatusBadRequest
+		}
+	}
+	return network.HTTPStatus(errors.GetType(err))
+}
+
+func keyName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if keyName(n) == keyName(name) {
+			return true
+		}
+	}
+	return false
+}
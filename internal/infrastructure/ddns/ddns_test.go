@@ -0,0 +1,297 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	pdnsApi "github.com/mittwald/go-powerdns"
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/tsigkey"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/audit"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0cw=="
+
+type fakeMetadata map[string][]string
+
+func (m fakeMetadata) GetMetadata(ctx context.Context, serverID, zoneID, kind string) ([]string, error) {
+	return m[zoneID+"/"+kind], nil
+}
+
+type fakeUpdater struct {
+	mu     sync.Mutex
+	rrsets []zones.ResourceRecordSet
+	events []*audit.Event
+}
+
+func (u *fakeUpdater) LockZone(zoneID string) func() {
+	return func() {}
+}
+
+func (u *fakeUpdater) UpdateRRSets(ctx context.Context, serverID, zoneID string, rrsets []zones.ResourceRecordSet) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.rrsets = append(u.rrsets, rrsets...)
+	return nil
+}
+
+func (u *fakeUpdater) Enabled() bool { return true }
+
+func (u *fakeUpdater) Updated() []zones.ResourceRecordSet {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.rrsets
+}
+
+// Events returns recorded events and forgets them
+func (u *fakeUpdater) Events() []*audit.Event {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	events := u.events
+	u.events = nil
+	return events
+}
+
+func (u *fakeUpdater) Record(e *audit.Event) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.events = append(u.events, e)
+}
+
+type fakeTSIGKeys struct {
+	mu   sync.Mutex
+	keys []tsigkey.TSIGKey
+}
+
+func (c *fakeTSIGKeys) ListTSIGKeys(ctx context.Context, serverID string) ([]tsigkey.TSIGKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]tsigkey.TSIGKey, 0, len(c.keys))
+	for _, key := range c.keys {
+		list = append(list, key.Redacted())
+	}
+	return list, nil
+}
+
+func (c *fakeTSIGKeys) GetTSIGKey(ctx context.Context, serverID, keyID string) (*tsigkey.TSIGKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range c.keys {
+		if key.ID == keyID {
+			return &key, nil
+		}
+	}
+	return nil, errors.NotFound.Newf("TSIG key %s not found", keyID)
+}
+
+func (c *fakeTSIGKeys) Set(keys ...tsigkey.TSIGKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = keys
+}
+
+// startPowerDNS starts PowerDNS API serving the zone example.com.
+func startPowerDNS(t *testing.T) pdnsApi.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/servers/localhost/zones/example.com.") {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"Not Found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(zones.Zone{
+			ID:   "example.com.",
+			Name: "example.com.",
+			Kind: zones.ZoneKindNative,
+			ResourceRecordSets: []zones.ResourceRecordSet{
+				{Name: "www.example.com.", Type: "A", TTL: 300, Records: []zones.Record{{Content: "192.0.2.1"}}},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	c, err := pdnsApi.New(pdnsApi.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+	return c
+}
+
+var (
+	dhcpKey  = tsigkey.TSIGKey{ID: "dhcp.", Name: "dhcp", Algorithm: "hmac-sha256", Key: secret}
+	otherKey = tsigkey.TSIGKey{ID: "other.", Name: "other", Algorithm: "hmac-sha256", Key: secret}
+)
+
+// startServer starts DNS UPDATE listener with keys loaded from the client
+func startServer(t *testing.T, updater *fakeUpdater, client *fakeTSIGKeys) (string, *Keys) {
+	keys := NewKeys(client, logrus.New())
+	_, err := keys.Load(context.Background())
+	require.NoError(t, err)
+	metadata := fakeMetadata{"example.com./" + zone.MetadataTSIGAllowDNSUpdate: {"dhcp"}}
+	var cfg config.Config
+	cfg.PDNS.AuthConfig.Timeout = 5
+	h := NewHandler(cfg, logrus.New(), startPowerDNS(t), metadata, updater, updater, keys)
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	srv := &dns.Server{
+		PacketConn:        pc,
+		Handler:           h,
+		TsigSecret:        keys.Secrets(),
+		MsgAcceptFunc:     AcceptUpdate,
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() { _ = srv.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return pc.LocalAddr().String(), keys
+}
+
+func update(t *testing.T, addr, key, keySecret string, m *dns.Msg) *dns.Msg {
+	co, err := dns.Dial("udp", addr)
+	require.NoError(t, err)
+	defer co.Close()
+	if key != "" {
+		co.TsigSecret = map[string]string{dns.Fqdn(key): keySecret}
+		m.SetTsig(dns.Fqdn(key), dns.HmacSHA256, 300, time.Now().Unix())
+	}
+	require.NoError(t, co.WriteMsg(m))
+	resp, err := co.ReadMsg()
+	// miekg/dns doesn't verify TSIG of NOTAUTH responses
+	if err != dns.ErrAuth || resp.Rcode != dns.RcodeNotAuth {
+		require.NoError(t, err)
+	}
+	return resp
+}
+
+func newUpdate(t *testing.T, zoneName string, records ...string) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetUpdate(zoneName)
+	for _, s := range records {
+		rr, err := dns.NewRR(s)
+		require.NoError(t, err)
+		m.Insert([]dns.RR{rr})
+	}
+	return m
+}
+
+func TestUpdate(t *testing.T) {
+	u := &fakeUpdater{}
+	addr, _ := startServer(t, u, &fakeTSIGKeys{keys: []tsigkey.TSIGKey{dhcpKey, otherKey}})
+
+	resp := update(t, addr, "dhcp", secret, newUpdate(t, "example.com.", "host.example.com. 60 IN A 192.0.2.10"))
+	require.Equal(t, dns.RcodeSuccess, resp.Rcode)
+	require.NotNil(t, resp.IsTsig())
+	require.Equal(t, []zones.ResourceRecordSet{
+		{Name: "host.example.com.", Type: "A", TTL: 60, ChangeType: zones.ChangeTypeReplace, Records: []zones.Record{{Content: "192.0.2.10"}}},
+	}, u.Updated())
+	events := u.Events()
+	require.Len(t, events, 1)
+	require.Equal(t, "dhcp", events[0].Actor)
+	require.Equal(t, "127.0.0.1", events[0].SourceIP)
+	require.Equal(t, "example.com.", events[0].Zone)
+	require.Equal(t, audit.ResultSuccess, events[0].Result)
+}
+
+func TestUpdateErrors(t *testing.T) {
+	u := &fakeUpdater{}
+	addr, _ := startServer(t, u, &fakeTSIGKeys{keys: []tsigkey.TSIGKey{dhcpKey, otherKey}})
+
+	prereq := newUpdate(t, "example.com.", "host.example.com. 60 IN A 192.0.2.10")
+	rr, err := dns.NewRR("www.example.com. 0 IN AAAA")
+	require.NoError(t, err)
+	prereq.RRsetUsed([]dns.RR{rr})
+
+	query := new(dns.Msg)
+	query.SetQuestion("example.com.", dns.TypeSOA)
+
+	for _, tc := range []struct {
+		name   string
+		key    string
+		secret string
+		msg    *dns.Msg
+		rcode  int
+		result string
+	}{
+		{"unsigned", "", "", newUpdate(t, "example.com.", "host.example.com. 60 IN A 192.0.2.10"), dns.RcodeRefused, audit.ResultDenied},
+		{"wrong secret", "dhcp", "b3RoZXI=", newUpdate(t, "example.com.", "host.example.com. 60 IN A 192.0.2.10"), dns.RcodeNotAuth, audit.ResultDenied},
+		{"key not allowed", "other", secret, newUpdate(t, "example.com.", "host.example.com. 60 IN A 192.0.2.10"), dns.RcodeRefused, audit.ResultDenied},
+		{"unknown zone", "dhcp", secret, newUpdate(t, "example.org.", "host.example.org. 60 IN A 192.0.2.10"), dns.RcodeNotAuth, audit.ResultFailure},
+		{"prerequisite", "dhcp", secret, prereq, dns.RcodeNXRrset, audit.ResultFailure},
+		{"outside of zone", "dhcp", secret, newUpdate(t, "example.com.", "host.example.org. 60 IN A 192.0.2.10"), dns.RcodeNotZone, audit.ResultFailure},
+		{"query", "", "", query, dns.RcodeNotImplemented, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := update(t, addr, tc.key, tc.secret, tc.msg)
+			require.Equal(t, tc.rcode, resp.Rcode, dns.RcodeToString[resp.Rcode])
+			events := u.Events()
+			if tc.result != "" {
+				require.Len(t, events, 1)
+				require.Equal(t, tc.result, events[0].Result)
+			}
+		})
+	}
+	require.Empty(t, u.Updated())
+}
+
+func TestUpdateDeletedKey(t *testing.T) {
+	u := &fakeUpdater{}
+	client := &fakeTSIGKeys{keys: []tsigkey.TSIGKey{dhcpKey, otherKey}}
+	addr, keys := startServer(t, u, client)
+
+	client.Set(otherKey)
+	changed, err := keys.Load(context.Background())
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	// Listener still has the secret of the deleted key until it is restarted
+	resp := update(t, addr, "dhcp", secret, newUpdate(t, "example.com.", "host.example.com. 60 IN A 192.0.2.10"))
+	require.Equal(t, dns.RcodeNotAuth, resp.Rcode)
+	events := u.Events()
+	require.Len(t, events, 1)
+	require.Equal(t, audit.ResultDenied, events[0].Result)
+	require.Empty(t, u.Updated())
+}
+
+func TestKeysRun(t *testing.T) {
+	client := &fakeTSIGKeys{keys: []tsigkey.TSIGKey{dhcpKey}}
+	keys := NewKeys(client, logrus.New())
+	_, err := keys.Load(context.Background())
+	require.NoError(t, err)
+
+	changes := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go keys.Run(ctx, time.Hour, func() { changes <- struct{}{} })
+
+	// Rotated secret is reloaded after invalidation without waiting for the interval
+	rotated := dhcpKey
+	rotated.Key = "cm90YXRlZHJvdGF0ZWRyb3RhdGVkcm90YXRlZHJvdGE="
+	client.Set(rotated)
+	keys.Invalidate()
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("keys were not reloaded")
+	}
+	key, ok := keys.Get("DHCP.")
+	require.True(t, ok)
+	require.Equal(t, rotated.Key, key.Key)
+	require.Equal(t, map[string]string{"dhcp.": rotated.Key}, keys.Secrets())
+
+	// Unchanged keys don't restart the listener
+	changed, err := keys.Load(context.Background())
+	require.NoError(t, err)
+	require.False(t, changed)
+}
@@ -0,0 +1,128 @@
+package ddns
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/domain/tsigkey"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Keys is a set of TSIG keys of PowerDNS with secrets, reloaded from its API
+type Keys struct {
+	mu sync.RWMutex
+	// keys by lower-case name without trailing dot
+	keys   map[string]tsigkey.TSIGKey
+	client tsigKeyClient
+	logger *logrus.Logger
+	reload chan struct{}
+}
+
+func NewKeys(client tsigKeyClient, logger *logrus.Logger) *Keys {
+	return &Keys{keys: make(map[string]tsigkey.TSIGKey), client: client, logger: logger, reload: make(chan struct{}, 1)}
+}
+
+// Set replaces the set of keys and reports whether it was changed
+func (k *Keys) Set(keys []tsigkey.TSIGKey) bool {
+	set := make(map[string]tsigkey.TSIGKey, len(keys))
+	for _, key := range keys {
+		set[keyName(key.Name)] = key
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	changed := len(set) != len(k.keys)
+	for name, key := range set {
+		if old, ok := k.keys[name]; !ok || old.Key != key.Key || old.Algorithm != key.Algorithm {
+			changed = true
+		}
+	}
+	k.keys = set
+	return changed
+}
+
+// Get returns the key by name
+func (k *Keys) Get(name string) (tsigkey.TSIGKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[keyName(name)]
+	return key, ok
+}
+
+// Len returns the number of keys
+func (k *Keys) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.keys)
+}
+
+// Secrets returns secrets of the keys by names for TsigSecret of dns.Server.
+// dns.Server looks up names as received, so both the name and its lower-case form are added.
+func (k *Keys) Secrets() map[string]string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	secrets := make(map[string]string, 2*len(k.keys))
+	for _, key := range k.keys {
+		secrets[dns.Fqdn(key.Name)] = key.Key
+		secrets[strings.ToLower(dns.Fqdn(key.Name))] = key.Key
+	}
+	return secrets
+}
+
+// Load reloads TSIG keys with secrets from API and reports whether they were changed
+func (k *Keys) Load(ctx context.Context) (bool, error) {
+	list, err := k.client.ListTSIGKeys(ctx, serverID)
+	if err != nil {
+		return false, errors.WrapPDNS(err, "listing TSIG keys")
+	}
+	keys := make([]tsigkey.TSIGKey, 0, len(list))
+	for _, l := range list {
+		key, err := k.client.GetTSIGKey(ctx, serverID, l.ID)
+		if err != nil {
+			return false, errors.WrapPDNS(err, "getting TSIG key %s", l.ID)
+		}
+		keys = append(keys, *key)
+	}
+	return k.Set(keys), nil
+}
+
+// Invalidate makes Run reload the keys without waiting for the interval.
+// It is called after the keys are changed via API.
+func (k *Keys) Invalidate() {
+	select {
+	case k.reload <- struct{}{}:
+	default:
+		// Reload is already pending
+	}
+}
+
+// Run reloads keys every interval or after Invalidate until ctx is done and calls onChange
+// when they were changed. Keys are kept if reloading fails.
+func (k *Keys) Run(ctx context.Context, interval time.Duration, onChange func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-k.reload:
+		}
+		loadCtx, cancel := context.WithTimeout(ctx, interval)
+		changed, err := k.Load(loadCtx)
+		cancel()
+		if err != nil {
+			k.logger.WithFields(logrus.Fields{
+				"action": log.ActionDDNSUpdate,
+			}).Errorf("Cannot reload TSIG keys: %v", err)
+			continue
+		}
+		if changed {
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package dnsclient
+
+import (
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Answer is a DNS response in the form of JSON API
+type Answer struct {
+	// Server is host:port the query was sent to
+	Server string `json:"server"`
+	Rcode  string `json:"rcode"`
+	Flags  Flags  `json:"flags"`
+	// RTT is round trip time in milliseconds
+	RTT        float64  `json:"rtt_ms"`
+	Question   []Record `json:"question"`
+	Answer     []Record `json:"answer"`
+	Authority  []Record `json:"authority"`
+	Additional []Record `json:"additional"`
+}
+
+// Flags are header flags of DNS response
+type Flags struct {
+	Authoritative      bool `json:"aa"`
+	Truncated          bool `json:"tc"`
+	RecursionDesired   bool `json:"rd"`
+	RecursionAvailable bool `json:"ra"`
+	AuthenticatedData  bool `json:"ad"`
+	CheckingDisabled   bool `json:"cd"`
+}
+
+// Record is a resource record of DNS response, TTL and content are empty for question
+type Record struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	TTL     uint32 `json:"ttl,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// NewAnswer converts the response from server received in rtt. EDNS0 OPT record is omitted.
+func NewAnswer(server string, m *dns.Msg, rtt time.Duration) *Answer {
+	a := &Answer{
+		Server: server,
+		Rcode:  dns.RcodeToString[m.Rcode],
+		Flags: Flags{
+			Authoritative:      m.Authoritative,
+			Truncated:          m.Truncated,
+			RecursionDesired:   m.RecursionDesired,
+			RecursionAvailable: m.RecursionAvailable,
+			AuthenticatedData:  m.AuthenticatedData,
+			CheckingDisabled:   m.CheckingDisabled,
+		},
+		RTT:        float64(rtt) / float64(time.Millisecond),
+		Question:   []Record{},
+		Answer:     records(m.Answer),
+		Authority:  records(m.Ns),
+		Additional: records(m.Extra),
+	}
+	for _, q := range m.Question {
+		a.Question = append(a.Question, Record{Name: q.Name, Type: dns.TypeToString[q.Qtype]})
+	}
+	return a
+}
+
+func records(rrs []dns.RR) []Record {
+	result := []Record{}
+	for _, rr := range rrs {
+		h := rr.Header()
+		if h.Rrtype == dns.TypeOPT {
+			continue
+		}
+		result = append(result, Record{
+			Name:    h.Name,
+			Type:    dns.TypeToString[h.Rrtype],
+			TTL:     h.Ttl,
+			Content: strings.TrimPrefix(rr.String(), h.String()),
+		})
+	}
+	return result
+}
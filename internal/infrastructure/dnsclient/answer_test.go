@@ -0,0 +1,37 @@
+package dnsclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAnswer(t *testing.T) {
+	req := new(dns.Msg)
+	req.SetQuestion("www.example.com.", dns.TypeA)
+	req.SetEdns0(udpBufferSize, false)
+	m := new(dns.Msg)
+	m.SetReply(req)
+	m.Authoritative = true
+	a, err := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+	require.NoError(t, err)
+	ns, err := dns.NewRR("example.com. 3600 IN NS ns1.example.com.")
+	require.NoError(t, err)
+	m.Answer = []dns.RR{a}
+	m.Ns = []dns.RR{ns}
+	m.SetEdns0(udpBufferSize, false)
+
+	answer := NewAnswer("127.0.0.1:5353", m, 1500*time.Microsecond)
+	require.Equal(t, &Answer{
+		Server:     "127.0.0.1:5353",
+		Rcode:      "NOERROR",
+		Flags:      Flags{Authoritative: true, RecursionDesired: true},
+		RTT:        1.5,
+		Question:   []Record{{Name: "www.example.com.", Type: "A"}},
+		Answer:     []Record{{Name: "www.example.com.", Type: "A", TTL: 300, Content: "192.0.2.1"}},
+		Authority:  []Record{{Name: "example.com.", Type: "NS", TTL: 3600, Content: "ns1.example.com."}},
+		Additional: []Record{},
+	}, answer)
+}
@@ -0,0 +1,124 @@
+// Package dnsclient queries PowerDNS servers over DNS protocol, to see the data they actually serve.
+// Network errors are Unavailable, bad responses are UpstreamError.
+package dnsclient
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+const (
+	// defaultTimeout is used for requests without deadline in context
+	defaultTimeout = 10 * time.Second
+	// udpBufferSize is EDNS0 UDP payload size advertised in queries
+	udpBufferSize = 1232
+)
+
+// TSIG is a key to sign requests
+type TSIG struct {
+	// Name of the key
+	Name string
+	// Algorithm in miekg/dns form, like dns.HmacSHA256
+	Algorithm string
+	// Secret is a base64 encoded secret
+	Secret string
+}
+
+// Transfer returns records of the zone transferred by AXFR from the server at addr (host:port).
+// The closing SOA record is omitted. Transfer is signed by key if it is not nil.
+func Transfer(ctx context.Context, addr, zone string, key *TSIG) ([]dns.RR, error) {
+	zone = dns.Fqdn(zone)
+	timeout := timeoutFromContext(ctx)
+	t := &dns.Transfer{DialTimeout: timeout, ReadTimeout: timeout, WriteTimeout: timeout}
+
+	m := new(dns.Msg)
+	m.SetAxfr(zone)
+	if key != nil {
+		name := strings.ToLower(dns.Fqdn(key.Name))
+		t.TsigSecret = map[string]string{name: key.Secret}
+		m.SetTsig(name, key.Algorithm, 300, time.Now().Unix())
+	}
+
+	envelopes, err := t.In(m, addr)
+	if err != nil {
+		return nil, wrap(err, "AXFR of zone %s from %s", zone, addr)
+	}
+	var rrs []dns.RR
+	for env := range envelopes {
+		if env.Error != nil {
+			// Drain the channel, so the reading goroutine exits
+			for range envelopes {
+			}
+			return nil, wrap(env.Error, "AXFR of zone %s from %s", zone, addr)
+		}
+		rrs = append(rrs, env.RR...)
+	}
+	// AXFR starts and ends with the same SOA record
+	if len(rrs) > 1 && rrs[len(rrs)-1].Header().Rrtype == dns.TypeSOA {
+		rrs = rrs[:len(rrs)-1]
+	}
+	return rrs, nil
+}
+
+// Query sends the query of qtype for name to the server at addr (host:port) over UDP and retries over TCP
+// if the answer is truncated. Recursion desired flag is set if recursion is true.
+// Returns the answer with its round trip time, answers with any rcode are not errors.
+func Query(ctx context.Context, addr, name string, qtype uint16, recursion bool) (*dns.Msg, time.Duration, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	m.RecursionDesired = recursion
+	m.SetEdns0(udpBufferSize, false)
+	return Exchange(ctx, addr, m)
+}
+
+// Exchange sends the message to the server at addr over UDP and retries over TCP if the answer is truncated
+func Exchange(ctx context.Context, addr string, m *dns.Msg) (*dns.Msg, time.Duration, error) {
+	c := &dns.Client{Net: "udp", Timeout: timeoutFromContext(ctx), UDPSize: udpBufferSize}
+	answer, rtt, err := c.ExchangeContext(ctx, m, addr)
+	if err == nil && answer.Truncated {
+		c.Net = "tcp"
+		answer, rtt, err = c.ExchangeContext(ctx, m, addr)
+	}
+	if err != nil {
+		return nil, 0, wrap(err, "query %s %s to %s", questionName(m), questionType(m), addr)
+	}
+	return answer, rtt, nil
+}
+
+func questionName(m *dns.Msg) string {
+	if len(m.Question) == 0 {
+		return ""
+	}
+	return m.Question[0].Name
+}
+
+func questionType(m *dns.Msg) string {
+	if len(m.Question) == 0 {
+		return ""
+	}
+	return dns.TypeToString[m.Question[0].Qtype]
+}
+
+// timeoutFromContext returns time left until the context deadline or default timeout
+func timeoutFromContext(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); left > 0 {
+			return left
+		}
+		return time.Millisecond
+	}
+	return defaultTimeout
+}
+
+// wrap returns Unavailable error for network errors and UpstreamError for others
+func wrap(err error, format string, args ...interface{}) error {
+	if _, ok := err.(net.Error); ok {
+		return errors.Unavailable.Wrapf(err, format, args...)
+	}
+	return errors.UpstreamError.Wrapf(err, format, args...)
+}
@@ -0,0 +1,130 @@
+package dnsclient
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0"
+
+// startServer starts DNS server over TCP with the handler, TSIG key "transfer." is known to it
+func startServer(t *testing.T, handler dns.HandlerFunc) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	srv := &dns.Server{
+		Listener:          l,
+		Handler:           handler,
+		TsigSecret:        map[string]string{"transfer.": testSecret},
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() { _ = srv.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return l.Addr().String()
+}
+
+func axfrHandler(t *testing.T, requireTSIG bool) dns.HandlerFunc {
+	return func(w dns.ResponseWriter, req *dns.Msg) {
+		if requireTSIG && (req.IsTsig() == nil || w.TsigStatus() != nil) {
+			m := new(dns.Msg)
+			m.SetRcode(req, dns.RcodeRefused)
+			_ = w.WriteMsg(m)
+			return
+		}
+		soa, err := dns.NewRR("example.com. 3600 IN SOA ns1.example.com. hostmaster.example.com. 7 10800 3600 604800 3600")
+		require.NoError(t, err)
+		a, err := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+		require.NoError(t, err)
+
+		ch := make(chan *dns.Envelope, 1)
+		tr := new(dns.Transfer)
+		ch <- &dns.Envelope{RR: []dns.RR{soa, a, soa}}
+		close(ch)
+		// Responses to signed requests are signed by Transfer.Out
+		_ = tr.Out(w, req, ch)
+		w.Hijack()
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	addr := startServer(t, axfrHandler(t, false))
+
+	rrs, err := Transfer(context.Background(), addr, "example.com", nil)
+	require.NoError(t, err)
+	require.Len(t, rrs, 2)
+	require.Equal(t, uint32(7), rrs[0].(*dns.SOA).Serial)
+	require.Equal(t, "192.0.2.1", rrs[1].(*dns.A).A.String())
+}
+
+func TestTransferTSIG(t *testing.T) {
+	addr := startServer(t, axfrHandler(t, true))
+
+	_, err := Transfer(context.Background(), addr, "example.com.", nil)
+	require.Equal(t, errors.UpstreamError, errors.GetType(err))
+
+	rrs, err := Transfer(context.Background(), addr, "example.com.", &TSIG{Name: "transfer", Algorithm: dns.HmacSHA256, Secret: testSecret})
+	require.NoError(t, err)
+	require.Len(t, rrs, 2)
+}
+
+func TestTransferUnavailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = Transfer(ctx, addr, "example.com.", nil)
+	require.Equal(t, errors.Unavailable, errors.GetType(err))
+}
+
+// startUDPAndTCPServer starts DNS server over UDP and TCP on the same port with the handler
+func startUDPAndTCPServer(t *testing.T, handler dns.HandlerFunc) string {
+	t.Helper()
+	addr := startServer(t, handler)
+	pc, err := net.ListenPacket("udp", addr)
+	require.NoError(t, err)
+	started := make(chan struct{})
+	srv := &dns.Server{PacketConn: pc, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go func() { _ = srv.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return addr
+}
+
+func TestQuery(t *testing.T) {
+	var udpQueries int32
+	addr := startUDPAndTCPServer(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.RecursionAvailable = req.RecursionDesired
+		if _, ok := w.RemoteAddr().(*net.UDPAddr); ok {
+			atomic.AddInt32(&udpQueries, 1)
+			// Force retry over TCP
+			m.Truncated = true
+		} else {
+			rr, err := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+			require.NoError(t, err)
+			m.Answer = append(m.Answer, rr)
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	answer, rtt, err := Query(context.Background(), addr, "www.example.com", dns.TypeA, true)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&udpQueries))
+	require.True(t, answer.RecursionAvailable)
+	require.Len(t, answer.Answer, 1)
+	require.Equal(t, "192.0.2.1", answer.Answer[0].(*dns.A).A.String())
+	require.True(t, rtt > 0)
+}
@@ -0,0 +1,159 @@
+// Package doh implements DNS-over-HTTPS (RFC 8484) frontend of local PowerDNS servers.
+// Queries for zones served by PowerDNS Authoritative are sent to it, others to PowerDNS Recursor.
+package doh
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/zone"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/dnsclient"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ContentType is media type of DNS messages
+	ContentType = "application/dns-message"
+	// maxMessageSize is the largest DNS message
+	maxMessageSize = dns.MaxMsgSize
+)
+
+type owner interface {
+	Owns(name string) bool
+}
+
+// Handler serves DNS queries in GET 'dns' parameter or POST body
+type Handler struct {
+	config config.Config
+	stats  stats.PrometheusStatsCollector
+	logger *logrus.Logger
+	zones  owner
+}
+
+func NewHandler(config config.Config, stats stats.PrometheusStatsCollector, logger *logrus.Logger, zones owner) *Handler {
+	return &Handler{config: config, stats: stats, logger: logger, zones: zones}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	timer := h.stats.GetLabeledResponseTimePeersHistogramTimer(h.config.Environment, network.GetHostname(), r.URL.Path, r.Method)
+	defer timer.ObserveDuration()
+
+	req, status, msg := readQuery(r)
+	if status != http.StatusOK {
+		h.writeError(w, r, status, msg)
+		return
+	}
+	qname := req.Question[0].Name
+
+	server, addr, timeout := zone.ServerRecursor, h.config.PDNS.RecursorConfig.DNSAddress, h.config.PDNS.RecursorConfig.Timeout
+	if h.zones.Owns(qname) {
+		server, addr, timeout = zone.ServerAuth, h.config.PDNS.AuthConfig.DNSAddress, h.config.PDNS.AuthConfig.Timeout
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	resp, _, err := dnsclient.Exchange(ctx, addr, req)
+	if err != nil {
+		h.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+			"action": log.ActionDoH,
+		}).Warnf("Query %s %s to %s failed: %v", qname, dns.TypeToString[req.Question[0].Qtype], server, err)
+		// Failure of upstream is a DNS answer, not an HTTP error
+		resp = new(dns.Msg)
+		resp.SetRcode(req, dns.RcodeServerFailure)
+	}
+	b, err := resp.Pack()
+	if err != nil {
+		h.writeError(w, r, http.StatusBadGateway, "cannot pack DNS response")
+		return
+	}
+
+	h.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionDoH,
+	}).Debugf("Query %s %s answered by %s with %s", qname, dns.TypeToString[req.Question[0].Qtype], server, dns.RcodeToString[resp.Rcode])
+
+	w.Header().Set("Content-Type", ContentType)
+	if ttl, ok := minTTL(resp); ok {
+		w.Header().Set("Cache-Control", "max-age="+strconv.FormatUint(uint64(ttl), 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b)
+	h.stats.CountCall(h.config.Environment, network.GetHostname(), r.URL.Path, r.Method, http.StatusOK)
+}
+
+// readQuery returns DNS query from the request or HTTP status and message of the error
+func readQuery(r *http.Request) (*dns.Msg, int, string) {
+	var b []byte
+	switch r.Method {
+	case http.MethodGet:
+		param := r.URL.Query().Get("dns")
+		if param == "" {
+			return nil, http.StatusBadRequest, "dns parameter is required"
+		}
+		// base64url without padding, tolerate padding
+		var err error
+		b, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(param, "="))
+		if err != nil {
+			return nil, http.StatusBadRequest, "dns parameter must be base64url encoded"
+		}
+	case http.MethodPost:
+		if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != ContentType {
+			return nil, http.StatusUnsupportedMediaType, "Content-Type must be " + ContentType
+		}
+		var err error
+		b, err = ioutil.ReadAll(io.LimitReader(r.Body, maxMessageSize+1))
+		if err != nil {
+			return nil, http.StatusBadRequest, "cannot read request body"
+		}
+	default:
+		return nil, http.StatusMethodNotAllowed, "method must be GET or POST"
+	}
+	if len(b) > maxMessageSize {
+		return nil, http.StatusRequestEntityTooLarge, "DNS message is too large"
+	}
+
+	req := new(dns.Msg)
+	if err := req.Unpack(b); err != nil {
+		return nil, http.StatusBadRequest, "invalid DNS message"
+	}
+	if req.Response || req.Opcode != dns.OpcodeQuery || len(req.Question) != 1 {
+		return nil, http.StatusBadRequest, "DNS message must be a query with a single question"
+	}
+	return req, http.StatusOK, ""
+}
+
+// minTTL returns the smallest TTL of records in the response, RFC 8484 section 5.1
+func minTTL(m *dns.Msg) (uint32, bool) {
+	var ttl uint32
+	var found bool
+	for _, section := range [][]dns.RR{m.Answer, m.Ns, m.Extra} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			if !found || rr.Header().Ttl < ttl {
+				ttl, found = rr.Header().Ttl, true
+			}
+		}
+	}
+	return ttl, found
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	h.logger.WithContext(r.Context()).WithFields(logrus.Fields{
+		"action": log.ActionDoH,
+	}).Debugf("Bad DoH request: %s", msg)
+	http.Error(w, msg, status)
+	h.stats.CountError(h.config.Environment, network.GetHostname(), r.URL.Path, status)
+}
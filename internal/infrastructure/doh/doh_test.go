@@ -0,0 +1,155 @@
+package doh
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type nopStats struct{}
+
+func (nopStats) CountCall(env, node, path, method string, status int) {}
+func (nopStats) CountError(env, node, path string, status int)        {}
+func (nopStats) GetLabeledResponseTimePeersHistogramTimer(env, node, path, method string) *prometheus.Timer {
+	return prometheus.NewTimer(prometheus.ObserverFunc(func(float64) {}))
+}
+
+// startServer starts DNS server over UDP answering A queries with the address
+func startServer(t *testing.T, address string) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	srv := &dns.Server{
+		PacketConn: pc,
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(req)
+			rr, err := dns.NewRR(req.Question[0].Name + " 300 IN A " + address)
+			require.NoError(t, err)
+			m.Answer = append(m.Answer, rr)
+			soa, err := dns.NewRR("example.com. 60 IN SOA ns1.example.com. hostmaster.example.com. 1 10800 3600 604800 3600")
+			require.NoError(t, err)
+			m.Ns = append(m.Ns, soa)
+			_ = w.WriteMsg(m)
+		}),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() { _ = srv.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+func newHandler(t *testing.T) *Handler {
+	var cfg config.Config
+	cfg.PDNS.AuthConfig.DNSAddress = startServer(t, "192.0.2.1")
+	cfg.PDNS.RecursorConfig.DNSAddress = startServer(t, "198.51.100.1")
+	cfg.PDNS.AuthConfig.Timeout = 5
+	cfg.PDNS.RecursorConfig.Timeout = 5
+	zones := NewZones(nil, logrus.New())
+	zones.Set([]string{"example.com"})
+	return NewHandler(cfg, nopStats{}, logrus.New(), zones)
+}
+
+func query(t *testing.T, name string) []byte {
+	m := new(dns.Msg)
+	m.SetQuestion(name, dns.TypeA)
+	m.Id = 0
+	b, err := m.Pack()
+	require.NoError(t, err)
+	return b
+}
+
+func answer(t *testing.T, rec *httptest.ResponseRecorder) *dns.Msg {
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	m := new(dns.Msg)
+	require.NoError(t, m.Unpack(rec.Body.Bytes()))
+	return m
+}
+
+func TestHandler(t *testing.T) {
+	h := newHandler(t)
+
+	// Authoritative zone over GET
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dns-query?dns="+base64.RawURLEncoding.EncodeToString(query(t, "www.example.com.")), nil))
+	m := answer(t, rec)
+	require.Equal(t, "192.0.2.1", m.Answer[0].(*dns.A).A.String())
+	require.Equal(t, "max-age=60", rec.Header().Get("Cache-Control"))
+
+	// Other names over POST
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/dns-query", bytes.NewReader(query(t, "www.example.org.")))
+	req.Header.Set("Content-Type", ContentType)
+	h.ServeHTTP(rec, req)
+	m = answer(t, rec)
+	require.Equal(t, "198.51.100.1", m.Answer[0].(*dns.A).A.String())
+	require.Equal(t, uint16(0), m.Id)
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h := newHandler(t)
+	response := func(m *dns.Msg) []byte {
+		m.Response = true
+		b, err := m.Pack()
+		require.NoError(t, err)
+		return b
+	}
+
+	for _, tc := range []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"no dns parameter", httptest.NewRequest(http.MethodGet, "/dns-query", nil), http.StatusBadRequest},
+		{"bad base64", httptest.NewRequest(http.MethodGet, "/dns-query?dns=%21%21", nil), http.StatusBadRequest},
+		{"garbage", httptest.NewRequest(http.MethodGet, "/dns-query?dns=AAAA", nil), http.StatusBadRequest},
+		{"response", httptest.NewRequest(http.MethodGet, "/dns-query?dns="+base64.RawURLEncoding.EncodeToString(response(new(dns.Msg).SetQuestion("example.com.", dns.TypeA))), nil), http.StatusBadRequest},
+		{"POST without content type", httptest.NewRequest(http.MethodPost, "/dns-query", bytes.NewReader(query(t, "example.com."))), http.StatusUnsupportedMediaType},
+		{"PUT", httptest.NewRequest(http.MethodPut, "/dns-query", nil), http.StatusMethodNotAllowed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, tc.req)
+			require.Equal(t, tc.status, rec.Code)
+		})
+	}
+}
+
+func TestHandlerUpstreamFailure(t *testing.T) {
+	h := newHandler(t)
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	h.config.PDNS.RecursorConfig.DNSAddress = l.LocalAddr().String()
+	require.NoError(t, l.Close())
+	h.config.PDNS.RecursorConfig.Timeout = 1
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/dns-query", bytes.NewReader(query(t, "www.example.org.")))
+	req.Header.Set("Content-Type", ContentType)
+	h.ServeHTTP(rec, req)
+	require.Equal(t, dns.RcodeServerFailure, answer(t, rec).Rcode)
+	require.Empty(t, rec.Header().Get("Cache-Control"))
+}
+
+func TestZonesOwns(t *testing.T) {
+	zones := NewZones(nil, logrus.New())
+	zones.Set([]string{"Example.com.", "10.in-addr.arpa"})
+	require.True(t, zones.Owns("example.com"))
+	require.True(t, zones.Owns("WWW.example.com."))
+	require.True(t, zones.Owns("1.0.0.10.in-addr.arpa."))
+	require.False(t, zones.Owns("example.org."))
+	require.False(t, zones.Owns("com."))
+	require.False(t, zones.Owns("notexample.com."))
+}
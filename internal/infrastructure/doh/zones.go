@@ -0,0 +1,84 @@
+package doh
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	pdnsApi "github.com/mittwald/go-powerdns"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Zones is a set of zones served by PowerDNS Authoritative, reloaded from its API
+type Zones struct {
+	mu     sync.RWMutex
+	names  map[string]bool
+	auth   pdnsApi.Client
+	logger *logrus.Logger
+}
+
+func NewZones(auth pdnsApi.Client, logger *logrus.Logger) *Zones {
+	return &Zones{names: make(map[string]bool), auth: auth, logger: logger}
+}
+
+// Set replaces the set of zones
+func (z *Zones) Set(names []string) {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(dns.Fqdn(name))] = true
+	}
+	z.mu.Lock()
+	z.names = set
+	z.mu.Unlock()
+}
+
+// Owns reports whether the name is in one of the zones
+func (z *Zones) Owns(name string) bool {
+	name = strings.ToLower(dns.Fqdn(name))
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	for off, end := 0, false; !end; off, end = dns.NextLabel(name, off) {
+		if z.names[name[off:]] {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reloads zones of PowerDNS server from API
+func (z *Zones) Load(ctx context.Context, serverID string) error {
+	zs, err := z.auth.Zones().ListZones(ctx, serverID)
+	if err != nil {
+		return errors.WrapPDNS(err, "listing zones")
+	}
+	names := make([]string, 0, len(zs))
+	for _, zone := range zs {
+		names = append(names, zone.Name)
+	}
+	z.Set(names)
+	return nil
+}
+
+// Run reloads zones every interval until ctx is done. Zones are kept if reloading fails.
+func (z *Zones) Run(ctx context.Context, serverID string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		loadCtx, cancel := context.WithTimeout(ctx, interval)
+		if err := z.Load(loadCtx, serverID); err != nil {
+			z.logger.WithFields(logrus.Fields{
+				"action": log.ActionDoH,
+			}).Errorf("Cannot reload authoritative zones: %v", err)
+		}
+		cancel()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
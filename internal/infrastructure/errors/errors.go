@@ -19,20 +19,59 @@ const (
 	// Conflict indicates that the request could not be processed because of conflict in the request,
 	// such as an edit conflict in the case of multiple updates.
 	Conflict
+	// Unauthorized the client is not identified.
+	Unauthorized
+	// Forbidden the client is identified but has no permission for the request.
+	Forbidden
+	// Unavailable a dependency (PowerDNS, LDAP, Consul) is temporarily unavailable.
+	Unavailable
+	// UpstreamError a dependency returned an unexpected error.
+	UpstreamError
+	// PreconditionFailed the object was changed since the client has read it, see If-Match header.
+	PreconditionFailed
 )
 
+// String returns error code used in error responses
+func (t ErrorType) String() string {
+	switch t {
+	case BadRequest:
+		return "bad_request"
+	case NotFound:
+		return "not_found"
+	case Conflict:
+		return "conflict"
+	case Unauthorized:
+		return "unauthorized"
+	case Forbidden:
+		return "forbidden"
+	case Unavailable:
+		return "unavailable"
+	case UpstreamError:
+		return "upstream_error"
+	case PreconditionFailed:
+		return "precondition_failed"
+	default:
+		return "internal_error"
+	}
+}
+
 type pdnsError struct {
 	errorType     ErrorType
 	originalError error
-	// context       errorContext
+	context       errorContext
 }
 
-/*
 type errorContext struct {
-	Field   string
-	Message string
+	// Details holds additional information, e.g. error message from PowerDNS
+	Details string
+	Fields  []FieldError
+}
+
+// FieldError describes an invalid field of request body or query
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
-*/
 
 // Error returns the message of a PDNSError.
 // Implements the error built-in interface.
@@ -67,9 +106,15 @@ func (t ErrorType) Wrap(err error, msg string) error {
 func (t ErrorType) Wrapf(err error, msg string, args ...interface{}) error {
 	e := errors.Wrapf(err, msg, args...)
 
+	var context errorContext
+	if pdnsErr, ok := err.(pdnsError); ok {
+		context = pdnsErr.context
+	}
+
 	return pdnsError{
 		errorType:     t,
 		originalError: e,
+		context:       context,
 	}
 }
 
@@ -101,7 +146,7 @@ func Wrapf(err error, msg string, args ...interface{}) error {
 		return pdnsError{
 			errorType:     pdnsErr.errorType,
 			originalError: wrappedError,
-			// context:       pdnsErr.context,
+			context:       pdnsErr.context,
 		}
 	}
 
@@ -116,42 +161,53 @@ func Cause(err error) error {
 	return errors.Cause(err)
 }
 
-/*
-// AddErrorContext adds a context to an error
-func AddErrorContext(err error, field, message string) error {
-	context := errorContext{
-		Field:   field,
-		Message: message,
+// AddDetails adds details to an error, e.g. error message from PowerDNS
+func AddDetails(err error, details string) error {
+	if pdnsErr, ok := err.(pdnsError); ok {
+		pdnsErr.context.Details = details
+		return pdnsErr
 	}
 
+	return pdnsError{
+		errorType:     NoType,
+		originalError: err,
+		context:       errorContext{Details: details},
+	}
+}
+
+// GetDetails returns details of an error
+func GetDetails(err error) string {
 	if pdnsErr, ok := err.(pdnsError); ok {
-		return pdnsError{
-			errorType:     pdnsErr.errorType,
-			originalError: pdnsErr.originalError,
-			context:       context,
-		}
+		return pdnsErr.context.Details
+	}
+
+	return ""
+}
+
+// AddFieldError adds an invalid field to an error
+func AddFieldError(err error, field, message string) error {
+	if pdnsErr, ok := err.(pdnsError); ok {
+		fields := make([]FieldError, len(pdnsErr.context.Fields), len(pdnsErr.context.Fields)+1)
+		copy(fields, pdnsErr.context.Fields)
+		pdnsErr.context.Fields = append(fields, FieldError{Field: field, Message: message})
+		return pdnsErr
 	}
 
 	return pdnsError{
 		errorType:     NoType,
 		originalError: err,
-		context:       context,
+		context:       errorContext{Fields: []FieldError{{Field: field, Message: message}}},
 	}
 }
 
-// GetErrorContext returns the error context
-func GetErrorContext(err error) map[string]string {
-	emptyContext := errorContext{}
-	if pdnsErr, ok := err.(pdnsError); ok || pdnsErr.context != emptyContext {
-		return map[string]string{
-			"field":   pdnsErr.context.Field,
-			"message": pdnsErr.context.Message,
-		}
+// GetFieldErrors returns invalid fields of an error
+func GetFieldErrors(err error) []FieldError {
+	if pdnsErr, ok := err.(pdnsError); ok {
+		return pdnsErr.context.Fields
 	}
 
 	return nil
 }
-*/
 
 // GetType returns the error type
 func GetType(err error) ErrorType {
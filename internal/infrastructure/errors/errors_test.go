@@ -0,0 +1,41 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mittwald/go-powerdns/pdnshttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapPDNS(t *testing.T) {
+	err := WrapPDNS(pdnshttp.ErrNotFound{URL: "/zones/example.com."}, "getting zone %s", "example.com.")
+	require.Equal(t, NotFound, GetType(err))
+
+	err = WrapPDNS(pdnshttp.ErrUnexpectedStatus{
+		StatusCode:  http.StatusUnprocessableEntity,
+		ErrResponse: pdnshttp.ErrResponse{Message: "RRset example.com. IN A: Conflicts with CNAME"},
+	}, "updating zone")
+	require.Equal(t, BadRequest, GetType(err))
+	require.Equal(t, "RRset example.com. IN A: Conflicts with CNAME", GetDetails(err))
+
+	err = WrapPDNS(pdnshttp.ErrUnexpectedStatus{StatusCode: http.StatusUnauthorized}, "listing zones")
+	require.Equal(t, UpstreamError, GetType(err))
+
+	// Type and details survive wrapping
+	err = Wrap(err, "handling request")
+	require.Equal(t, UpstreamError, GetType(err))
+}
+
+func TestFieldErrors(t *testing.T) {
+	err := BadRequest.New("invalid zone")
+	err = AddFieldError(err, "name", "must be canonical")
+	err = AddFieldError(err, "kind", "unknown kind")
+	err = Wrap(err, "creating zone")
+
+	require.Equal(t, BadRequest, GetType(err))
+	require.Equal(t, []FieldError{
+		{Field: "name", Message: "must be canonical"},
+		{Field: "kind", Message: "unknown kind"},
+	}, GetFieldErrors(err))
+}
@@ -0,0 +1,64 @@
+package errors
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mittwald/go-powerdns/pdnshttp"
+	"github.com/pkg/errors"
+)
+
+// WrapPDNS wraps an error returned by PowerDNS API client with type matched to PowerDNS response.
+// Error message from PowerDNS is added as details.
+func WrapPDNS(err error, msg string, args ...interface{}) error {
+	t, details := pdnsErrorType(errors.Cause(err))
+	wrapped := t.Wrapf(err, msg, args...)
+	if details != "" {
+		wrapped = AddDetails(wrapped, details)
+	}
+
+	return wrapped
+}
+
+func pdnsErrorType(err error) (ErrorType, string) {
+	switch e := err.(type) {
+	case pdnshttp.ErrNotFound, *pdnshttp.ErrNotFound:
+		return NotFound, ""
+	case pdnshttp.ErrUnexpectedStatus:
+		return pdnsStatusType(e.StatusCode), pdnsErrorDetails(e.ErrResponse)
+	case *pdnshttp.ErrUnexpectedStatus:
+		return pdnsStatusType(e.StatusCode), pdnsErrorDetails(e.ErrResponse)
+	case net.Error:
+		return Unavailable, ""
+	}
+	if err == context.DeadlineExceeded {
+		return Unavailable, ""
+	}
+
+	return UpstreamError, ""
+}
+
+func pdnsStatusType(status int) ErrorType {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return BadRequest
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return Unavailable
+	default:
+		// Including 401 and 403, it's pdns-api misconfiguration, not a client's fault
+		return UpstreamError
+	}
+}
+
+func pdnsErrorDetails(r pdnshttp.ErrResponse) string {
+	if len(r.Messages) == 0 {
+		return r.Message
+	}
+	return strings.Join(append([]string{r.Message}, r.Messages...), "; ")
+}
@@ -0,0 +1,59 @@
+// Package etag implements optimistic concurrency with ETag and If-Match headers.
+// PowerDNS has no conditional updates, so ETag is a hash of the object as returned by the API
+// and the check is done right before the change.
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// HTTP headers
+const (
+	Header        = "ETag"
+	IfMatchHeader = "If-Match"
+)
+
+// Compute returns a strong ETag of the object, a quoted hash of its JSON
+func Compute(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.Wrap(err, "computing ETag")
+	}
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// Requested reports whether the request has If-Match header
+func Requested(r *http.Request) bool {
+	return r.Header.Get(IfMatchHeader) != ""
+}
+
+// Check compares If-Match header of the request with the ETag of current object.
+// Current is empty if the object doesn't exist. Request without If-Match always passes.
+func Check(r *http.Request, current string) error {
+	return Match(r.Header.Get(IfMatchHeader), current)
+}
+
+// Match compares If-Match header value with the ETag of current object as RFC 7232 does:
+// * matches any existing object, weak ETags never match.
+func Match(ifMatch, current string) error {
+	if ifMatch == "" {
+		return nil
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if current != "" && (tag == "*" || tag == current) {
+			return nil
+		}
+	}
+	if current == "" {
+		return errors.PreconditionFailed.New("object doesn't exist, but If-Match header is set")
+	}
+	return errors.PreconditionFailed.Newf("object was changed, current ETag is %s", current)
+}
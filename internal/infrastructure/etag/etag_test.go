@@ -0,0 +1,49 @@
+package etag
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	a, err := Compute(map[string]int{"serial": 1})
+	require.NoError(t, err)
+	b, err := Compute(map[string]int{"serial": 1})
+	require.NoError(t, err)
+	c, err := Compute(map[string]int{"serial": 2})
+	require.NoError(t, err)
+
+	require.Equal(t, a, b)
+	require.NotEqual(t, a, c)
+	require.Len(t, a, 34)
+	require.Equal(t, byte('"'), a[0])
+}
+
+func TestMatch(t *testing.T) {
+	current := `"abc"`
+	require.NoError(t, Match("", current))
+	require.NoError(t, Match("", ""))
+	require.NoError(t, Match(`"abc"`, current))
+	require.NoError(t, Match(`"xyz", "abc"`, current))
+	require.NoError(t, Match("*", current))
+
+	for _, ifMatch := range []string{`"xyz"`, `W/"abc"`, "abc"} {
+		require.Equal(t, errors.PreconditionFailed, errors.GetType(Match(ifMatch, current)), ifMatch)
+	}
+	require.Equal(t, errors.PreconditionFailed, errors.GetType(Match("*", "")))
+	require.Equal(t, errors.PreconditionFailed, errors.GetType(Match(`"abc"`, "")))
+}
+
+func TestCheck(t *testing.T) {
+	r := httptest.NewRequest("PATCH", "/api/v1/servers/localhost/zones/example.com.", nil)
+	require.False(t, Requested(r))
+	require.NoError(t, Check(r, `"abc"`))
+
+	r.Header.Set(IfMatchHeader, `"abc"`)
+	require.True(t, Requested(r))
+	require.NoError(t, Check(r, `"abc"`))
+	require.Error(t, Check(r, `"xyz"`))
+}
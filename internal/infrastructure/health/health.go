@@ -0,0 +1,168 @@
+package health
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	pdnsApi "github.com/mittwald/go-powerdns"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/ldap"
+)
+
+// CheckTimeout limits duration of a single check.
+// Consul calls readiness endpoint with 1s timeout, so all checks must be finished before.
+const CheckTimeout = 800 * time.Millisecond
+
+// Statuses of checks and readiness report
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+	// StatusDegraded is a status of readiness report with optional checks down
+	StatusDegraded = "degraded"
+)
+
+// Check is a readiness check of a single dependency
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+	// Optional check being down degrades the service instead of making it not ready,
+	// e.g. LDAP isn't needed for reading requests
+	Optional bool
+}
+
+// Result is an outcome of a single check
+type Result struct {
+	Status string `json:"status"`
+	// Latency in milliseconds
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Report is an outcome of all readiness checks
+type Report struct {
+	Status   string            `json:"status"`
+	Hostname string            `json:"hostname"`
+	Checks   map[string]Result `json:"checks"`
+}
+
+// Ready reports whether all required checks are up
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// Run runs all checks concurrently. A check not finished in CheckTimeout is down,
+// even if it doesn't respect context cancellation.
+func Run(ctx context.Context, hostname string, checks []Check) Report {
+	report := Report{
+		Status:   StatusUp,
+		Hostname: hostname,
+		Checks:   make(map[string]Result, len(checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c Check) {
+			defer wg.Done()
+			res := run(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.Name] = res
+			switch {
+			case res.Status == StatusUp:
+			case !c.Optional:
+				report.Status = StatusDown
+			case report.Status == StatusUp:
+				report.Status = StatusDegraded
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func run(ctx context.Context, c Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	// Buffered, so the check goroutine doesn't leak after timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = errors.Newf("check timed out after %s", CheckTimeout)
+	}
+
+	res := Result{
+		Status:  StatusUp,
+		Latency: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// PowerDNS checks that PowerDNS API (authoritative or recursor) responds
+func PowerDNS(client pdnsApi.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := client.Servers().ListServers(ctx)
+		return err
+	}
+}
+
+// Consul checks that Consul agent is reachable and the cluster has a leader
+func Consul(client *api.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		leader, err := client.Status().Leader()
+		if err != nil {
+			return err
+		}
+		if leader == "" {
+			return errors.New("Consul cluster has no leader")
+		}
+		return nil
+	}
+}
+
+type ldapHealthChecker interface {
+	Health() ldap.Health
+}
+
+// LDAP checks that circuit breaker of LDAP connection pool is not open
+func LDAP(checker ldapHealthChecker) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		h := checker.Health()
+		if !h.Up {
+			return errors.Newf("LDAP is down (circuit breaker %s): %s", h.Breaker, h.Error)
+		}
+		return nil
+	}
+}
+
+// FileReadable checks that file can be opened for reading
+func FileReadable(path string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	}
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	checks := []Check{
+		{Name: "ok", Fn: func(ctx context.Context) error { return nil }},
+		{Name: "failed", Fn: func(ctx context.Context) error { return errors.New("boom") }},
+		// Ignores context, must be reported as down by timeout anyway
+		{Name: "slow", Fn: func(ctx context.Context) error { time.Sleep(2 * CheckTimeout); return nil }},
+	}
+
+	r := Run(context.Background(), "node", checks)
+	require.False(t, r.Ready())
+	require.Equal(t, StatusUp, r.Checks["ok"].Status)
+	require.Equal(t, StatusDown, r.Checks["failed"].Status)
+	require.Equal(t, "boom", r.Checks["failed"].Error)
+	require.Equal(t, StatusDown, r.Checks["slow"].Status)
+
+	r = Run(context.Background(), "node", checks[:1])
+	require.True(t, r.Ready())
+
+	// Optional check down degrades the node, required one makes it not ready anyway
+	optional := Check{Name: "optional", Fn: checks[1].Fn, Optional: true}
+	r = Run(context.Background(), "node", []Check{checks[0], optional})
+	require.True(t, r.Ready())
+	require.Equal(t, StatusDegraded, r.Status)
+	require.Equal(t, StatusDown, r.Checks["optional"].Status)
+	r = Run(context.Background(), "node", []Check{optional, checks[1]})
+	require.False(t, r.Ready())
+	require.Equal(t, StatusDown, r.Status)
+
+	// No checks, nothing to wait for
+	r = Run(context.Background(), "node", nil)
+	require.True(t, r.Ready())
+}
+
+func TestFileReadable(t *testing.T) {
+	check := FileReadable(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, check(context.Background()))
+}
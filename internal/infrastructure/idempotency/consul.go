@@ -0,0 +1,145 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// consulMaxValueSize is the largest value of Consul KV
+const consulMaxValueSize = 512 * 1024
+
+// consulStore keeps records in Consul KV, shared by all API nodes.
+// Consul KV has no expiration, so expired records are purged at most once per TTL.
+type consulStore struct {
+	consul *api.Client
+	prefix string
+	ttl    time.Duration
+	// inProgressTTL is a lease of records of requests in progress
+	inProgressTTL time.Duration
+
+	mu         sync.Mutex
+	lastPurged time.Time
+}
+
+func newConsulStore(consul *api.Client, prefix string, ttl, inProgressTTL time.Duration) *consulStore {
+	return &consulStore{consul: consul, prefix: prefix, ttl: ttl, inProgressTTL: inProgressTTL, lastPurged: time.Now()}
+}
+
+func (s *consulStore) Reserve(key string, rec *Record) (*Record, error) {
+	s.purge()
+
+	kv := s.consul.KV()
+	value, err := json.Marshal(rec)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding idempotency record")
+	}
+	p := &api.KVPair{Key: path.Join(s.prefix, key), Value: value}
+
+	pair, _, err := kv.Get(p.Key, nil)
+	if err != nil {
+		return nil, errors.Unavailable.Wrap(err, "reading idempotency record from Consul")
+	}
+	if pair != nil {
+		existing, err := decodeRecord(pair.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !existing.expired(s.ttl, s.inProgressTTL, time.Now()) {
+			return existing, nil
+		}
+		// Replace the expired record, including an abandoned reservation
+		p.ModifyIndex = pair.ModifyIndex
+	}
+
+	// Check-and-set with zero index creates the key only if it doesn't exist.
+	// Unlike CAS, transaction returns ModifyIndex of the reservation.
+	ok, resp, _, err := kv.Txn(api.KVTxnOps{{Verb: api.KVCAS, Key: p.Key, Value: p.Value, Index: p.ModifyIndex}}, nil)
+	if err != nil {
+		return nil, errors.Unavailable.Wrap(err, "writing idempotency record to Consul")
+	}
+	if ok && len(resp.Results) == 1 {
+		rec.Version = resp.Results[0].ModifyIndex
+		return nil, nil
+	}
+	// Another node has reserved the key right now
+	pair, _, err = kv.Get(p.Key, nil)
+	if err != nil {
+		return nil, errors.Unavailable.Wrap(err, "reading idempotency record from Consul")
+	}
+	if pair == nil {
+		return nil, errors.Conflict.New("idempotency key was released concurrently, retry the request")
+	}
+	return decodeRecord(pair.Value)
+}
+
+func (s *consulStore) Complete(key string, rec Record) error {
+	value, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "encoding idempotency record")
+	}
+	if len(value) > consulMaxValueSize {
+		return errors.Newf("idempotency record of %d bytes is larger than Consul KV limit", len(value))
+	}
+	// Check-and-set fails if the reservation has expired and another request replaced it
+	ok, _, err := s.consul.KV().CAS(&api.KVPair{Key: path.Join(s.prefix, key), Value: value, ModifyIndex: rec.Version}, nil)
+	if err != nil {
+		return errors.Unavailable.Wrap(err, "writing idempotency record to Consul")
+	}
+	if !ok {
+		return errors.Conflict.New("idempotency reservation has expired and was taken over")
+	}
+	return nil
+}
+
+func (s *consulStore) Release(key string, rec Record) error {
+	// Record of another request, which took over the expired reservation, is kept
+	_, _, err := s.consul.KV().DeleteCAS(&api.KVPair{Key: path.Join(s.prefix, key), ModifyIndex: rec.Version}, nil)
+	if err != nil {
+		return errors.Unavailable.Wrap(err, "deleting idempotency record from Consul")
+	}
+	return nil
+}
+
+// MaxBodySize leaves room for other fields of the record, the body is encoded with base64 in JSON
+func (s *consulStore) MaxBodySize() int {
+	return (consulMaxValueSize - 16*1024) / 4 * 3
+}
+
+// purge deletes expired records if TTL has passed since the last purge.
+// Failures are ignored, the next purge will retry.
+func (s *consulStore) purge() {
+	now := time.Now()
+	s.mu.Lock()
+	if now.Sub(s.lastPurged) < s.ttl {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPurged = now
+	s.mu.Unlock()
+
+	kv := s.consul.KV()
+	pairs, _, err := kv.List(s.prefix+"/", nil)
+	if err != nil {
+		return
+	}
+	for _, pair := range pairs {
+		rec, err := decodeRecord(pair.Value)
+		if err != nil || rec.expired(s.ttl, s.inProgressTTL, now) {
+			// Delete only if nobody has replaced the record since listing
+			_, _, _ = kv.DeleteCAS(pair, nil)
+		}
+	}
+}
+
+func decodeRecord(value []byte) (*Record, error) {
+	rec := new(Record)
+	if err := json.Unmarshal(value, rec); err != nil {
+		return nil, errors.Wrap(err, "decoding idempotency record")
+	}
+	return rec, nil
+}
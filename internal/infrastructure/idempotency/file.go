@@ -0,0 +1,111 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// fileStore keeps records in a local JSON file. It is not shared between nodes,
+// so it fits a single API node or clients sticky to a node.
+type fileStore struct {
+	path string
+	ttl  time.Duration
+	// inProgressTTL is a lease of records of requests in progress
+	inProgressTTL time.Duration
+
+	mu      sync.Mutex
+	records map[string]Record
+	// version is the last Version of reservations, versions are not saved to the file,
+	// so reservations made before restart can't be completed
+	version uint64
+}
+
+func newFileStore(path string, ttl, inProgressTTL time.Duration) (*fileStore, error) {
+	s := &fileStore{path: path, ttl: ttl, inProgressTTL: inProgressTTL, records: make(map[string]Record)}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading idempotency file")
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &s.records); err != nil {
+			return nil, errors.Wrap(err, "decoding idempotency file")
+		}
+	}
+	return s, nil
+}
+
+func (s *fileStore) Reserve(key string, rec *Record) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[key]; ok && !existing.expired(s.ttl, s.inProgressTTL, time.Now()) {
+		return &existing, nil
+	}
+	s.version++
+	rec.Version = s.version
+	s.records[key] = *rec
+	return nil, s.save()
+}
+
+func (s *fileStore) Complete(key string, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.records[key]; !ok || current.Version != rec.Version {
+		return errors.Conflict.New("idempotency reservation has expired and was taken over")
+	}
+	s.records[key] = rec
+	return s.save()
+}
+
+func (s *fileStore) Release(key string, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Record of another request, which took over the expired reservation, is kept
+	if current, ok := s.records[key]; !ok || current.Version != rec.Version {
+		return nil
+	}
+	delete(s.records, key)
+	return s.save()
+}
+
+// MaxBodySize is not limited by the file, the middleware has its own limit
+func (s *fileStore) MaxBodySize() int {
+	return math.MaxInt32
+}
+
+// save purges expired records and atomically replaces the file
+func (s *fileStore) save() error {
+	now := time.Now()
+	for key, rec := range s.records {
+		if rec.expired(s.ttl, s.inProgressTTL, now) {
+			delete(s.records, key)
+		}
+	}
+	b, err := json.Marshal(s.records)
+	if err != nil {
+		return errors.Wrap(err, "encoding idempotency file")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return errors.Wrap(err, "writing idempotency file")
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return errors.Wrap(err, "writing idempotency file")
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return errors.Wrap(err, "writing idempotency file")
+	}
+	return nil
+}
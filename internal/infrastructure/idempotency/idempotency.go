@@ -0,0 +1,92 @@
+// Package idempotency stores responses of requests with Idempotency-Key header,
+// so retried requests are replayed instead of being applied twice.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// Store types
+const (
+	StoreConsul = "consul"
+	StoreFile   = "file"
+)
+
+// Record is a request saved under an idempotency key and its response
+type Record struct {
+	// RequestHash detects reuse of the key for another request
+	RequestHash string    `json:"request_hash"`
+	Created     time.Time `json:"created"`
+	// Status is zero while the request is in progress
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+	// Version identifies the reservation in the store, it is set by Reserve. Complete and Release
+	// change the record only if it is still this reservation, not taken over after its lease expired.
+	Version uint64 `json:"-"`
+}
+
+// Done reports whether the response is saved
+func (r *Record) Done() bool {
+	return r.Status != 0
+}
+
+// expired reports whether the record is treated as absent. Records of requests in progress
+// expire after a short lease, so a request interrupted by a crash doesn't lock its key until TTL.
+func (r *Record) expired(ttl, inProgressTTL time.Duration, now time.Time) bool {
+	if !r.Done() {
+		return now.Sub(r.Created) > inProgressTTL
+	}
+	return now.Sub(r.Created) > ttl
+}
+
+// Store keeps records for the configured TTL, or in-progress TTL until the response is saved.
+// Expired records are treated as absent.
+type Store interface {
+	// Reserve saves the record and sets its Version if there is no record with the key yet,
+	// otherwise returns the existing record
+	Reserve(key string, rec *Record) (*Record, error)
+	// Complete saves the response of the reserved request, it is a conflict if the reservation
+	// was taken over
+	Complete(key string, rec Record) error
+	// Release removes the reserved record, so the request can be retried
+	Release(key string, rec Record) error
+	// MaxBodySize returns the size of the largest response body the store can save
+	MaxBodySize() int
+}
+
+// NewStore creates a store from config
+func NewStore(cfg config.Config, consul *api.Client) (Store, error) {
+	ttl := time.Duration(cfg.Idempotency.TTL) * time.Second
+	inProgressTTL := time.Duration(cfg.Idempotency.InProgressTTL) * time.Second
+	switch cfg.Idempotency.Store {
+	case StoreConsul:
+		return newConsulStore(consul, cfg.Idempotency.ConsulPrefix, ttl, inProgressTTL), nil
+	case StoreFile:
+		return newFileStore(cfg.Idempotency.Path, ttl, inProgressTTL)
+	default:
+		return nil, errors.Newf("unknown idempotency store %q", cfg.Idempotency.Store)
+	}
+}
+
+// Key returns a storage key for the client idempotency key.
+// Keys are scoped by user, so different users can't replay responses of each other.
+func Key(uid, key string) string {
+	sum := sha256.Sum256([]byte(uid + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestHash returns a hash of method, URL and body of the request
+func RequestHash(method, url string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + url + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,90 @@
+package idempotency
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lib", "idempotency.json")
+	var cfg config.Config
+	cfg.Idempotency = config.IdempotencyConfig{Store: StoreFile, TTL: 60, InProgressTTL: 10, Path: path}
+	store, err := NewStore(cfg, nil)
+	require.NoError(t, err)
+
+	key := Key("jdoe", "abc")
+	require.NotEqual(t, key, Key("alice", "abc"))
+
+	rec := Record{RequestHash: RequestHash(http.MethodPost, "/api/v1/servers/localhost/zones", []byte("{}")), Created: time.Now()}
+	existing, err := store.Reserve(key, &rec)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	existing, err = store.Reserve(key, &Record{Created: time.Now()})
+	require.NoError(t, err)
+	require.False(t, existing.Done())
+
+	rec.Status = http.StatusCreated
+	rec.Body = []byte(`{"name":"example.com."}`)
+	require.NoError(t, store.Complete(key, rec))
+
+	// Records survive restart
+	store, err = NewStore(cfg, nil)
+	require.NoError(t, err)
+	existing, err = store.Reserve(key, &Record{Created: time.Now()})
+	require.NoError(t, err)
+	require.True(t, existing.Done())
+	require.Equal(t, rec.RequestHash, existing.RequestHash)
+	require.Equal(t, rec.Body, existing.Body)
+
+	released := Key("jdoe", "released")
+	rec = Record{RequestHash: "x", Created: time.Now()}
+	_, err = store.Reserve(released, &rec)
+	require.NoError(t, err)
+	require.NoError(t, store.Release(released, rec))
+	existing, err = store.Reserve(released, &Record{RequestHash: "x", Created: time.Now()})
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	// Abandoned reservation is replaced after in-progress TTL
+	abandoned := Key("jdoe", "abandoned")
+	stale := Record{RequestHash: "x", Created: time.Now().Add(-time.Minute)}
+	_, err = store.Reserve(abandoned, &stale)
+	require.NoError(t, err)
+	newer := Record{RequestHash: "x", Created: time.Now()}
+	existing, err = store.Reserve(abandoned, &newer)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	// The abandoned request can't overwrite or release the reservation of the newer one
+	stale.Status = http.StatusCreated
+	err = store.Complete(abandoned, stale)
+	require.Equal(t, errors.Conflict, errors.GetType(err))
+	require.NoError(t, store.Release(abandoned, stale))
+	existing, err = store.Reserve(abandoned, &Record{RequestHash: "x", Created: time.Now()})
+	require.NoError(t, err)
+	require.False(t, existing.Done())
+	newer.Status = http.StatusCreated
+	require.NoError(t, store.Complete(abandoned, newer))
+
+	// Expired response is replaced
+	old := Key("jdoe", "old")
+	_, err = store.Reserve(old, &Record{RequestHash: "x", Created: time.Now().Add(-time.Hour), Status: http.StatusCreated})
+	require.NoError(t, err)
+	existing, err = store.Reserve(old, &Record{RequestHash: "y", Created: time.Now()})
+	require.NoError(t, err)
+	require.Nil(t, existing)
+}
+
+func TestNewStore(t *testing.T) {
+	var cfg config.Config
+	cfg.Idempotency.Store = "memcached"
+	_, err := NewStore(cfg, nil)
+	require.Error(t, err)
+}
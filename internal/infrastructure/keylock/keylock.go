@@ -0,0 +1,44 @@
+// Package keylock serializes operations on the same key, like read-modify-write of a zone,
+// within the process. Operations on different keys run concurrently.
+package keylock
+
+import "sync"
+
+// Locker is a set of mutexes by key. The zero value is not usable, use New.
+type Locker struct {
+	mu    sync.Mutex
+	locks map[string]*lock
+}
+
+type lock struct {
+	mu sync.Mutex
+	// refs is a number of holders and waiters, the lock is removed when it is zero
+	refs int
+}
+
+func New() *Locker {
+	return &Locker{locks: make(map[string]*lock)}
+}
+
+// Lock locks the key and returns the function unlocking it
+func (l *Locker) Lock(key string) (unlock func()) {
+	l.mu.Lock()
+	k, ok := l.locks[key]
+	if !ok {
+		k = new(lock)
+		l.locks[key] = k
+	}
+	k.refs++
+	l.mu.Unlock()
+
+	k.mu.Lock()
+	return func() {
+		k.mu.Unlock()
+		l.mu.Lock()
+		k.refs--
+		if k.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,34 @@
+package keylock
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocker(t *testing.T) {
+	l := New()
+	keys := []string{"example.com.", "example.org."}
+	counters := map[string]*int{"example.com.": new(int), "example.org.": new(int)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		for _, key := range keys {
+			wg.Add(1)
+			go func(key string) {
+				defer wg.Done()
+				unlock := l.Lock(key)
+				defer unlock()
+				// Unsynchronized read-modify-write is safe under the lock of the key
+				v := *counters[key]
+				*counters[key] = v + 1
+			}(key)
+		}
+	}
+	wg.Wait()
+
+	require.Equal(t, 100, *counters["example.com."])
+	require.Equal(t, 100, *counters["example.org."])
+	require.Empty(t, l.locks)
+}
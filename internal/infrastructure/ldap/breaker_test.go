@@ -0,0 +1,48 @@
+package ldap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	require.True(t, b.allow())
+	b.failure()
+	require.Equal(t, breakerClosed, b.State())
+	require.True(t, b.allow())
+	b.failure()
+	require.Equal(t, breakerOpen, b.State())
+	require.False(t, b.allow(), "open breaker must reject requests")
+
+	// Let only one probe through after timeout
+	now = now.Add(time.Minute)
+	require.True(t, b.allow())
+	require.Equal(t, breakerHalfOpen, b.State())
+	require.False(t, b.allow(), "half-open breaker must allow a single probe")
+
+	// Failed probe opens the breaker again
+	b.failure()
+	require.Equal(t, breakerOpen, b.State())
+	require.False(t, b.allow())
+
+	now = now.Add(time.Minute)
+	require.True(t, b.allow())
+	b.success()
+	require.Equal(t, breakerClosed, b.State())
+	require.True(t, b.allow())
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := newBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		b.failure()
+	}
+	require.True(t, b.allow())
+	require.Equal(t, breakerClosed, b.State())
+}
@@ -0,0 +1,132 @@
+package ldap
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+)
+
+// authKey identifies an authorization decision
+type authKey struct {
+	username string
+	cnType   string
+	zoneType string
+	zone     string
+}
+
+type authEntry struct {
+	allowed bool
+	expires time.Time
+}
+
+// authCache is a TTL cache of authorization decisions.
+// Allowed and denied decisions have separate TTLs.
+type authCache struct {
+	mu         sync.Mutex
+	entries    map[authKey]authEntry
+	allowTTL   time.Duration
+	denyTTL    time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+func newAuthCache(allowTTL, denyTTL time.Duration, maxEntries int) *authCache {
+	return &authCache{
+		entries:    make(map[authKey]authEntry),
+		allowTTL:   allowTTL,
+		denyTTL:    denyTTL,
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// get returns cached decision and true if it is found and not expired
+func (c *authCache) get(key authKey) (allowed bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return false, false
+	}
+
+	return e.allowed, true
+}
+
+// set stores decision with TTL depends on decision
+func (c *authCache) set(key authKey, allowed bool) {
+	ttl := c.denyTTL
+	if allowed {
+		ttl = c.allowTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+	c.entries[key] = authEntry{allowed: allowed, expires: c.now().Add(ttl)}
+}
+
+// evict removes expired entries, and any entry if cache is still full.
+// Must be called with mutex held.
+func (c *authCache) evict() {
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	for k := range c.entries {
+		if len(c.entries) < c.maxEntries {
+			break
+		}
+		delete(c.entries, k)
+	}
+}
+
+// invalidateZone removes all decisions for the zone, with or without trailing dot
+func (c *authCache) invalidateZone(zoneType, zone string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	zone = network.DeCanonicalize(zone)
+	for k := range c.entries {
+		if k.zoneType == zoneType && network.DeCanonicalize(k.zone) == zone {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// invalidateUser removes all decisions for the user, or all decisions if username is empty
+func (c *authCache) invalidateUser(username string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if username == "" {
+		c.entries = make(map[authKey]authEntry)
+		return
+	}
+	for k := range c.entries {
+		if k.username == username {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// len returns number of entries including expired but not evicted yet
+func (c *authCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
@@ -0,0 +1,59 @@
+package ldap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthCache(t *testing.T) {
+	now := time.Now()
+	c := newAuthCache(time.Minute, 10*time.Second, 0)
+	c.now = func() time.Time { return now }
+
+	allow := authKey{username: "alice", cnType: CNTypeReplace, zoneType: "zones", zone: "example.com."}
+	deny := authKey{username: "bob", cnType: CNTypeReplace, zoneType: "zones", zone: "example.com."}
+	c.set(allow, true)
+	c.set(deny, false)
+
+	allowed, ok := c.get(allow)
+	require.True(t, ok)
+	require.True(t, allowed)
+	allowed, ok = c.get(deny)
+	require.True(t, ok)
+	require.False(t, allowed)
+
+	// Denied decisions expire first
+	now = now.Add(10 * time.Second)
+	_, ok = c.get(deny)
+	require.False(t, ok)
+	_, ok = c.get(allow)
+	require.True(t, ok)
+
+	// Zone invalidation ignores trailing dot
+	c.invalidateZone("zones", "example.com")
+	_, ok = c.get(allow)
+	require.False(t, ok)
+
+	c.set(allow, true)
+	c.set(deny, true)
+	c.invalidateUser("alice")
+	_, ok = c.get(allow)
+	require.False(t, ok)
+	_, ok = c.get(deny)
+	require.True(t, ok)
+
+	c.invalidateUser("")
+	require.Equal(t, 0, c.len())
+}
+
+func TestAuthCacheMaxEntries(t *testing.T) {
+	c := newAuthCache(time.Minute, time.Minute, 2)
+	c.set(authKey{username: "a"}, true)
+	c.set(authKey{username: "b"}, true)
+	c.set(authKey{username: "c"}, true)
+	require.Equal(t, 2, c.len())
+	_, ok := c.get(authKey{username: "c"})
+	require.True(t, ok)
+}
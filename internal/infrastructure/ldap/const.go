@@ -3,4 +3,6 @@ package ldap
 const (
 	CNTypeReplace = "replace"
 	CNTypeDelete  = "delete"
+	// CNTypeRead allows reading of sensitive data, e.g. the audit log
+	CNTypeRead = "read"
 )
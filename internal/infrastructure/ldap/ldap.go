@@ -1,15 +1,27 @@
 package ldap
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"time"
 
 	"github.com/go-ldap/ldap"
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
 	log "github.com/mixanemca/pdns-api/internal/infrastructure/logger"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/stats"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/tracing"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -17,38 +29,168 @@ const (
 )
 
 type LDAPZoneAdder interface {
-	LDAPAddZone(zoneType, zone string) error
+	LDAPAddZone(ctx context.Context, zoneType, zone string) error
 }
 
 type LDAPZoneDeleter interface {
-	LDAPDelZone(zoneType, zone string) error
+	LDAPDelZone(ctx context.Context, zoneType, zone string) error
+}
+
+// Results of LDAP operations for metrics
+const (
+	resultOK          = "ok"
+	resultError       = "error"
+	resultUnavailable = "unavailable"
+	resultRejected    = "rejected"
+)
+
+var errPoolExhausted = errors.New("timed out waiting for a free LDAP connection")
+
+// Health represents state of LDAP connection for the health endpoint
+type Health struct {
+	Up        bool   `json:"up"`
+	Breaker   string `json:"breaker"`
+	PoolIdle  int    `json:"pool_idle"`
+	PoolInUse int    `json:"pool_in_use"`
+	Error     string `json:"error,omitempty"`
 }
 
 type ldapService struct {
-	logger     *logrus.Logger
-	config     config.Config
-	ldapClient *ldap.Conn
+	logger    *logrus.Logger
+	config    config.Config
+	stats     stats.LDAPStatsCollector
+	tlsConfig *tls.Config
+	pool      *pool
+	breaker   *breaker
+	// cache is nil if authorization cache is disabled
+	cache *authCache
 }
 
-func NewLDAPService(logger *logrus.Logger, config config.Config) (*ldapService, error) {
+func NewLDAPService(logger *logrus.Logger, config config.Config, stats stats.LDAPStatsCollector) (*ldapService, error) {
 	var err error
-	s := &ldapService{logger: logger, config: config}
+	s := &ldapService{logger: logger, config: config, stats: stats}
+	if config.LDAP.Cache.Enabled {
+		s.cache = newAuthCache(
+			time.Duration(config.LDAP.Cache.AllowTTL)*time.Second,
+			time.Duration(config.LDAP.Cache.DenyTTL)*time.Second,
+			config.LDAP.Cache.MaxEntries,
+		)
+	}
 	if viper.GetBool("ldap.enabled") {
 		err = s.LDAPInit()
 	}
 	return s, err
 }
 
-// LDAPInit connecting to LDAP server and performs a bind with the given username and password from config.
+// LDAPInit creates a pool of connections to LDAP server
+// and checks that server is reachable with the username and password from config.
 func (s *ldapService) LDAPInit() (err error) {
+	s.tlsConfig, err = newTLSConfig(s.config.LDAP)
+	if err != nil {
+		return err
+	}
+	s.breaker = newBreaker(s.config.LDAP.Breaker.Threshold, time.Duration(s.config.LDAP.Breaker.Timeout)*time.Second)
+	s.pool = newPool(s.config.LDAP.PoolSize, s.dial)
+
+	conn, err := s.pool.get(s.requestTimeout())
+	if err != nil {
+		s.stats.SetLDAPUp(s.config.Environment, network.GetHostname(), false)
+		return err
+	}
+	s.pool.put(conn, false)
+	s.stats.SetLDAPUp(s.config.Environment, network.GetHostname(), true)
+	s.updatePoolStats()
+
+	return nil
+}
+
+// Close closes all connections to LDAP server
+func (s *ldapService) Close() {
+	if s.pool != nil {
+		s.pool.close()
+	}
+}
+
+// Health returns state of the circuit breaker and the pool. It makes no requests to LDAP,
+// so frequent readiness probes don't load the server and don't count in the breaker.
+// LDAP is down while the breaker is open after failures of requests.
+func (s *ldapService) Health() Health {
+	if s.pool == nil {
+		return Health{Error: "LDAP is not initialized"}
+	}
+
+	state := s.breaker.State()
+	idle, inUse := s.pool.stats()
+	h := Health{
+		Up:        state != breakerOpen,
+		Breaker:   breakerStateString(state),
+		PoolIdle:  idle,
+		PoolInUse: inUse,
+	}
+	if !h.Up {
+		h.Error = "requests to LDAP are failing"
+	}
+
+	return h
+}
+
+// dial connects to the LDAP server from config, upgrades connection with StartTLS if required
+// and performs a bind with the given username and password.
+func (s *ldapService) dial() (*ldap.Conn, error) {
 	// connects to the given ldap URL vie TCP using tls.Dial or net.Dial if ldaps:// or ldap:// specified as protocol
 	s.logger.WithFields(logrus.Fields{
 		"action": log.ActionLDAPConnect,
 	}).Debugf("Connect to LDAP server %s", s.config.LDAP.URL)
 
-	s.ldapClient, err = ldap.DialURL(s.config.LDAP.URL)
+	u, err := url.Parse(s.config.LDAP.URL)
 	if err != nil {
-		return err
+		return nil, ldap.NewError(ldap.ErrorNetwork, err)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		// missing port
+		host = u.Host
+		port = ""
+	}
+
+	tlsConfig := s.tlsConfig.Clone()
+	tlsConfig.ServerName = host
+
+	dialer := &net.Dialer{Timeout: time.Duration(s.config.LDAP.Timeout.Dial) * time.Second}
+	var conn *ldap.Conn
+	switch u.Scheme {
+	case "ldap":
+		if port == "" {
+			port = ldap.DefaultLdapPort
+		}
+		c, err := dialer.Dial("tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			return nil, ldap.NewError(ldap.ErrorNetwork, err)
+		}
+		conn = ldap.NewConn(c, false)
+	case "ldaps":
+		if port == "" {
+			port = ldap.DefaultLdapsPort
+		}
+		c, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, port), tlsConfig)
+		if err != nil {
+			return nil, ldap.NewError(ldap.ErrorNetwork, err)
+		}
+		conn = ldap.NewConn(c, true)
+	default:
+		return nil, ldap.NewError(ldap.ErrorNetwork, fmt.Errorf("unknown scheme '%s'", u.Scheme))
+	}
+	conn.Start()
+	if s.config.LDAP.Debug {
+		conn.Debug = true
+	}
+	conn.SetTimeout(s.requestTimeout())
+
+	if s.config.LDAP.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
 	}
 
 	// performs a bind with the given username and password
@@ -56,24 +198,186 @@ func (s *ldapService) LDAPInit() (err error) {
 		"action": log.ActionLDAPConnect,
 	}).Debugf("LDAP bind with username %s", s.config.LDAP.User)
 
-	err = s.ldapClient.Bind(fmt.Sprintf("uid=%s,%s", s.config.LDAP.User, s.config.LDAP.BaseDN), s.config.LDAP.Password)
+	err = conn.Bind(fmt.Sprintf("uid=%s,%s", s.config.LDAP.User, s.config.LDAP.BaseDN), s.config.LDAP.Password)
 	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// do runs op on a pooled connection.
+// On network failures the connection is dropped and op is retried on a new one.
+func (s *ldapService) do(ctx context.Context, operation string, op func(conn *ldap.Conn) error) (err error) {
+	env, node := s.config.Environment, network.GetHostname()
+
+	_, span := tracing.Tracer().Start(ctx, "ldap."+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("net.peer.name", s.config.LDAP.URL)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	if s.pool == nil {
+		return errors.Unavailable.New("LDAP is not initialized")
+	}
+	if !s.breaker.allow() {
+		s.stats.CountLDAPRequest(env, node, operation, resultRejected)
+		return errors.Unavailable.Newf("LDAP circuit breaker is %s", breakerStateString(s.breaker.State()))
+	}
+
+	timer := s.stats.GetLDAPRequestTimer(env, node, operation)
+	defer timer.ObserveDuration()
+	defer s.updatePoolStats()
+
+	for attempt := 0; attempt <= s.config.LDAP.Retries; attempt++ {
+		if attempt > 0 {
+			s.logger.WithContext(ctx).WithFields(logrus.Fields{
+				"action": log.ActionLDAPConnect,
+			}).Warnf("LDAP %s failed, reconnecting (attempt %d): %v", operation, attempt, err)
+		}
+
+		var conn *ldap.Conn
+		conn, err = s.pool.get(s.requestTimeout())
+		if err == errPoolExhausted {
+			// Server is fine, we are just too busy
+			s.breaker.success()
+			s.stats.CountLDAPRequest(env, node, operation, resultRejected)
+			return errors.Unavailable.Wrapf(err, "LDAP %s", operation)
+		}
+		if isNetworkError(err) {
+			continue
+		}
+		if err != nil {
+			break
+		}
+
+		err = op(conn)
+		broken := isNetworkError(err)
+		s.pool.put(conn, broken)
+		if !broken {
+			break
+		}
+	}
+
+	if err != nil && (isNetworkError(err) || !isLDAPError(err)) {
+		s.breaker.failure()
+		s.stats.SetLDAPUp(env, node, false)
+		s.stats.SetLDAPBreakerState(env, node, s.breaker.State())
+		s.stats.CountLDAPRequest(env, node, operation, resultUnavailable)
+		return errors.Unavailable.Wrapf(err, "LDAP %s", operation)
+	}
+
+	s.breaker.success()
+	s.stats.SetLDAPUp(env, node, true)
+	s.stats.SetLDAPBreakerState(env, node, s.breaker.State())
+	if err != nil {
+		s.stats.CountLDAPRequest(env, node, operation, resultError)
 		return err
 	}
+	s.stats.CountLDAPRequest(env, node, operation, resultOK)
 
 	return nil
 }
 
+func (s *ldapService) requestTimeout() time.Duration {
+	return time.Duration(s.config.LDAP.Timeout.Request) * time.Second
+}
+
+func (s *ldapService) updatePoolStats() {
+	idle, inUse := s.pool.stats()
+	s.stats.SetLDAPPoolConnections(s.config.Environment, network.GetHostname(), "idle", idle)
+	s.stats.SetLDAPPoolConnections(s.config.Environment, network.GetHostname(), "in-use", inUse)
+}
+
+// newTLSConfig returns TLS config for ldaps:// and StartTLS connections
+func newTLSConfig(cfg config.LDAPConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := ioutil.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading LDAP CA file %s", cfg.CAFile)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Newf("no certificates found in LDAP CA file %s", cfg.CAFile)
+	}
+	tlsConfig.RootCAs = pool
+
+	return tlsConfig, nil
+}
+
+// isNetworkError reports whether err means that connection is unusable
+func isNetworkError(err error) bool {
+	if e, ok := err.(*ldap.Error); ok {
+		switch e.ResultCode {
+		case ldap.ErrorNetwork, ldap.LDAPResultServerDown, ldap.LDAPResultUnavailable, ldap.LDAPResultBusy:
+			return true
+		}
+	}
+	return false
+}
+
+// isLDAPError reports whether err is a result returned by LDAP server
+func isLDAPError(err error) bool {
+	_, ok := err.(*ldap.Error)
+	return ok
+}
+
 // AuthorizeViaLDAP doing search request to LDAP server.
 // Return bool value for search result or error.
-func (s *ldapService) AuthorizeViaLDAP(cnType, zoneType, zone, username string) (bool, error) {
-	// Reconnect to LDAP service is connection is clossed
-	if s.ldapClient.IsClosing() {
-		if err := s.LDAPInit(); err != nil {
-			return false, errors.Wrapf(err, "authorizing %s for %s %s (zone %s)", username, cnType, zoneType, zone)
-		}
+// Decisions are cached if authorization cache is enabled.
+func (s *ldapService) AuthorizeViaLDAP(ctx context.Context, cnType, zoneType, zone, username string) (bool, error) {
+	if s.cache == nil {
+		return s.authorize(ctx, cnType, zoneType, zone, username)
+	}
+
+	key := authKey{username: username, cnType: cnType, zoneType: zoneType, zone: zone}
+	if allowed, ok := s.cache.get(key); ok {
+		s.stats.CountAuthCache(s.config.Environment, network.GetHostname(), "hit")
+		return allowed, nil
+	}
+	s.stats.CountAuthCache(s.config.Environment, network.GetHostname(), "miss")
+
+	allowed, err := s.authorize(ctx, cnType, zoneType, zone, username)
+	if err != nil {
+		// Never cache errors
+		return false, err
 	}
+	s.cache.set(key, allowed)
+	s.stats.SetAuthCacheEntries(s.config.Environment, network.GetHostname(), s.cache.len())
 
+	return allowed, nil
+}
+
+// InvalidateAuthCache drops cached authorization decisions for the user,
+// or all decisions if username is empty. Should be called when ACL changes.
+func (s *ldapService) InvalidateAuthCache(username string) {
+	if s.cache == nil {
+		return
+	}
+	s.cache.invalidateUser(username)
+	s.stats.SetAuthCacheEntries(s.config.Environment, network.GetHostname(), s.cache.len())
+}
+
+func (s *ldapService) invalidateZone(zoneType, zone string) {
+	if s.cache == nil {
+		return
+	}
+	s.cache.invalidateZone(zoneType, zone)
+	s.stats.SetAuthCacheEntries(s.config.Environment, network.GetHostname(), s.cache.len())
+}
+
+func (s *ldapService) authorize(ctx context.Context, cnType, zoneType, zone, username string) (bool, error) {
 	// Makes a new search request
 	searchRequest := ldap.NewSearchRequest(
 		s.config.LDAP.SearchBase,
@@ -87,7 +391,11 @@ func (s *ldapService) AuthorizeViaLDAP(cnType, zoneType, zone, username string)
 		nil,
 	)
 
-	sr, err := s.ldapClient.Search(searchRequest)
+	var sr *ldap.SearchResult
+	err := s.do(ctx, "search", func(conn *ldap.Conn) (err error) {
+		sr, err = conn.Search(searchRequest)
+		return err
+	})
 	if err != nil {
 		return false, errors.Wrapf(err, "authorizing %s for %s %s (zone %s)", username, cnType, zoneType, zone)
 	}
@@ -103,16 +411,11 @@ func (s *ldapService) AuthorizeViaLDAP(cnType, zoneType, zone, username string)
 
 // LDAPAddZone creates LDAP Organizational Unit with zone name
 // and adds two Common Names (CN) for replace and delete checks.
-func (s *ldapService) LDAPAddZone(zoneType, zone string) error {
-	// Reconnect to LDAP service is connection is clossed
-	if s.ldapClient.IsClosing() {
-		if err := s.LDAPInit(); err != nil {
-			return errors.Wrapf(err, "adding %s zone to %s", zone, zoneType)
-		}
-	}
+func (s *ldapService) LDAPAddZone(ctx context.Context, zoneType, zone string) error {
+	defer s.invalidateZone(zoneType, zone)
 
 	dn := fmt.Sprintf("ou=%s,ou=%s,ou=dnsaas,ou=groups,%s", zone, zoneType, s.config.LDAP.SearchBase)
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"action": log.ActionLDAPAddZone,
 	}).Debugf("DN: %s", dn)
 
@@ -124,17 +427,17 @@ func (s *ldapService) LDAPAddZone(zoneType, zone string) error {
 	addOUReq.Attribute("objectClass", []string{"organizationalUnit", "top"})
 
 	// Do request
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"action": log.ActionLDAPAddZone,
 	}).Debugf("Add %s %s to LDAP", zoneType, network.DeCanonicalize(zone))
-	if err := s.ldapClient.Add(addOUReq); err != nil {
+	if err := s.do(ctx, "add", func(conn *ldap.Conn) error { return conn.Add(addOUReq) }); err != nil {
 		return errors.Wrapf(err, "adding %s zone to %s", zone, zoneType)
 	}
 
 	// Add CN's to new zone
-	if err := s.LDAPAddCN(zoneType, zone, CNTypeReplace, CNTypeDelete); err != nil {
+	if err := s.LDAPAddCN(ctx, zoneType, zone, CNTypeReplace, CNTypeDelete); err != nil {
 		// Cleanup
-		_ = s.LDAPDelZone(zoneType, zone)
+		_ = s.LDAPDelZone(ctx, zoneType, zone)
 		return errors.Wrapf(err, "adding %s zone to %s", zone, zoneType)
 	}
 
@@ -143,21 +446,16 @@ func (s *ldapService) LDAPAddZone(zoneType, zone string) error {
 
 // LDAPDelZone deletes LDAP Organizational Unit with zone name
 // add deletes Common Names (CN) for replace and delete checks
-func (s *ldapService) LDAPDelZone(zoneType, zone string) error {
-	// Reconnect to LDAP service is connection is clossed
-	if s.ldapClient.IsClosing() {
-		if err := s.LDAPInit(); err != nil {
-			return errors.Wrapf(err, "remove %s zone from %s", zone, zoneType)
-		}
-	}
+func (s *ldapService) LDAPDelZone(ctx context.Context, zoneType, zone string) error {
+	defer s.invalidateZone(zoneType, zone)
 
 	// Del CN's from zone
-	if err := s.LDAPDelCN(zoneType, zone, CNTypeReplace, CNTypeDelete); err != nil {
+	if err := s.LDAPDelCN(ctx, zoneType, zone, CNTypeReplace, CNTypeDelete); err != nil {
 		return errors.Wrapf(err, "remove %s zone from %s", zone, zoneType)
 	}
 
 	dn := fmt.Sprintf("ou=%s,ou=%s,ou=dnsaas,ou=groups,%s", zone, zoneType, s.config.LDAP.SearchBase)
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"action": log.ActionLDAPDelZone,
 	}).Debugf("DN: %s", dn)
 
@@ -167,10 +465,10 @@ func (s *ldapService) LDAPDelZone(zoneType, zone string) error {
 		[]ldap.Control{},
 	)
 	// Do request
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"action": log.ActionLDAPDelZone,
 	}).Debugf("Delete %s %s from LDAP", zoneType, zone)
-	if err := s.ldapClient.Del(delReq); err != nil {
+	if err := s.do(ctx, "delete", func(conn *ldap.Conn) error { return conn.Del(delReq) }); err != nil {
 		return errors.Wrapf(err, "remove %s zone from %s", zone, zoneType)
 	}
 
@@ -178,9 +476,9 @@ func (s *ldapService) LDAPDelZone(zoneType, zone string) error {
 }
 
 // LDAPAddCN added groupOfNames (delete, replace) to LDAP.
-func (s *ldapService) LDAPAddCN(zoneType, zone string, cnTypes ...string) error {
+func (s *ldapService) LDAPAddCN(ctx context.Context, zoneType, zone string, cnTypes ...string) error {
 	for _, t := range cnTypes {
-		if err := s.cnAdd(zoneType, zone, t); err != nil {
+		if err := s.cnAdd(ctx, zoneType, zone, t); err != nil {
 			return errors.Wrapf(err, "adding CN %s", t)
 		}
 	}
@@ -189,9 +487,9 @@ func (s *ldapService) LDAPAddCN(zoneType, zone string, cnTypes ...string) error
 }
 
 // LDAPDelCN added groupOfNames (delete, replace) to LDAP.
-func (s *ldapService) LDAPDelCN(zoneType, zone string, cnTypes ...string) error {
+func (s *ldapService) LDAPDelCN(ctx context.Context, zoneType, zone string, cnTypes ...string) error {
 	for _, t := range cnTypes {
-		if err := s.cnDel(zoneType, zone, t); err != nil {
+		if err := s.cnDel(ctx, zoneType, zone, t); err != nil {
 			return errors.Wrapf(err, "removing CN %s", t)
 		}
 	}
@@ -199,7 +497,7 @@ func (s *ldapService) LDAPDelCN(zoneType, zone string, cnTypes ...string) error
 	return nil
 }
 
-func (s *ldapService) cnAdd(zoneType, zone, cnType string) error {
+func (s *ldapService) cnAdd(ctx context.Context, zoneType, zone, cnType string) error {
 	// Makes a new add request.
 	addCNReq := ldap.NewAddRequest(
 		fmt.Sprintf("cn=%s,ou=%s,ou=%s,ou=dnsaas,ou=groups,%s", cnType, zone, zoneType, s.config.LDAP.SearchBase),
@@ -212,11 +510,11 @@ func (s *ldapService) cnAdd(zoneType, zone, cnType string) error {
 		fmt.Sprintf("uid=%s,%s", s.config.LDAP.User, s.config.LDAP.BaseDN),
 	})
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"action": log.ActionLDAPAddCN,
 	}).Debugf("Add CN %s to %s %s", cnType, zoneType, zone)
 	// Do request
-	if err := s.ldapClient.Add(addCNReq); err != nil {
+	if err := s.do(ctx, "add", func(conn *ldap.Conn) error { return conn.Add(addCNReq) }); err != nil {
 		if e, ok := err.(*ldap.Error); ok {
 			switch e.ResultCode {
 			case ldap.LDAPResultNoSuchObject:
@@ -232,18 +530,18 @@ func (s *ldapService) cnAdd(zoneType, zone, cnType string) error {
 	return nil
 }
 
-func (s *ldapService) cnDel(zoneType, zone, cnType string) error {
+func (s *ldapService) cnDel(ctx context.Context, zoneType, zone, cnType string) error {
 	// Makes a new delete request
 	delCNReq := ldap.NewDelRequest(
 		fmt.Sprintf("cn=%s,ou=%s,ou=%s,ou=dnsaas,ou=groups,%s", cnType, zone, zoneType, s.config.LDAP.SearchBase),
 		[]ldap.Control{},
 	)
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"action": log.ActionLDAPDelCN,
 	}).Debugf("Delete CN %s from %s %s", cnType, zone, zoneType)
 	// Do request
-	if err := s.ldapClient.Del(delCNReq); err != nil {
+	if err := s.do(ctx, "delete", func(conn *ldap.Conn) error { return conn.Del(delCNReq) }); err != nil {
 		if e, ok := err.(*ldap.Error); ok {
 			if e.ResultCode == ldap.LDAPResultNoSuchObject {
 				err = errors.NotFound.Wrapf(err, "can't remove CN %s", cnType)
@@ -0,0 +1,100 @@
+package ldap
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+)
+
+// pool is a bounded pool of bound LDAP connections.
+// The size bounds all connections: idle and in use.
+type pool struct {
+	mu     sync.Mutex
+	idle   []*ldap.Conn
+	slots  chan struct{}
+	dial   func() (*ldap.Conn, error)
+	closed bool
+}
+
+func newPool(size int, dial func() (*ldap.Conn, error)) *pool {
+	if size < 1 {
+		size = 1
+	}
+	return &pool{
+		slots: make(chan struct{}, size),
+		dial:  dial,
+	}
+}
+
+// get returns an idle connection or dials a new one.
+// It waits up to timeout for a free slot if the pool is exhausted.
+func (p *pool) get(timeout time.Duration) (*ldap.Conn, error) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-t.C:
+		return nil, errPoolExhausted
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.slots
+		return nil, errors.New("LDAP connection pool is closed")
+	}
+	for len(p.idle) > 0 {
+		conn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !conn.IsClosing() {
+			p.mu.Unlock()
+			return conn, nil
+		}
+		conn.Close()
+	}
+	p.mu.Unlock()
+
+	conn, err := p.dial()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// put returns connection to the pool. Broken connections are closed.
+func (p *pool) put(conn *ldap.Conn, broken bool) {
+	p.mu.Lock()
+	if broken || p.closed || conn.IsClosing() {
+		p.mu.Unlock()
+		conn.Close()
+	} else {
+		p.idle = append(p.idle, conn)
+		p.mu.Unlock()
+	}
+	<-p.slots
+}
+
+// close closes all idle connections, connections in use are closed on put
+func (p *pool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, conn := range p.idle {
+		conn.Close()
+	}
+	p.idle = nil
+}
+
+// stats returns number of idle and in use connections
+func (p *pool) stats() (idle, inUse int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.idle), len(p.slots)
+}
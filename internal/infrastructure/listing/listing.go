@@ -0,0 +1,256 @@
+// Package listing implements pagination, filtering and sorting of object lists.
+// PowerDNS API returns all objects at once, so pages are cut from the full list.
+package listing
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/network"
+)
+
+// MaxLimit is a maximum number of items in a page
+const MaxLimit = 1000
+
+// Fields of items available for filtering and sorting
+const (
+	FieldName = "name"
+	FieldKind = "kind"
+)
+
+// Response headers
+const (
+	TotalCountHeader = "X-Total-Count"
+	NextCursorHeader = "X-Next-Cursor"
+)
+
+// Params holds pagination, filtering and sorting parameters
+type Params struct {
+	// Limit is a page size, zero means all items
+	Limit int
+	// Cursor is an opaque position returned in NextCursor of the previous page
+	Cursor string
+	// Name is a glob pattern of item name, e.g. *.example.com
+	Name string
+	// Suffix matches item name and its subdomains
+	Suffix string
+	// Kinds matches any of the kinds, case-insensitive
+	Kinds []string
+	// Sort is a field to sort by, name by default
+	Sort string
+	Desc bool
+}
+
+// Item is a listed object. Handlers convert their objects to Items
+// and use indexes from the Page to pick objects back.
+type Item struct {
+	Name string
+	Kind string
+}
+
+// Page is a result of applying Params to items
+type Page struct {
+	// Indexes of items in the page, in sort order
+	Indexes []int
+	// Total is a number of items matching filters
+	Total int
+	// NextCursor is empty for the last page
+	NextCursor string
+}
+
+// ParseParams reads limit, cursor, name, suffix, kind and sort query parameters.
+// Fields are the item fields the listing supports, kind filter is read only if FieldKind is among them.
+// Sort is a field name, with minus prefix for descending order, like -name.
+func ParseParams(q url.Values, fields ...string) (Params, error) {
+	p := Params{
+		Cursor: q.Get("cursor"),
+		Name:   q.Get("name"),
+		Suffix: q.Get("suffix"),
+		Sort:   FieldName,
+	}
+	var err error
+
+	if limit := q.Get("limit"); limit != "" {
+		if p.Limit, err = strconv.Atoi(limit); err != nil || p.Limit < 1 || p.Limit > MaxLimit {
+			return p, errors.AddFieldError(
+				errors.BadRequest.Newf("bad 'limit' query parameter: %s", limit),
+				"limit", fmt.Sprintf("must be an integer from 1 to %d", MaxLimit),
+			)
+		}
+	}
+	if p.Name != "" {
+		if _, err := path.Match(p.Name, ""); err != nil {
+			return p, errors.AddFieldError(
+				errors.BadRequest.Newf("bad 'name' query parameter: %s", p.Name),
+				"name", "must be a glob pattern",
+			)
+		}
+	}
+	if hasField(fields, FieldKind) {
+		for _, kinds := range q["kind"] {
+			for _, kind := range strings.Split(kinds, ",") {
+				if kind != "" {
+					p.Kinds = append(p.Kinds, kind)
+				}
+			}
+		}
+	}
+	if s := q.Get("sort"); s != "" {
+		p.Desc = strings.HasPrefix(s, "-")
+		p.Sort = strings.TrimPrefix(s, "-")
+		if !hasField(fields, p.Sort) {
+			return p, errors.AddFieldError(
+				errors.BadRequest.Newf("bad 'sort' query parameter: %s", s),
+				"sort", fmt.Sprintf("must be one of %s, with optional minus prefix", strings.Join(fields, ", ")),
+			)
+		}
+	}
+	return p, nil
+}
+
+// Apply filters and sorts items and returns the page after the cursor
+func (p Params) Apply(items []Item) (Page, error) {
+	idx := make([]int, 0, len(items))
+	for i, item := range items {
+		if p.match(item) {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return p.less(p.key(items[idx[i]]), p.key(items[idx[j]]))
+	})
+
+	page := Page{Indexes: idx, Total: len(idx)}
+	if p.Cursor != "" {
+		after, err := p.decodeCursor()
+		if err != nil {
+			return page, err
+		}
+		start := sort.Search(len(idx), func(i int) bool {
+			return p.less(after, p.key(items[idx[i]]))
+		})
+		page.Indexes = idx[start:]
+	}
+	if p.Limit > 0 && len(page.Indexes) > p.Limit {
+		page.Indexes = page.Indexes[:p.Limit]
+		page.NextCursor = p.encodeCursor(p.key(items[page.Indexes[p.Limit-1]]))
+	}
+	return page, nil
+}
+
+// SetHeaders sets total count and, if there is a next page, its cursor and Link to it
+func (pg Page) SetHeaders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(TotalCountHeader, strconv.Itoa(pg.Total))
+	if pg.NextCursor == "" {
+		return
+	}
+	w.Header().Set(NextCursorHeader, pg.NextCursor)
+	q := r.URL.Query()
+	q.Set("cursor", pg.NextCursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.String()))
+}
+
+func (p Params) match(item Item) bool {
+	name := normalize(item.Name)
+	if p.Name != "" {
+		if ok, _ := path.Match(normalize(p.Name), name); !ok {
+			return false
+		}
+	}
+	if p.Suffix != "" {
+		suffix := normalize(p.Suffix)
+		if name != suffix && !strings.HasSuffix(name, "."+suffix) {
+			return false
+		}
+	}
+	if len(p.Kinds) > 0 {
+		matched := false
+		for _, kind := range p.Kinds {
+			if strings.EqualFold(kind, item.Kind) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// sortKey orders items by sort field, then by name
+type sortKey struct {
+	field string
+	name  string
+}
+
+func (p Params) key(item Item) sortKey {
+	k := sortKey{name: normalize(item.Name)}
+	if p.Sort == FieldKind {
+		k.field = item.Kind
+	}
+	return k
+}
+
+func (p Params) less(a, b sortKey) bool {
+	if p.Desc {
+		a, b = b, a
+	}
+	if a.field != b.field {
+		return a.field < b.field
+	}
+	return a.name < b.name
+}
+
+// Cursor is a sort order and a key of the last item of the previous page,
+// so pages stay consistent when items are added or removed between requests
+func (p Params) encodeCursor(k sortKey) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(p.sortParam() + "\x00" + k.field + "\x00" + k.name))
+}
+
+func (p Params) decodeCursor() (sortKey, error) {
+	b, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	parts := strings.Split(string(b), "\x00")
+	if err != nil || len(parts) != 3 {
+		return sortKey{}, errors.AddFieldError(
+			errors.BadRequest.New("bad 'cursor' query parameter"),
+			"cursor", "is malformed",
+		)
+	}
+	if parts[0] != p.sortParam() {
+		return sortKey{}, errors.AddFieldError(
+			errors.BadRequest.New("bad 'cursor' query parameter"),
+			"cursor", "was returned for another sort order",
+		)
+	}
+	return sortKey{field: parts[1], name: parts[2]}, nil
+}
+
+func (p Params) sortParam() string {
+	if p.Desc {
+		return "-" + p.Sort
+	}
+	return p.Sort
+}
+
+// normalize returns lower-case name without trailing dot
+func normalize(name string) string {
+	return strings.ToLower(network.DeCanonicalize(name))
+}
+
+func hasField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
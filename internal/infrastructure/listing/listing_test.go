@@ -0,0 +1,123 @@
+package listing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var items = []Item{
+	{Name: "b.example.com.", Kind: "Master"},
+	{Name: "example.com.", Kind: "Native"},
+	{Name: "a.example.com.", Kind: "Slave"},
+	{Name: "example.org.", Kind: "Native"},
+	{Name: "notexample.com.", Kind: "Master"},
+}
+
+func names(page Page) []string {
+	var res []string
+	for _, i := range page.Indexes {
+		res = append(res, items[i].Name)
+	}
+	return res
+}
+
+func apply(t *testing.T, query string, fields ...string) Page {
+	q, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	p, err := ParseParams(q, fields...)
+	require.NoError(t, err)
+	page, err := p.Apply(items)
+	require.NoError(t, err)
+	return page
+}
+
+func TestFilters(t *testing.T) {
+	page := apply(t, "", FieldName, FieldKind)
+	require.Equal(t, 5, page.Total)
+	require.Equal(t, []string{"a.example.com.", "b.example.com.", "example.com.", "example.org.", "notexample.com."}, names(page))
+
+	page = apply(t, "name=*.EXAMPLE.com", FieldName, FieldKind)
+	require.Equal(t, []string{"a.example.com.", "b.example.com."}, names(page))
+
+	page = apply(t, "suffix=example.com.", FieldName, FieldKind)
+	require.Equal(t, []string{"a.example.com.", "b.example.com.", "example.com."}, names(page))
+
+	page = apply(t, "kind=native&kind=slave", FieldName, FieldKind)
+	require.Equal(t, []string{"a.example.com.", "example.com.", "example.org."}, names(page))
+	require.Equal(t, 3, page.Total)
+
+	page = apply(t, "kind=Native,Slave&sort=-kind", FieldName, FieldKind)
+	require.Equal(t, []string{"a.example.com.", "example.org.", "example.com."}, names(page))
+
+	// Kind filter is ignored if listing has no kinds
+	page = apply(t, "kind=Native", FieldName)
+	require.Equal(t, 5, page.Total)
+}
+
+func TestPagination(t *testing.T) {
+	var got []string
+	query := "limit=2&sort=-name"
+	for i := 0; ; i++ {
+		require.Less(t, i, 3)
+		page := apply(t, query, FieldName, FieldKind)
+		require.Equal(t, 5, page.Total)
+		got = append(got, names(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		query = "limit=2&sort=-name&cursor=" + page.NextCursor
+	}
+	require.Equal(t, []string{"notexample.com.", "example.org.", "example.com.", "b.example.com.", "a.example.com."}, got)
+}
+
+func TestBadParams(t *testing.T) {
+	for _, query := range []string{"limit=0", "limit=abc", "limit=1001", "name=[", "sort=kind", "sort=type"} {
+		q, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		_, err = ParseParams(q, FieldName)
+		require.Error(t, err, query)
+		require.Equal(t, errors.BadRequest, errors.GetType(err), query)
+	}
+
+	page := apply(t, "limit=1&sort=name", FieldName)
+	p, err := ParseParams(url.Values{"sort": {"-name"}, "cursor": {page.NextCursor}}, FieldName)
+	require.NoError(t, err)
+	_, err = p.Apply(items)
+	require.Equal(t, errors.BadRequest, errors.GetType(err))
+
+	p, err = ParseParams(url.Values{"cursor": {"!!"}}, FieldName)
+	require.NoError(t, err)
+	_, err = p.Apply(items)
+	require.Equal(t, errors.BadRequest, errors.GetType(err))
+}
+
+func TestSetHeaders(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/servers/localhost/zones?limit=2&kind=Native", nil)
+	q := r.URL.Query()
+	p, err := ParseParams(q, FieldName, FieldKind)
+	require.NoError(t, err)
+	page, err := p.Apply(items)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	page.SetHeaders(w, r)
+	require.Equal(t, "2", w.Header().Get(TotalCountHeader))
+	require.Empty(t, w.Header().Get(NextCursorHeader))
+	require.Empty(t, w.Header().Get("Link"))
+
+	p.Limit = 1
+	page, err = p.Apply(items)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	page.SetHeaders(w, r)
+	require.NotEmpty(t, w.Header().Get(NextCursorHeader))
+	require.Equal(t,
+		"</api/v1/servers/localhost/zones?cursor="+page.NextCursor+"&kind=Native&limit=2>; rel=\"next\"",
+		w.Header().Get("Link"),
+	)
+}
@@ -1,24 +1,53 @@
 package logger
 
 const (
-	ActionSystem            = "system"
-	ActionServersList       = "servers list"
-	ActionServerList        = "server list"
-	ActionFlushCache        = "flush cache"
-	ActionZonesList         = "zones list"
-	ActionZoneList          = "zone list"
-	ActionZoneAdd           = "zone add"
-	ActionZoneUpdate        = "zone update"
-	ActionZoneDelete        = "zone delete"
-	ActionForwardZonesList  = "forward zones list"
-	ActionForwardZoneList   = "forward zone list"
-	ActionForwardZoneAdd    = "forward zone add"
-	ActionForwardZoneDelete = "forward zone delete"
-	ActionForwardZoneUpdate = "forward zone update"
-	ActionLDAPConnect       = "LDAP connect"
-	ActionLDAPAuthorization = "LDAP authorization"
-	ActionLDAPAddZone       = "LDAP add zone"
-	ActionLDAPDelZone       = "LDAP delete zone"
-	ActionLDAPAddCN         = "LDAP add CN"
-	ActionLDAPDelCN         = "LDAP delete CN"
+	ActionSystem             = "system"
+	ActionServersList        = "servers list"
+	ActionServerList         = "server list"
+	ActionSearchData         = "search data"
+	ActionVersion            = "version"
+	ActionFlushCache         = "flush cache"
+	ActionZonesList          = "zones list"
+	ActionZoneList           = "zone list"
+	ActionZoneAdd            = "zone add"
+	ActionZoneUpdate         = "zone update"
+	ActionZoneDelete         = "zone delete"
+	ActionZoneAxfrRetrieve   = "zone axfr-retrieve"
+	ActionZoneNotify         = "zone notify"
+	ActionZoneRectify        = "zone rectify"
+	ActionZoneTransfer       = "zone transfer"
+	ActionZoneConsistency    = "zone consistency"
+	ActionZoneVerify         = "zone verify"
+	ActionLookup             = "lookup"
+	ActionDoH                = "doh"
+	ActionDDNSUpdate         = "dns update"
+	ActionZoneTSIGKeysList   = "zone tsig keys list"
+	ActionZoneTSIGKeysUpdate = "zone tsig keys update"
+	ActionRRSetList          = "rrset list"
+	ActionRRSetUpdate        = "rrset update"
+	ActionRRSetDelete        = "rrset delete"
+	ActionACMEChallengeAdd   = "acme challenge add"
+	ActionACMEChallengeDel   = "acme challenge delete"
+	ActionForwardZonesList   = "forward zones list"
+	ActionForwardZoneList    = "forward zone list"
+	ActionForwardZoneAdd     = "forward zone add"
+	ActionForwardZoneDelete  = "forward zone delete"
+	ActionForwardZoneUpdate  = "forward zone update"
+	ActionTSIGKeysList       = "tsig keys list"
+	ActionTSIGKeyList        = "tsig key list"
+	ActionTSIGKeyAdd         = "tsig key add"
+	ActionTSIGKeyUpdate      = "tsig key update"
+	ActionTSIGKeyDelete      = "tsig key delete"
+	ActionLDAPConnect        = "LDAP connect"
+	ActionLDAPAuthorization  = "LDAP authorization"
+	ActionLDAPAddZone        = "LDAP add zone"
+	ActionLDAPDelZone        = "LDAP delete zone"
+	ActionLDAPAddCN          = "LDAP add CN"
+	ActionLDAPDelCN          = "LDAP delete CN"
+	ActionLDAPFlushCache     = "LDAP flush cache"
+	ActionAudit              = "audit"
+	ActionAuditList          = "audit list"
+	ActionRequestValidation  = "request validation"
+	ActionOpenAPI            = "openapi"
+	ActionIdempotency        = "idempotency"
 )
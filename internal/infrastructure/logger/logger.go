@@ -1,13 +1,17 @@
 package logger
 
 import (
-	"github.com/sirupsen/logrus"
 	"os"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/requestid"
+	"github.com/sirupsen/logrus"
 )
 
 func NewLogger(logFilePath string, logLevel string) *logrus.Logger {
 	var logger = logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
+	// Add request ID to entries created with WithContext
+	logger.AddHook(requestid.Hook{})
 	// default log output
 	logger.Out = os.Stdout
 	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
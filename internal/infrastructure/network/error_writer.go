@@ -1,15 +1,27 @@
 package network
 
 import (
-	"fmt"
+	"encoding/json"
 	"net/http"
 
 	"github.com/mixanemca/pdns-api/internal/app/config"
 	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/requestid"
 	statistic "github.com/mixanemca/pdns-api/internal/infrastructure/stats"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrorResponse is a JSON body of all error responses
+type ErrorResponse struct {
+	// Code is a machine readable error type, e.g. not_found
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details holds additional information, e.g. error message from PowerDNS
+	Details   string              `json:"details,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+	Fields    []errors.FieldError `json:"fields,omitempty"`
+}
+
 type errorWriter struct {
 	config config.Config
 	logger *logrus.Logger
@@ -20,29 +32,66 @@ func NewErrorWriter(config config.Config, logger *logrus.Logger, stats statistic
 	return &errorWriter{config: config, logger: logger, stats: stats}
 }
 
+// WriteError writes error response, logs and counts the error
 func (s *errorWriter) WriteError(w http.ResponseWriter, urlPath string, action string, err error) {
-	var status int
+	status := WriteErrorResponse(w, err)
+
+	fields := logrus.Fields{
+		"action": action,
+	}
+	// Request ID is set to response header by middleware
+	if requestID := w.Header().Get(requestid.Header); requestID != "" {
+		fields["request_id"] = requestID
+	}
+	if details := errors.GetDetails(err); details != "" {
+		fields["details"] = details
+	}
+	s.logger.WithFields(fields).Error(err.Error())
+
+	s.stats.CountError(s.config.Environment, GetHostname(), urlPath, status)
+}
 
+// WriteErrorResponse writes error as ErrorResponse with status by error type.
+// Returns written status.
+func WriteErrorResponse(w http.ResponseWriter, err error) int {
 	errorType := errors.GetType(err)
+	status := HTTPStatus(errorType)
+
+	resp := ErrorResponse{
+		Code:      errorType.String(),
+		Message:   err.Error(),
+		Details:   errors.GetDetails(err),
+		RequestID: w.Header().Get(requestid.Header),
+		Fields:    errors.GetFieldErrors(err),
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+
+	return status
+}
+
+// HTTPStatus returns HTTP response status for the error type
+func HTTPStatus(errorType errors.ErrorType) int {
 	switch errorType {
 	case errors.BadRequest:
-		status = http.StatusBadRequest
+		return http.StatusBadRequest
 	case errors.NotFound:
-		status = http.StatusNotFound
+		return http.StatusNotFound
 	case errors.Conflict:
-		status = http.StatusConflict
+		return http.StatusConflict
+	case errors.Unauthorized:
+		return http.StatusUnauthorized
+	case errors.Forbidden:
+		return http.StatusForbidden
+	case errors.Unavailable:
+		return http.StatusServiceUnavailable
+	case errors.UpstreamError:
+		return http.StatusBadGateway
+	case errors.PreconditionFailed:
+		return http.StatusPreconditionFailed
 	default:
-		status = http.StatusInternalServerError
+		return http.StatusInternalServerError
 	}
-
-	// Set response status
-	w.WriteHeader(status)
-	// Write error to response
-	fmt.Fprintf(w, "Error: %s\n", err.Error())
-
-	s.logger.WithFields(logrus.Fields{
-		"action": action,
-	}).Error(err.Error())
-
-	s.stats.CountError(s.config.Environment, GetHostname(), urlPath, status)
 }
@@ -0,0 +1,32 @@
+package network
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/requestid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteErrorResponse(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rr.Header().Set(requestid.Header, "abc")
+
+	err := errors.AddFieldError(errors.BadRequest.New("invalid zone"), "name", "is required")
+	status := WriteErrorResponse(rr, errors.Wrap(err, "creating zone"))
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Equal(t, "application/json;charset=utf-8", rr.Header().Get("Content-Type"))
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, ErrorResponse{
+		Code:      "bad_request",
+		Message:   "creating zone: invalid zone",
+		RequestID: "abc",
+		Fields:    []errors.FieldError{{Field: "name", Message: "is required"}},
+	}, resp)
+}
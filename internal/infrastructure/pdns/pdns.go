@@ -0,0 +1,111 @@
+// Package pdns implements PowerDNS Authoritative API endpoints missing in go-powerdns client.
+// Errors are the same as returned by go-powerdns, so they can be wrapped by errors.WrapPDNS.
+package pdns
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mittwald/go-powerdns/apis/zones"
+	"github.com/mittwald/go-powerdns/pdnshttp"
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/domain/tsigkey"
+)
+
+// Client of PowerDNS Authoritative API
+type Client struct {
+	http *pdnshttp.Client
+}
+
+// NewClient creates a client with base URL and API key of PowerDNS Authoritative from config
+func NewClient(cfg config.AuthConfig, httpClient *http.Client) *Client {
+	auth := &pdnshttp.APIKeyAuthenticator{APIKey: cfg.ApiKey}
+	return &Client{http: pdnshttp.NewClient(strings.TrimSuffix(cfg.BaseURL, "/"), httpClient, auth, ioutil.Discard)}
+}
+
+// Metadata is a zone metadata kind with its values
+type Metadata struct {
+	Kind     string   `json:"kind"`
+	Metadata []string `json:"metadata"`
+}
+
+// SetMetadata replaces values of the zone metadata kind
+func (c *Client) SetMetadata(ctx context.Context, serverID, zoneID, kind string, values []string) error {
+	path := fmt.Sprintf("/api/v1/servers/%s/zones/%s/metadata/%s", url.PathEscape(serverID), url.PathEscape(zoneID), url.PathEscape(kind))
+	return c.http.Put(ctx, path, nil, pdnshttp.WithJSONRequestBody(Metadata{Kind: kind, Metadata: values}))
+}
+
+// GetMetadata returns values of the zone metadata kind, empty if the kind is not set
+func (c *Client) GetMetadata(ctx context.Context, serverID, zoneID, kind string) ([]string, error) {
+	path := fmt.Sprintf("/api/v1/servers/%s/zones/%s/metadata/%s", url.PathEscape(serverID), url.PathEscape(zoneID), url.PathEscape(kind))
+	md := Metadata{}
+	if err := c.http.Get(ctx, path, &md); err != nil {
+		return nil, err
+	}
+	return md.Metadata, nil
+}
+
+// DeleteMetadata deletes the zone metadata kind
+func (c *Client) DeleteMetadata(ctx context.Context, serverID, zoneID, kind string) error {
+	path := fmt.Sprintf("/api/v1/servers/%s/zones/%s/metadata/%s", url.PathEscape(serverID), url.PathEscape(zoneID), url.PathEscape(kind))
+	return c.http.Delete(ctx, path, nil)
+}
+
+// PatchRRSets applies changes of RRsets to the zone in a single PATCH, PowerDNS applies all of them or none
+func (c *Client) PatchRRSets(ctx context.Context, serverID, zoneID string, rrsets []zones.ResourceRecordSet) error {
+	path := fmt.Sprintf("/api/v1/servers/%s/zones/%s", url.PathEscape(serverID), url.PathEscape(zoneID))
+	patch := struct {
+		RRSets []zones.ResourceRecordSet `json:"rrsets"`
+	}{RRSets: rrsets}
+	return c.http.Patch(ctx, path, nil, pdnshttp.WithJSONRequestBody(&patch))
+}
+
+// ListTSIGKeys returns TSIG keys of the server, PowerDNS omits secrets in the list
+func (c *Client) ListTSIGKeys(ctx context.Context, serverID string) ([]tsigkey.TSIGKey, error) {
+	keys := make([]tsigkey.TSIGKey, 0)
+	if err := c.http.Get(ctx, fmt.Sprintf("/api/v1/servers/%s/tsigkeys", url.PathEscape(serverID)), &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetTSIGKey returns the TSIG key with its secret
+func (c *Client) GetTSIGKey(ctx context.Context, serverID, keyID string) (*tsigkey.TSIGKey, error) {
+	key := new(tsigkey.TSIGKey)
+	if err := c.http.Get(ctx, tsigKeyPath(serverID, keyID), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// CreateTSIGKey creates the TSIG key, PowerDNS generates the secret if it is empty
+func (c *Client) CreateTSIGKey(ctx context.Context, serverID string, key tsigkey.TSIGKey) (*tsigkey.TSIGKey, error) {
+	created := new(tsigkey.TSIGKey)
+	path := fmt.Sprintf("/api/v1/servers/%s/tsigkeys", url.PathEscape(serverID))
+	if err := c.http.Post(ctx, path, created, pdnshttp.WithJSONRequestBody(key)); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateTSIGKey changes algorithm and secret of the TSIG key
+func (c *Client) UpdateTSIGKey(ctx context.Context, serverID, keyID string, key tsigkey.TSIGKey) (*tsigkey.TSIGKey, error) {
+	updated := new(tsigkey.TSIGKey)
+	if err := c.http.Put(ctx, tsigKeyPath(serverID, keyID), updated, pdnshttp.WithJSONRequestBody(key)); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteTSIGKey deletes the TSIG key
+func (c *Client) DeleteTSIGKey(ctx context.Context, serverID, keyID string) error {
+	return c.http.Delete(ctx, tsigKeyPath(serverID, keyID), nil)
+}
+
+func tsigKeyPath(serverID, keyID string) string {
+	return fmt.Sprintf("/api/v1/servers/%s/tsigkeys/%s", url.PathEscape(serverID), url.PathEscape(keyID))
+}
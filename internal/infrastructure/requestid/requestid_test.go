@@ -0,0 +1,43 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValid(t *testing.T) {
+	require.True(t, Valid(New()))
+	require.True(t, Valid("f81d4fae-7dec-11d0-a765-00a0c91e6bf6"))
+	require.False(t, Valid(""))
+	require.False(t, Valid("with space"))
+	require.False(t, Valid("new\nline"))
+	require.False(t, Valid(strings.Repeat("a", maxLength+1)))
+}
+
+func TestHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.Out = &buf
+	logger.AddHook(Hook{})
+
+	ctx := NewContext(context.Background(), "abc")
+	logger.WithContext(ctx).WithFields(logrus.Fields{"action": "test"}).Info("with ID")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "abc", entry["request_id"])
+	require.Equal(t, "test", entry["action"])
+
+	buf.Reset()
+	logger.Info("without ID")
+	entry = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.NotContains(t, entry, "request_id")
+}
@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LDAPStatsCollector collects metrics of LDAP connection pool and operations
+type LDAPStatsCollector interface {
+	SetLDAPUp(env, node string, up bool)
+	SetLDAPPoolConnections(env, node, state string, count int)
+	SetLDAPBreakerState(env, node string, state int)
+	CountLDAPRequest(env, node, operation, result string)
+	GetLDAPRequestTimer(env, node, operation string) *prometheus.Timer
+	CountAuthCache(env, node, result string)
+	SetAuthCacheEntries(env, node string, count int)
+}
+
+type LDAPStats struct {
+	upGauge          *prometheus.GaugeVec
+	poolGauge        *prometheus.GaugeVec
+	breakerGauge     *prometheus.GaugeVec
+	requestsCount    *prometheus.CounterVec
+	durationVec      *prometheus.HistogramVec
+	authCacheCount   *prometheus.CounterVec
+	authCacheEntries *prometheus.GaugeVec
+}
+
+func NewLDAPStats(
+	upGauge *prometheus.GaugeVec,
+	poolGauge *prometheus.GaugeVec,
+	breakerGauge *prometheus.GaugeVec,
+	requestsCount *prometheus.CounterVec,
+	durationVec *prometheus.HistogramVec,
+	authCacheCount *prometheus.CounterVec,
+	authCacheEntries *prometheus.GaugeVec,
+) *LDAPStats {
+	return &LDAPStats{
+		upGauge:          upGauge,
+		poolGauge:        poolGauge,
+		breakerGauge:     breakerGauge,
+		requestsCount:    requestsCount,
+		durationVec:      durationVec,
+		authCacheCount:   authCacheCount,
+		authCacheEntries: authCacheEntries,
+	}
+}
+
+// SetLDAPUp sets 1 if LDAP server is reachable, 0 otherwise
+func (p *LDAPStats) SetLDAPUp(env, node string, up bool) {
+	var v float64
+	if up {
+		v = 1
+	}
+	p.upGauge.WithLabelValues(env, node).Set(v)
+}
+
+// SetLDAPPoolConnections sets number of pooled connections in state (idle or in-use)
+func (p *LDAPStats) SetLDAPPoolConnections(env, node, state string, count int) {
+	p.poolGauge.WithLabelValues(env, node, state).Set(float64(count))
+}
+
+// SetLDAPBreakerState sets circuit breaker state (0 closed, 1 half-open, 2 open)
+func (p *LDAPStats) SetLDAPBreakerState(env, node string, state int) {
+	p.breakerGauge.WithLabelValues(env, node).Set(float64(state))
+}
+
+// CountLDAPRequest counts LDAP operations by result
+func (p *LDAPStats) CountLDAPRequest(env, node, operation, result string) {
+	p.requestsCount.WithLabelValues(env, node, operation, result).Inc()
+}
+
+func (p *LDAPStats) GetLDAPRequestTimer(env, node, operation string) *prometheus.Timer {
+	return prometheus.NewTimer(
+		p.durationVec.WithLabelValues(
+			env,
+			node,
+			operation,
+		),
+	)
+}
+
+// CountAuthCache counts authorization cache lookups by result (hit or miss)
+func (p *LDAPStats) CountAuthCache(env, node, result string) {
+	p.authCacheCount.WithLabelValues(env, node, result).Inc()
+}
+
+// SetAuthCacheEntries sets number of cached authorization decisions
+func (p *LDAPStats) SetAuthCacheEntries(env, node string, count int) {
+	p.authCacheEntries.WithLabelValues(env, node).Set(float64(count))
+}
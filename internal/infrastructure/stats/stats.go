@@ -13,6 +13,7 @@ type PrometheusStatsCollector interface {
 }
 
 type PrometheusStats struct {
+	*LDAPStats
 	gaugeVec      *prometheus.GaugeVec
 	counterVec    *prometheus.CounterVec
 	errorsCounter *prometheus.CounterVec
@@ -24,8 +25,9 @@ func NewPrometheusStats(
 	counterVec *prometheus.CounterVec,
 	errorsCounter *prometheus.CounterVec,
 	histogramVec *prometheus.HistogramVec,
+	ldapStats *LDAPStats,
 ) *PrometheusStats {
-	return &PrometheusStats{gaugeVec: gaugeVec, counterVec: counterVec, errorsCounter: errorsCounter, histogramVec: histogramVec}
+	return &PrometheusStats{gaugeVec: gaugeVec, counterVec: counterVec, errorsCounter: errorsCounter, histogramVec: histogramVec, LDAPStats: ldapStats}
 }
 
 func (p *PrometheusStats) CountCall(env, node, path, method string, status int) {
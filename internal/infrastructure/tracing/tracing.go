@@ -0,0 +1,131 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mixanemca/pdns-api/internal/app/config"
+	"github.com/mixanemca/pdns-api/internal/infrastructure/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ServiceName is a name of pdns-api in traces
+	ServiceName = "pdns-api"
+
+	instrumentationName = "github.com/mixanemca/pdns-api"
+
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Init configures global tracer provider and trace context propagation.
+// If tracing is disabled, spans are not recorded and shutdown does nothing.
+func Init(cfg config.Config) (shutdown func(ctx context.Context) error, err error) {
+	noop := func(ctx context.Context) error { return nil }
+	if !cfg.Tracing.Enabled {
+		return noop, nil
+	}
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+	)
+	switch cfg.Tracing.Exporter {
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Tracing.OTLP.Endpoint)}
+		if cfg.Tracing.OTLP.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		// Exporter connects lazily, so unavailable collector doesn't block start
+		exporter, err = otlptracehttp.New(context.Background(), opts...)
+		if err != nil {
+			return noop, errors.Wrap(err, "creating OTLP trace exporter")
+		}
+	case ExporterStdout:
+		var out io.Writer = os.Stdout
+		if cfg.Tracing.Stdout.Path != "" {
+			file, err := os.OpenFile(cfg.Tracing.Stdout.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+			if err != nil {
+				return noop, errors.Wrapf(err, "opening trace file %s", cfg.Tracing.Stdout.Path)
+			}
+			out, closer = file, file
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(out))
+		if err != nil {
+			return noop, errors.Wrap(err, "creating stdout trace exporter")
+		}
+	default:
+		return noop, errors.Newf("unknown trace exporter %q", cfg.Tracing.Exporter)
+	}
+
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(ServiceName),
+		semconv.ServiceVersionKey.String(cfg.Version),
+		semconv.DeploymentEnvironmentKey.String(cfg.Environment),
+		attribute.String("pdns_api.role", cfg.Role),
+	)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return func(ctx context.Context) error {
+		// Flush remaining spans
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns pdns-api tracer from global tracer provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// NewTransport wraps base transport to create client spans and inject trace context into requests
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
+
+// NewHTTPClient returns HTTP client with traced default transport, e.g. for PowerDNS API clients
+func NewHTTPClient() *http.Client {
+	return &http.Client{Transport: NewTransport(http.DefaultTransport)}
+}
+
+// Detach returns a context with values of ctx (trace span, request ID and others)
+// but without its deadline and cancellation. Used to finish changes on PowerDNS
+// even if client went away, and still keep them in the same trace.
+func Detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
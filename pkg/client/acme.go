@@ -0,0 +1,55 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AddACMEChallenge adds the value to ACME DNS-01 challenge TXT records of the domain in its closest
+// authoritative zone and waits until all healthy nodes serve it or the timeout expires, checks are done once
+// if timeout is zero. ctx and HTTP client timeout (30 seconds by default) should allow for the wait.
+func (c *Client) AddACMEChallenge(ctx context.Context, domain, value string, timeout time.Duration) (*ACMEChallenge, error) {
+	body := struct {
+		Name    string `json:"name"`
+		Value   string `json:"value"`
+		Timeout int    `json:"timeout"`
+	}{Name: domain, Value: value, Timeout: int(timeout / time.Second)}
+	challenge := new(ACMEChallenge)
+	if err := c.do(ctx, http.MethodPost, apiPath("acme", "challenge"), nil, body, challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// DeleteACMEChallenge removes the value, or all values if it is empty, from ACME DNS-01 challenge TXT records
+// of the domain and waits like AddACMEChallenge. Removing absent values is not an error.
+func (c *Client) DeleteACMEChallenge(ctx context.Context, domain, value string, timeout time.Duration) (*ACMEChallenge, error) {
+	q := url.Values{"name": {domain}, "timeout": {strconv.Itoa(int(timeout / time.Second))}}
+	if value != "" {
+		q.Set("value", value)
+	}
+	challenge := new(ACMEChallenge)
+	if err := c.do(ctx, http.MethodDelete, apiPath("acme", "challenge"), q, nil, challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
@@ -0,0 +1,284 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client is a Go client for pdns-api.
+//
+//	c, err := client.New(
+//		client.WithBaseURL("http://pdns-api.example.com:8080"),
+//		client.WithClientUID("jdoe"),
+//	)
+//	zone, err := c.GetZone(ctx, "localhost", "example.com")
+//	if client.GetType(err) == client.NotFound {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Defaults for retries of idempotent requests
+const (
+	DefaultRetries   = 2
+	DefaultRetryWait = 500 * time.Millisecond
+)
+
+// ClientUIDHeader is a header with user ID for authorization via LDAP
+const ClientUIDHeader = "X-PDNS-Client-UID"
+
+// RequestIDHeader is a header with request ID, returned in errors and written to pdns-api logs
+const RequestIDHeader = "X-Request-ID"
+
+// IdempotencyKeyHeader is a header with a key that makes retries of POST and PATCH requests safe
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Client is a pdns-api client. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	clientUID  string
+	headers    http.Header
+	retries    int
+	retryWait  time.Duration
+	// idempotencyKeys enables Idempotency-Key header for POST and PATCH requests
+	idempotencyKeys bool
+}
+
+// ClientOption configures the Client
+type ClientOption func(c *Client) error
+
+// New creates a new Client. WithBaseURL is required.
+func New(opts ...ClientOption) (*Client, error) {
+	c := &Client{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		headers:    make(http.Header),
+		retries:    DefaultRetries,
+		retryWait:  DefaultRetryWait,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	if c.baseURL == nil {
+		return nil, fmt.Errorf("base URL is required")
+	}
+	return c, nil
+}
+
+// WithBaseURL sets URL of pdns-api, like http://pdns-api.example.com:8080
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+		if err != nil {
+			return fmt.Errorf("parsing base URL: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("base URL %q must have scheme and host", baseURL)
+		}
+		c.baseURL = u
+		return nil
+	}
+}
+
+// WithHTTPClient sets HTTP client, e.g. with TLS settings or tracing transport
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) error {
+		c.httpClient = hc
+		return nil
+	}
+}
+
+// WithClientUID sets user ID sent in X-PDNS-Client-UID header.
+// It is required by mutating requests if pdns-api authorizes users via LDAP.
+func WithClientUID(uid string) ClientOption {
+	return func(c *Client) error {
+		c.clientUID = uid
+		return nil
+	}
+}
+
+// WithHeader adds a header to every request, e.g. for authenticating proxy
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) error {
+		c.headers.Add(key, value)
+		return nil
+	}
+}
+
+// WithRetries sets number of retries and initial wait between them.
+// The wait is doubled after every attempt. Only idempotent requests (GET, PUT, DELETE,
+// or POST and PATCH with WithIdempotencyKeys) failed with network error, 502, 503 or 504 are retried. Zero retries disables retrying.
+func WithRetries(retries int, wait time.Duration) ClientOption {
+	return func(c *Client) error {
+		if retries < 0 {
+			return fmt.Errorf("retries must not be negative")
+		}
+		c.retries = retries
+		c.retryWait = wait
+		return nil
+	}
+}
+
+// WithIdempotencyKeys sends a random Idempotency-Key with every POST and PATCH request,
+// so they are retried like idempotent requests. pdns-api replays the saved response of a retry.
+func WithIdempotencyKeys() ClientOption {
+	return func(c *Client) error {
+		c.idempotencyKeys = true
+		return nil
+	}
+}
+
+// do sends request with JSON body from in and decodes JSON response to out.
+// in and out may be nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("encoding request body: %w", err)
+		}
+	}
+
+	u := c.baseURL.String() + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	// The same key is sent with all attempts
+	var key string
+	if c.idempotencyKeys && (method == http.MethodPost || method == http.MethodPatch) {
+		key = newIdempotencyKey()
+	}
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, u, key, body)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			defer resp.Body.Close()
+			if out == nil || resp.StatusCode == http.StatusNoContent {
+				return nil
+			}
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("decoding response body: %w", err)
+			}
+			return nil
+		}
+		if err == nil {
+			err = newResponseError(resp)
+			resp.Body.Close()
+		}
+
+		if attempt >= c.retries || !retryable(method, key != "", err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, url, idempotencyKey string, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.clientUID != "" {
+		req.Header.Set(ClientUIDHeader, c.clientUID)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
+	return c.httpClient.Do(req)
+}
+
+func newResponseError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(b, e); err != nil || e.Type == "" {
+		// Not a pdns-api response, e.g. from proxy
+		e.Type = errorTypeFromStatus(resp.StatusCode)
+		e.Message = strings.TrimSpace(string(b))
+		if e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+	if e.RequestID == "" {
+		e.RequestID = resp.Header.Get(RequestIDHeader)
+	}
+	return e
+}
+
+// retryable reports whether request may be retried. Requests with Idempotency-Key are idempotent.
+func retryable(method string, withKey bool, err error) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		if !withKey {
+			return false
+		}
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		switch e.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// Network errors, but not cancellation by caller
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// apiPath returns path of API v1 with escaped segments, so zone names are sent as is
+func apiPath(segments ...string) string {
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return "/api/v1/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,366 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mixanemca/pdns-api/pkg/client"
+	"github.com/mixanemca/pdns-api/pkg/client/clienttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZones(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.RequireClientUID = true
+	ctx := context.Background()
+
+	_, err := srv.Client().CreateZone(ctx, clienttest.ServerID, client.Zone{Name: "example.com", Kind: client.ZoneKindNative})
+	require.Equal(t, client.Unauthorized, client.GetType(err))
+
+	c := srv.Client(client.WithClientUID("jdoe"))
+	created, err := c.CreateZone(ctx, clienttest.ServerID, client.Zone{Name: "example.com", Kind: client.ZoneKindNative})
+	require.NoError(t, err)
+	require.Equal(t, "example.com.", created.Name)
+
+	_, err = c.CreateZone(ctx, clienttest.ServerID, client.Zone{Name: "example.com.", Kind: client.ZoneKindNative})
+	require.Equal(t, client.Conflict, client.GetType(err))
+
+	www := client.RRSet{Name: "www.example.com.", Type: "A", TTL: 300, Records: []client.Record{{Content: "192.0.2.1"}}}
+	require.NoError(t, c.ReplaceRRSet(ctx, clienttest.ServerID, "example.com", www))
+	zone, err := c.GetZone(ctx, clienttest.ServerID, "example.com.")
+	require.NoError(t, err)
+	require.Equal(t, []client.RRSet{www}, zone.RRSets)
+
+	got, err := c.GetRRSet(ctx, clienttest.ServerID, "example.com", "www.example.com", "a")
+	require.NoError(t, err)
+	require.Equal(t, www, *got)
+
+	txt := client.RRSet{Name: "@", Type: "TXT", TTL: 300, Records: []client.Record{{Content: `"v=spf1 -all"`}}}
+	got, err = c.PutRRSet(ctx, clienttest.ServerID, "example.com", txt)
+	require.NoError(t, err)
+	require.Equal(t, "example.com.", got.Name)
+	_, err = c.PutRRSet(ctx, clienttest.ServerID, "example.com", txt)
+	require.NoError(t, err)
+	zone, err = c.GetZone(ctx, clienttest.ServerID, "example.com.")
+	require.NoError(t, err)
+	require.Len(t, zone.RRSets, 2)
+	require.NoError(t, c.DeleteRRSet(ctx, clienttest.ServerID, "example.com", "example.com.", "TXT"))
+
+	results, err := c.Search(ctx, clienttest.ServerID, "www.*", 0, client.ObjectTypeRecord)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "192.0.2.1", results[0].Content)
+
+	require.NoError(t, c.DeleteRRSet(ctx, clienttest.ServerID, "example.com", "www.example.com.", "A"))
+	require.NoError(t, c.FlushCache(ctx, clienttest.ServerID, "www.example.com"))
+	require.Equal(t, []string{"www.example.com."}, srv.Flushed())
+
+	require.NoError(t, c.DeleteZone(ctx, clienttest.ServerID, "example.com"))
+	_, err = c.GetZone(ctx, clienttest.ServerID, "example.com")
+	require.Equal(t, client.NotFound, client.GetType(err))
+	_, ok := srv.Zone("example.com")
+	require.False(t, ok)
+}
+
+func TestCreateZoneFromTemplate(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.AddZoneTemplate("internal", client.Zone{
+		Kind:   client.ZoneKindMaster,
+		RRSets: []client.RRSet{{Name: "@", Type: "TXT", TTL: 300, Records: []client.Record{{Content: `"baseline"`}}}},
+	})
+	c := srv.Client()
+	ctx := context.Background()
+
+	created, err := c.CreateZoneFromTemplate(ctx, clienttest.ServerID, "internal", client.Zone{Name: "example.com"})
+	require.NoError(t, err)
+	require.Equal(t, client.ZoneKindMaster, created.Kind)
+	require.Equal(t, "example.com.", created.RRSets[0].Name)
+
+	_, err = c.CreateZoneFromTemplate(ctx, clienttest.ServerID, "external", client.Zone{Name: "example.org"})
+	require.Equal(t, client.BadRequest, client.GetType(err))
+}
+
+func TestZoneActions(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	_, err := c.CreateZone(ctx, clienttest.ServerID, client.Zone{Name: "example.com", Kind: client.ZoneKindSlave})
+	require.Equal(t, client.BadRequest, client.GetType(err))
+	srv.AddTSIGKey(client.TSIGKey{Name: "transfer", Algorithm: "hmac-sha256", Key: "c2VjcmV0"})
+	_, err = c.CreateZone(ctx, clienttest.ServerID, client.Zone{
+		Name:            "example.com",
+		Kind:            client.ZoneKindSlave,
+		Masters:         []string{"192.0.2.1"},
+		SlaveTSIGKeyIDs: []string{"transfer"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.AxfrRetrieve(ctx, clienttest.ServerID, "example.com"))
+	require.Equal(t, client.BadRequest, client.GetType(c.NotifyZone(ctx, clienttest.ServerID, "example.com")))
+	require.Equal(t, client.BadRequest, client.GetType(c.RectifyZone(ctx, clienttest.ServerID, "example.com")))
+	require.Equal(t, client.NotFound, client.GetType(c.AxfrRetrieve(ctx, clienttest.ServerID, "example.org")))
+}
+
+func TestTSIGKeys(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	created, err := c.CreateTSIGKey(ctx, clienttest.ServerID, client.TSIGKey{Name: "transfer", Algorithm: "hmac-sha512"})
+	require.NoError(t, err)
+	require.Equal(t, "transfer.", created.ID)
+	require.NotEmpty(t, created.Key)
+	_, err = c.CreateTSIGKey(ctx, clienttest.ServerID, client.TSIGKey{Name: "transfer"})
+	require.Equal(t, client.Conflict, client.GetType(err))
+
+	// Secrets are not returned by listings
+	keys, err := c.ListTSIGKeys(ctx, clienttest.ServerID)
+	require.NoError(t, err)
+	require.Equal(t, []client.TSIGKey{{ID: "transfer.", Name: "transfer.", Algorithm: "hmac-sha512"}}, keys)
+	key, err := c.GetTSIGKey(ctx, clienttest.ServerID, created.ID)
+	require.NoError(t, err)
+	require.Empty(t, key.Key)
+
+	updated, err := c.UpdateTSIGKey(ctx, clienttest.ServerID, created.ID, client.TSIGKey{Algorithm: "hmac-sha256", Key: "c2VjcmV0"})
+	require.NoError(t, err)
+	require.Equal(t, "c2VjcmV0", updated.Key)
+
+	srv.AddZone(client.Zone{Name: "example.com", Kind: client.ZoneKindMaster})
+	_, err = c.SetZoneTSIGKeys(ctx, clienttest.ServerID, "example.com", client.ZoneTSIGKeys{MasterTSIGKeyIDs: []string{"unknown"}})
+	require.Equal(t, client.BadRequest, client.GetType(err))
+	_, err = c.SetZoneTSIGKeys(ctx, clienttest.ServerID, "example.com", client.ZoneTSIGKeys{MasterTSIGKeyIDs: []string{"transfer"}})
+	require.NoError(t, err)
+	zoneKeys, err := c.GetZoneTSIGKeys(ctx, clienttest.ServerID, "example.com")
+	require.NoError(t, err)
+	require.Equal(t, &client.ZoneTSIGKeys{MasterTSIGKeyIDs: []string{"transfer"}, SlaveTSIGKeyIDs: []string{}}, zoneKeys)
+
+	require.NoError(t, c.DeleteTSIGKey(ctx, clienttest.ServerID, created.ID))
+	require.Equal(t, client.NotFound, client.GetType(c.DeleteTSIGKey(ctx, clienttest.ServerID, created.ID)))
+}
+
+func TestTransferZone(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.AddZone(client.Zone{Name: "example.com", Kind: client.ZoneKindNative, Serial: 3, RRSets: []client.RRSet{
+		{Name: "www.example.com.", Type: "A", TTL: 60, Records: []client.Record{{Content: "192.0.2.1"}}},
+		{Name: "example.com.", Type: "NS", TTL: 3600, Records: []client.Record{{Content: "ns1.example.com."}}},
+	}})
+	c := srv.Client()
+	ctx := context.Background()
+
+	transfer, err := c.TransferZone(ctx, clienttest.ServerID, "example.com", "")
+	require.NoError(t, err)
+	require.Equal(t, transfer.APISerial, transfer.Serial)
+	require.Equal(t, "example.com.", transfer.RRSets[0].Name)
+
+	_, err = c.TransferZone(ctx, clienttest.ServerID, "example.com", "unknown")
+	require.Equal(t, client.NotFound, client.GetType(err))
+}
+
+func TestGetZoneConsistency(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.AddZone(client.Zone{Name: "example.com.", Kind: client.ZoneKindNative, Serial: 5})
+	c := srv.Client()
+	ctx := context.Background()
+
+	consistency, err := c.GetZoneConsistency(ctx, clienttest.ServerID, "example.com", false)
+	require.NoError(t, err)
+	require.True(t, consistency.Consistent)
+	require.Equal(t, 5, consistency.Serial)
+	require.Empty(t, consistency.RRSetsHash)
+	require.Equal(t, clienttest.NodeName, consistency.Nodes[0].Node)
+
+	consistency, err = c.GetZoneConsistency(ctx, clienttest.ServerID, "example.com", true)
+	require.NoError(t, err)
+	require.NotEmpty(t, consistency.Nodes[0].RRSetsHash)
+
+	consistency, err = c.GetZoneConsistency(ctx, clienttest.ServerID, "unknown.com", false)
+	require.NoError(t, err)
+	require.False(t, consistency.Consistent)
+	require.False(t, consistency.Nodes[0].InSync)
+}
+
+func TestVerifyZone(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.AddZone(client.Zone{Name: "example.com.", Kind: client.ZoneKindNative, RRSets: []client.RRSet{
+		{Name: "www.example.com.", Type: "A", TTL: 60, Records: []client.Record{{Content: "192.0.2.1"}, {Content: "192.0.2.2"}}},
+	}})
+	c := srv.Client()
+	ctx := context.Background()
+
+	verification, err := c.VerifyZone(ctx, clienttest.ServerID, "example.com", []client.ExpectedRRSet{
+		{Name: "www.example.com.", Type: "A", Records: []string{"192.0.2.2", "192.0.2.1"}},
+		{Name: "old.example.com.", Type: "CNAME"},
+	}, 0)
+	require.NoError(t, err)
+	require.True(t, verification.Converged)
+	require.Len(t, verification.Nodes[0].Checks, 4)
+
+	verification, err = c.VerifyZone(ctx, clienttest.ServerID, "example.com", []client.ExpectedRRSet{
+		{Name: "www.example.com.", Type: "A", Records: []string{"192.0.2.3"}},
+	}, 10*time.Second)
+	require.NoError(t, err)
+	require.False(t, verification.Converged)
+	require.Equal(t, []string{"192.0.2.1", "192.0.2.2"}, verification.Nodes[0].Checks[0].Records)
+}
+
+func TestACMEChallenge(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.AddZone(client.Zone{Name: "example.com.", Kind: client.ZoneKindNative})
+	srv.AddZone(client.Zone{Name: "sub.example.com.", Kind: client.ZoneKindNative})
+	c := srv.Client()
+	ctx := context.Background()
+
+	challenge, err := c.AddACMEChallenge(ctx, "*.www.sub.example.com", "first", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "_acme-challenge.www.sub.example.com.", challenge.Name)
+	require.Equal(t, "sub.example.com.", challenge.Zone)
+	require.True(t, challenge.Visible)
+	_, err = c.AddACMEChallenge(ctx, "www.sub.example.com", "second", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []string{"_acme-challenge.www.sub.example.com.", "_acme-challenge.www.sub.example.com."}, srv.Flushed())
+
+	challenge, err = c.DeleteACMEChallenge(ctx, "www.sub.example.com", "first", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"second"}, challenge.Values)
+	challenge, err = c.DeleteACMEChallenge(ctx, "www.sub.example.com", "", 0)
+	require.NoError(t, err)
+	require.Empty(t, challenge.Values)
+	z, _ := srv.Zone("sub.example.com")
+	require.Empty(t, z.RRSets)
+
+	_, err = c.AddACMEChallenge(ctx, "example.org", "first", 0)
+	require.Equal(t, client.NotFound, client.GetType(err))
+}
+
+func TestLookup(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.AddZone(client.Zone{Name: "example.com.", Kind: client.ZoneKindNative, RRSets: []client.RRSet{
+		{Name: "www.example.com.", Type: "A", TTL: 60, Records: []client.Record{{Content: "192.0.2.1"}}},
+	}})
+	c := srv.Client()
+	ctx := context.Background()
+
+	lookup, err := c.Lookup(ctx, "www.example.com", "", "")
+	require.NoError(t, err)
+	require.Equal(t, "A", lookup.Type)
+	require.Len(t, lookup.Results, 2)
+	require.Equal(t, client.ServerAuth, lookup.Results[0].Server)
+	require.True(t, lookup.Results[0].Answer.Flags.Authoritative)
+	require.Equal(t, []client.DNSRecord{{Name: "www.example.com.", Type: "A", TTL: 60, Content: "192.0.2.1"}}, lookup.Results[1].Answer.Answer)
+
+	lookup, err = c.Lookup(ctx, "mail.example.com.", "MX", client.ServerRecursor)
+	require.NoError(t, err)
+	require.Len(t, lookup.Results, 1)
+	require.Equal(t, "NXDOMAIN", lookup.Results[0].Answer.Rcode)
+
+	_, err = c.Lookup(ctx, "www.example.com.", "A", "google")
+	require.Equal(t, client.BadRequest, client.GetType(err))
+}
+
+func TestForwardZones(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	fz := client.ForwardZone{Name: "corp.example.", Nameservers: []string{"10.0.0.1"}}
+	require.NoError(t, c.CreateForwardZones(ctx, clienttest.ServerID, fz))
+	fz.Nameservers = []string{"10.0.0.2:5353"}
+	require.NoError(t, c.UpdateForwardZone(ctx, clienttest.ServerID, fz))
+
+	fzs, err := c.ListForwardZones(ctx, clienttest.ServerID)
+	require.NoError(t, err)
+	require.Equal(t, []client.ForwardZone{fz}, fzs)
+
+	require.NoError(t, c.DeleteForwardZones(ctx, clienttest.ServerID, fz))
+	err = c.DeleteForwardZone(ctx, clienttest.ServerID, fz.Name)
+	require.Equal(t, client.NotFound, client.GetType(err))
+}
+
+func TestError(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.FailNext(&client.Error{
+		StatusCode: http.StatusBadRequest,
+		Type:       client.BadRequest,
+		Message:    "request doesn't match API specification",
+		RequestID:  "abc",
+		Fields:     []client.FieldError{{Field: "q", Message: "is required"}},
+	})
+
+	_, err := srv.Client().Search(context.Background(), clienttest.ServerID, "", 0, "")
+	require.EqualError(t, err, "pdns-api: 400 request doesn't match API specification; q: is required [request_id abc]")
+	e, ok := err.(*client.Error)
+	require.True(t, ok)
+	require.Equal(t, []client.FieldError{{Field: "q", Message: "is required"}}, e.Fields)
+}
+
+func TestRetries(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			// Not a pdns-api response, e.g. from load balancer
+			http.Error(w, "no healthy upstream", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"id":"localhost"}]`))
+	}))
+	defer ts.Close()
+
+	c, err := client.New(client.WithBaseURL(ts.URL), client.WithRetries(2, time.Millisecond))
+	require.NoError(t, err)
+	servers, err := c.ListServers(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []client.Server{{ID: "localhost"}}, servers)
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+
+	// Not idempotent requests are not retried
+	atomic.StoreInt32(&calls, 0)
+	_, err = c.CreateZone(context.Background(), "localhost", client.Zone{Name: "example.com."})
+	require.Equal(t, client.Unavailable, client.GetType(err))
+	require.EqualError(t, err, "pdns-api: 503 no healthy upstream")
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	var calls int32
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(client.IdempotencyKeyHeader))
+		if atomic.AddInt32(&calls, 1) < 2 {
+			http.Error(w, "no healthy upstream", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"name":"example.com."}`))
+	}))
+	defer ts.Close()
+
+	c, err := client.New(client.WithBaseURL(ts.URL), client.WithRetries(2, time.Millisecond), client.WithIdempotencyKeys())
+	require.NoError(t, err)
+	_, err = c.CreateZone(context.Background(), "localhost", client.Zone{Name: "example.com."})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	// Retry has the same key
+	require.Len(t, keys, 2)
+	require.NotEmpty(t, keys[0])
+	require.Equal(t, keys[0], keys[1])
+
+	// DELETE is idempotent without a key
+	require.NoError(t, c.DeleteZone(context.Background(), "localhost", "example.com."))
+	require.Empty(t, keys[2])
+}
@@ -0,0 +1,1150 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clienttest provides an in-memory fake of pdns-api for unit tests of client users.
+//
+//	srv := clienttest.NewServer()
+//	defer srv.Close()
+//	srv.AddZone(client.Zone{Name: "example.com.", Kind: client.ZoneKindNative})
+//	c := srv.Client()
+package clienttest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/mixanemca/pdns-api/pkg/client"
+)
+
+// ServerID is the only PowerDNS server ID known by the fake
+const ServerID = "localhost"
+
+// NodeName is the only pdns-api node of the fake
+const NodeName = "clienttest"
+
+// Server is a fake pdns-api. Zones and forward zones are kept in memory,
+// names are stored canonical, with trailing dot.
+type Server struct {
+	*httptest.Server
+	// RequireClientUID makes mutating requests without X-PDNS-Client-UID fail with 401,
+	// like pdns-api with LDAP authorization
+	RequireClientUID bool
+
+	mu           sync.Mutex
+	zones        map[string]client.Zone
+	forwardZones map[string]client.ForwardZone
+	templates    map[string]client.Zone
+	tsigKeys     map[string]client.TSIGKey
+	zoneTSIGKeys map[string]client.ZoneTSIGKeys
+	flushed      []string
+	failures     []*client.Error
+}
+
+// NewServer starts a new fake pdns-api. Caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		zones:        make(map[string]client.Zone),
+		forwardZones: make(map[string]client.ForwardZone),
+		templates:    make(map[string]client.Zone),
+		tsigKeys:     make(map[string]client.TSIGKey),
+		zoneTSIGKeys: make(map[string]client.ZoneTSIGKeys),
+	}
+
+	r := mux.NewRouter()
+	r.Use(s.middleware)
+	r.HandleFunc("/api/v1/servers", s.listServers).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}", s.getServer).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/search-data", s.search).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/lookup", s.lookup).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/cache/flush", s.flushCache).Methods(http.MethodPut)
+	r.HandleFunc("/api/v1/acme/challenge", s.addACMEChallenge).Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/acme/challenge", s.deleteACMEChallenge).Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones", s.listZones).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones", s.createZone).Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}", s.getZone).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}", s.patchZone).Methods(http.MethodPatch)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}", s.deleteZone).Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/{action:axfr-retrieve|notify|rectify}", s.zoneAction).Methods(http.MethodPut)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/axfr", s.transferZone).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/consistency", s.zoneConsistency).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/verify", s.verifyZone).Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/tsigkeys", s.getZoneTSIGKeys).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/tsigkeys", s.setZoneTSIGKeys).Methods(http.MethodPut)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/rrsets/{name}/{type}", s.getRRSet).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/zones/{zoneID}/rrsets/{name}/{type}", s.putRRSet).Methods(http.MethodPut)
+	r.HandleFunc("/api/v1/servers/{serverID}/tsigkeys", s.listTSIGKeys).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/tsigkeys", s.createTSIGKey).Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/servers/{serverID}/tsigkeys/{keyID}", s.getTSIGKey).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/tsigkeys/{keyID}", s.updateTSIGKey).Methods(http.MethodPut)
+	r.HandleFunc("/api/v1/servers/{serverID}/tsigkeys/{keyID}", s.deleteTSIGKey).Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/servers/{serverID}/forward-zones", s.listForwardZones).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/forward-zones", s.createForwardZones).Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/servers/{serverID}/forward-zones", s.deleteForwardZones).Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/servers/{serverID}/forward-zones/{zoneID}", s.getForwardZone).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/servers/{serverID}/forward-zones/{zoneID}", s.updateForwardZone).Methods(http.MethodPatch)
+	r.HandleFunc("/api/v1/servers/{serverID}/forward-zones/{zoneID}", s.deleteForwardZone).Methods(http.MethodDelete)
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, client.NotFound, "route not found")
+	})
+
+	s.Server = httptest.NewServer(r)
+	return s
+}
+
+// Client returns a client of the fake without retries. opts are applied after defaults.
+func (s *Server) Client(opts ...client.ClientOption) *client.Client {
+	opts = append([]client.ClientOption{
+		client.WithBaseURL(s.URL),
+		client.WithHTTPClient(s.Server.Client()),
+		client.WithRetries(0, 0),
+	}, opts...)
+	c, err := client.New(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// AddZone adds zone as is, without any checks
+func (s *Server) AddZone(z client.Zone) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z.Name = canonicalize(z.Name)
+	z.ID = z.Name
+	s.zones[z.Name] = z
+}
+
+// AddZoneTemplate adds zone template by name. Kind and RRSets of the template zone
+// are used by zones created from the template, RRSet names are relative to the zone.
+func (s *Server) AddZoneTemplate(name string, z client.Zone) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.templates[strings.ToLower(name)] = z
+}
+
+// Zone returns zone by name with or without trailing dot
+func (s *Server) Zone(name string) (client.Zone, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z, ok := s.zones[canonicalize(name)]
+	return z, ok
+}
+
+// AddTSIGKey adds TSIG key as is, without any checks
+func (s *Server) AddTSIGKey(key client.TSIGKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key.Name = canonicalize(key.Name)
+	key.ID = key.Name
+	s.tsigKeys[key.Name] = key
+}
+
+// TSIGKey returns TSIG key with secret by name with or without trailing dot
+func (s *Server) TSIGKey(name string) (client.TSIGKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.tsigKeys[canonicalize(name)]
+	return key, ok
+}
+
+// AddForwardZone adds forward zone as is, without any checks
+func (s *Server) AddForwardZone(fz client.ForwardZone) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fz.Name = canonicalize(fz.Name)
+	s.forwardZones[fz.Name] = fz
+}
+
+// ForwardZone returns forward zone by name with or without trailing dot
+func (s *Server) ForwardZone(name string) (client.ForwardZone, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fz, ok := s.forwardZones[canonicalize(name)]
+	return fz, ok
+}
+
+// Flushed returns names flushed from cache, in order of requests
+func (s *Server) Flushed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.flushed...)
+}
+
+// FailNext makes the next request fail with the error. Calls are queued,
+// so the first error fails the first request, the second one the second, and so on.
+// StatusCode of the error is required.
+func (s *Server) FailNext(err *client.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, err)
+}
+
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		var failure *client.Error
+		if len(s.failures) > 0 {
+			failure, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+
+		if failure != nil {
+			writeJSON(w, failure.StatusCode, failure)
+			return
+		}
+		if s.RequireClientUID && r.Method != http.MethodGet && r.Method != http.MethodPut && r.Header.Get(client.ClientUIDHeader) == "" {
+			writeError(w, http.StatusUnauthorized, client.Unauthorized, "X-PDNS-Client-UID header is required")
+			return
+		}
+		if id, ok := mux.Vars(r)["serverID"]; ok && id != ServerID {
+			writeError(w, http.StatusNotFound, client.NotFound, "server "+id+" not found")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listServers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []client.Server{server()})
+}
+
+func (s *Server) getServer(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, server())
+}
+
+// lookup answers from zones in memory, both servers answer the same, without timing
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	name := strings.ToLower(canonicalize(q.Get("name")))
+	if name == "" {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "name is required")
+		return
+	}
+	rrType := strings.ToUpper(q.Get("type"))
+	if rrType == "" {
+		rrType = "A"
+	}
+	var servers []string
+	switch q.Get("server") {
+	case client.ServerAuth:
+		servers = []string{client.ServerAuth}
+	case client.ServerRecursor:
+		servers = []string{client.ServerRecursor}
+	case "", client.LookupBoth:
+		servers = []string{client.ServerAuth, client.ServerRecursor}
+	default:
+		writeError(w, http.StatusBadRequest, client.BadRequest, "unknown server "+q.Get("server"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rcode := "NXDOMAIN"
+	answer := []client.DNSRecord{}
+	for _, z := range s.zones {
+		for _, rrset := range z.RRSets {
+			if strings.ToLower(canonicalize(rrset.Name)) != name {
+				continue
+			}
+			rcode = "NOERROR"
+			if rrset.Type != rrType {
+				continue
+			}
+			for _, record := range rrset.Records {
+				if !record.Disabled {
+					answer = append(answer, client.DNSRecord{Name: name, Type: rrType, TTL: rrset.TTL, Content: record.Content})
+				}
+			}
+		}
+	}
+	lookup := client.Lookup{Name: name, Type: rrType, Results: []client.LookupResult{}}
+	for _, server := range servers {
+		recursion := server == client.ServerRecursor
+		lookup.Results = append(lookup.Results, client.LookupResult{Server: server, Answer: &client.DNSAnswer{
+			Server:     "127.0.0.1:53",
+			Rcode:      rcode,
+			Flags:      client.DNSFlags{Authoritative: !recursion, RecursionDesired: recursion, RecursionAvailable: recursion},
+			Question:   []client.DNSRecord{{Name: name, Type: rrType}},
+			Answer:     answer,
+			Authority:  []client.DNSRecord{},
+			Additional: []client.DNSRecord{},
+		}})
+	}
+	writeJSON(w, http.StatusOK, lookup)
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.ToLower(q.Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "not enough query parameters")
+		return
+	}
+	max := 10
+	if v := q.Get("max"); v != "" {
+		var err error
+		if max, err = strconv.Atoi(v); err != nil {
+			writeError(w, http.StatusBadRequest, client.BadRequest, "bad 'max' query parameter")
+			return
+		}
+	}
+	objectType := client.ObjectType(q.Get("object_type"))
+	if objectType == "" {
+		objectType = client.ObjectTypeAll
+	}
+	// Query matches names with or without trailing dot
+	match := func(name string) bool {
+		name = strings.ToLower(name)
+		for _, n := range []string{name, strings.TrimSuffix(name, ".")} {
+			if ok, _ := path.Match(query, n); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]client.SearchResult, 0)
+	for _, name := range s.zoneNames() {
+		z := s.zones[name]
+		if (objectType == client.ObjectTypeAll || objectType == client.ObjectTypeZone) && match(z.Name) {
+			results = append(results, client.SearchResult{Name: z.Name, ObjectType: client.ObjectTypeZone, ZoneID: z.ID, Zone: z.Name})
+		}
+		if objectType != client.ObjectTypeAll && objectType != client.ObjectTypeRecord {
+			continue
+		}
+		for _, rrset := range z.RRSets {
+			if !match(rrset.Name) {
+				continue
+			}
+			for _, rec := range rrset.Records {
+				results = append(results, client.SearchResult{
+					Content:    rec.Content,
+					Disabled:   rec.Disabled,
+					Name:       rrset.Name,
+					ObjectType: client.ObjectTypeRecord,
+					ZoneID:     z.ID,
+					Zone:       z.Name,
+					Type:       rrset.Type,
+					TTL:        rrset.TTL,
+				})
+			}
+		}
+	}
+	if len(results) > max {
+		results = results[:max]
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *Server) flushCache(w http.ResponseWriter, r *http.Request) {
+	domain := r.URL.Query().Get("domain")
+	if domain == "" {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "not enough query parameters")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flushed = append(s.flushed, canonicalize(domain))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listZones(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zones := make([]client.Zone, 0, len(s.zones))
+	for _, name := range s.zoneNames() {
+		z := s.zones[name]
+		z.RRSets = nil
+		zones = append(zones, z)
+	}
+	writeJSON(w, http.StatusOK, zones)
+}
+
+func (s *Server) createZone(w http.ResponseWriter, r *http.Request) {
+	var z client.Zone
+	if err := json.NewDecoder(r.Body).Decode(&z); err != nil {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "decoding input zone: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z.Name = canonicalize(z.Name)
+	if name := r.URL.Query().Get("template"); name != "" {
+		tmpl, ok := s.templates[strings.ToLower(name)]
+		if !ok {
+			writeError(w, http.StatusBadRequest, client.BadRequest, "unknown zone template "+name)
+			return
+		}
+		z = applyTemplate(tmpl, z)
+	}
+	if z.Name == "" || z.Kind == "" {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "name and kind are required")
+		return
+	}
+	if (z.Kind == client.ZoneKindSlave) != (len(z.Masters) > 0) {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "masters are required for Slave zone and allowed only for it")
+		return
+	}
+	if _, ok := s.zones[z.Name]; ok {
+		writeError(w, http.StatusConflict, client.Conflict, "zone "+z.Name+" already exists")
+		return
+	}
+	keys := client.ZoneTSIGKeys{MasterTSIGKeyIDs: z.MasterTSIGKeyIDs, SlaveTSIGKeyIDs: z.SlaveTSIGKeyIDs}
+	if !s.knownTSIGKeys(w, keys) {
+		return
+	}
+	z.ID = z.Name
+	z.Serial = 1
+	z.Nameservers = nil
+	z.MasterTSIGKeyIDs = nil
+	z.SlaveTSIGKeyIDs = nil
+	s.zones[z.Name] = z
+	s.zoneTSIGKeys[z.Name] = keys
+	writeJSON(w, http.StatusCreated, z)
+}
+
+// zoneAction checks the zone kind like pdns-api, the action itself does nothing
+func (s *Server) zoneAction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z, ok := s.zones[canonicalize(vars["zoneID"])]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "zone "+vars["zoneID"]+" not found")
+		return
+	}
+	if (vars["action"] == "axfr-retrieve") != (z.Kind == client.ZoneKindSlave) {
+		writeError(w, http.StatusBadRequest, client.BadRequest, vars["action"]+" is not supported for "+z.Kind+" zone "+z.Name)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyTemplate fills kind and missing RRSets of the zone from the template
+func applyTemplate(tmpl, z client.Zone) client.Zone {
+	if z.Kind == "" {
+		z.Kind = tmpl.Kind
+	}
+	for _, rrset := range tmpl.RRSets {
+		if rrset.Name == "" || rrset.Name == "@" {
+			rrset.Name = z.Name
+		} else if !strings.HasSuffix(rrset.Name, ".") {
+			rrset.Name += "." + z.Name
+		}
+		found := false
+		for _, existing := range z.RRSets {
+			if canonicalize(existing.Name) == rrset.Name && existing.Type == rrset.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			z.RRSets = append(z.RRSets, rrset)
+		}
+	}
+	return z
+}
+
+func (s *Server) getZone(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z, ok := s.zones[canonicalize(mux.Vars(r)["zoneID"])]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "zone not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, z)
+}
+
+func (s *Server) patchZone(w http.ResponseWriter, r *http.Request) {
+	var patch struct {
+		RRSets []client.RRSet `json:"rrsets"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "decoding input zone: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := canonicalize(mux.Vars(r)["zoneID"])
+	z, ok := s.zones[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "zone not found")
+		return
+	}
+	rrsets := append([]client.RRSet(nil), z.RRSets...)
+	for _, in := range patch.RRSets {
+		in.Name = canonicalize(in.Name)
+		kept := rrsets[:0:0]
+		for _, rrset := range rrsets {
+			if rrset.Name != in.Name || rrset.Type != in.Type {
+				kept = append(kept, rrset)
+			}
+		}
+		switch in.ChangeType {
+		case client.ChangeTypeReplace:
+			in.ChangeType = ""
+			kept = append(kept, in)
+		case client.ChangeTypeDelete:
+		default:
+			writeError(w, http.StatusBadRequest, client.BadRequest, "unknown changetype "+in.ChangeType)
+			return
+		}
+		rrsets = kept
+	}
+	z.RRSets = rrsets
+	z.Serial++
+	s.zones[name] = z
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getRRSet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z, ok := s.zones[canonicalize(mux.Vars(r)["zoneID"])]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "zone not found")
+		return
+	}
+	name, rrType := rrsetVars(r, z.Name)
+	for _, rrset := range z.RRSets {
+		if rrset.Name == name && rrset.Type == rrType {
+			writeJSON(w, http.StatusOK, rrset)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, client.NotFound, "rrset not found")
+}
+
+func (s *Server) putRRSet(w http.ResponseWriter, r *http.Request) {
+	var in client.RRSet
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "decoding input RRset: "+err.Error())
+		return
+	}
+	if len(in.Records) == 0 {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "RRset has no records")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zoneName := canonicalize(mux.Vars(r)["zoneID"])
+	z, ok := s.zones[zoneName]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "zone not found")
+		return
+	}
+	in.Name, in.Type = rrsetVars(r, z.Name)
+	in.ChangeType = ""
+	rrsets := []client.RRSet{in}
+	for _, rrset := range z.RRSets {
+		if rrset.Name != in.Name || rrset.Type != in.Type {
+			rrsets = append(rrsets, rrset)
+		}
+	}
+	z.RRSets = rrsets
+	z.Serial++
+	s.zones[zoneName] = z
+	writeJSON(w, http.StatusOK, in)
+}
+
+// rrsetVars returns canonical RRset name and type from the path
+func rrsetVars(r *http.Request, zone string) (string, string) {
+	vars := mux.Vars(r)
+	name := canonicalize(strings.ToLower(vars["name"]))
+	if vars["name"] == "@" {
+		name = zone
+	}
+	return name, strings.ToUpper(vars["type"])
+}
+
+func (s *Server) deleteZone(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := canonicalize(mux.Vars(r)["zoneID"])
+	if _, ok := s.zones[name]; !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "zone not found")
+		return
+	}
+	delete(s.zones, name)
+	delete(s.zoneTSIGKeys, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// transferZone returns RRSets of the zone sorted by name and type, the fake serves the latest serial
+func (s *Server) transferZone(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z, ok := s.zones[canonicalize(mux.Vars(r)["zoneID"])]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "zone not found")
+		return
+	}
+	if key := r.URL.Query().Get("tsig_key"); key != "" {
+		if _, ok := s.tsigKeys[canonicalize(key)]; !ok {
+			writeError(w, http.StatusNotFound, client.NotFound, "TSIG key not found")
+			return
+		}
+	}
+	rrsets := append([]client.RRSet(nil), z.RRSets...)
+	sort.Slice(rrsets, func(i, j int) bool {
+		if rrsets[i].Name != rrsets[j].Name {
+			return rrsets[i].Name < rrsets[j].Name
+		}
+		return rrsets[i].Type < rrsets[j].Type
+	})
+	writeJSON(w, http.StatusOK, client.ZoneTransfer{
+		Zone:      z.Name,
+		Server:    "127.0.0.1:5353",
+		Serial:    z.Serial,
+		APISerial: z.Serial,
+		RRSets:    rrsets,
+	})
+}
+
+// zoneConsistency reports the zone on the only node of the fake, which is always consistent
+func (s *Server) zoneConsistency(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z, ok := s.zones[canonicalize(mux.Vars(r)["zoneID"])]
+	if !ok {
+		writeJSON(w, http.StatusOK, client.ZoneConsistency{
+			Zone:  canonicalize(mux.Vars(r)["zoneID"]),
+			Nodes: []client.NodeZoneState{{Node: NodeName, Status: http.StatusNotFound, Error: "zone not found"}},
+		})
+		return
+	}
+	state := client.NodeZoneState{Node: NodeName, Status: http.StatusOK, Serial: z.Serial, InSync: true}
+	if rrsets, _ := strconv.ParseBool(r.URL.Query().Get("rrsets")); rrsets {
+		b, _ := json.Marshal(z.RRSets)
+		sum := sha256.Sum256(b)
+		state.RRSetsHash = hex.EncodeToString(sum[:])
+	}
+	writeJSON(w, http.StatusOK, client.ZoneConsistency{
+		Zone:       z.Name,
+		Consistent: true,
+		Serial:     state.Serial,
+		RRSetsHash: state.RRSetsHash,
+		Nodes:      []client.NodeZoneState{state},
+	})
+}
+
+// verifyZone checks expected RRsets against zones in memory once, both servers of the only node
+// answer with the current zone data
+func (s *Server) verifyZone(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RRSets []client.ExpectedRRSet `json:"rrsets"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, client.BadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := canonicalize(mux.Vars(r)["zoneID"])
+	z := s.zones[name]
+	node := client.NodeVerification{Node: NodeName, Status: http.StatusOK, Converged: true, Checks: []client.Check{}}
+	for _, e := range req.RRSets {
+		records := []string{}
+		for _, rrset := range z.RRSets {
+			if strings.EqualFold(canonicalize(rrset.Name), canonicalize(e.Name)) && rrset.Type == e.Type {
+				for _, record := range rrset.Records {
+					if !record.Disabled {
+						records = append(records, record.Content)
+					}
+				}
+			}
+		}
+		expected := append([]string(nil), e.Records...)
+		sort.Strings(records)
+		sort.Strings(expected)
+		ok := strings.Join(records, "\n") == strings.Join(expected, "\n")
+		for _, server := range []string{client.ServerAuth, client.ServerRecursor} {
+			node.Checks = append(node.Checks, client.Check{
+				Name: canonicalize(e.Name), Type: e.Type, Server: server, OK: ok, Rcode: "NOERROR", Records: records,
+			})
+		}
+		node.Converged = node.Converged && ok
+	}
+	writeJSON(w, http.StatusOK, client.ZoneVerification{
+		Zone:      name,
+		Converged: node.Converged,
+		Attempts:  1,
+		Nodes:     []client.NodeVerification{node},
+	})
+}
+
+func (s *Server) addACMEChallenge(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "decoding challenge: "+err.Error())
+		return
+	}
+	if req.Value == "" {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "challenge value is required")
+		return
+	}
+	s.changeACMEChallenge(w, req.Name, req.Value, false)
+}
+
+func (s *Server) deleteACMEChallenge(w http.ResponseWriter, r *http.Request) {
+	s.changeACMEChallenge(w, r.URL.Query().Get("name"), r.URL.Query().Get("value"), true)
+}
+
+// changeACMEChallenge adds or removes the value of challenge TXT records in the closest zone of the domain,
+// or all values if value is empty. Changes are visible at once.
+func (s *Server) changeACMEChallenge(w http.ResponseWriter, domain, value string, remove bool) {
+	name := strings.TrimPrefix(canonicalize(strings.ToLower(domain)), "*.")
+	if !strings.HasPrefix(name, "_acme-challenge.") {
+		name = "_acme-challenge." + name
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var zoneName string
+	for _, n := range s.zoneNames() {
+		if (name == n || strings.HasSuffix(name, "."+n)) && len(n) > len(zoneName) {
+			zoneName = n
+		}
+	}
+	if zoneName == "" {
+		writeError(w, http.StatusNotFound, client.NotFound, "no authoritative zone for "+name)
+		return
+	}
+	z := s.zones[zoneName]
+
+	content := `"` + value + `"`
+	var records []client.Record
+	rrsets := []client.RRSet{}
+	for _, rrset := range z.RRSets {
+		if rrset.Name == name && rrset.Type == "TXT" {
+			records = rrset.Records
+			continue
+		}
+		rrsets = append(rrsets, rrset)
+	}
+	var found bool
+	kept := []client.Record{}
+	for _, record := range records {
+		found = found || record.Content == content
+		if remove && (value == "" || record.Content == content) {
+			continue
+		}
+		kept = append(kept, record)
+	}
+	if !remove && !found {
+		kept = append(kept, client.Record{Content: content})
+	}
+	if len(kept) > 0 {
+		rrsets = append(rrsets, client.RRSet{Name: name, Type: "TXT", TTL: 60, Records: kept})
+	}
+	z.RRSets = rrsets
+	z.Serial++
+	s.zones[zoneName] = z
+	s.flushed = append(s.flushed, name)
+
+	values, contents := []string{}, []string{}
+	for _, record := range kept {
+		values = append(values, strings.Trim(record.Content, `"`))
+		contents = append(contents, record.Content)
+	}
+	node := client.NodeVerification{Node: NodeName, Status: http.StatusOK, Converged: true, Checks: []client.Check{}}
+	for _, server := range []string{client.ServerAuth, client.ServerRecursor} {
+		node.Checks = append(node.Checks, client.Check{Name: name, Type: "TXT", Server: server, OK: true, Rcode: "NOERROR", Records: contents})
+	}
+	writeJSON(w, http.StatusOK, client.ACMEChallenge{
+		Name:     name,
+		Zone:     zoneName,
+		Values:   values,
+		Visible:  true,
+		Attempts: 1,
+		Nodes:    []client.NodeVerification{node},
+	})
+}
+
+func (s *Server) getZoneTSIGKeys(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := canonicalize(mux.Vars(r)["zoneID"])
+	if _, ok := s.zones[name]; !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "zone not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNilTSIGKeys(s.zoneTSIGKeys[name]))
+}
+
+func (s *Server) setZoneTSIGKeys(w http.ResponseWriter, r *http.Request) {
+	var keys client.ZoneTSIGKeys
+	if err := json.NewDecoder(r.Body).Decode(&keys); err != nil {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "decoding input TSIG keys: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := canonicalize(mux.Vars(r)["zoneID"])
+	z, ok := s.zones[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "zone not found")
+		return
+	}
+	if len(keys.SlaveTSIGKeyIDs) > 0 && z.Kind != client.ZoneKindSlave {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "slave TSIG key is allowed for Slave zone only")
+		return
+	}
+	if !s.knownTSIGKeys(w, keys) {
+		return
+	}
+	keys = nonNilTSIGKeys(keys)
+	s.zoneTSIGKeys[name] = keys
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// knownTSIGKeys writes an error if the zone references unknown TSIG keys. Must be called with mutex held.
+func (s *Server) knownTSIGKeys(w http.ResponseWriter, keys client.ZoneTSIGKeys) bool {
+	for _, id := range append(append([]string(nil), keys.MasterTSIGKeyIDs...), keys.SlaveTSIGKeyIDs...) {
+		if _, ok := s.tsigKeys[canonicalize(id)]; !ok {
+			writeError(w, http.StatusBadRequest, client.BadRequest, "unknown TSIG key "+id)
+			return false
+		}
+	}
+	return true
+}
+
+func nonNilTSIGKeys(keys client.ZoneTSIGKeys) client.ZoneTSIGKeys {
+	if keys.MasterTSIGKeyIDs == nil {
+		keys.MasterTSIGKeyIDs = []string{}
+	}
+	if keys.SlaveTSIGKeyIDs == nil {
+		keys.SlaveTSIGKeyIDs = []string{}
+	}
+	return keys
+}
+
+func (s *Server) listTSIGKeys(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.tsigKeys))
+	for name := range s.tsigKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	keys := make([]client.TSIGKey, 0, len(names))
+	for _, name := range names {
+		key := s.tsigKeys[name]
+		key.Key = ""
+		keys = append(keys, key)
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (s *Server) getTSIGKey(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.tsigKeys[canonicalize(mux.Vars(r)["keyID"])]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "TSIG key not found")
+		return
+	}
+	key.Key = ""
+	writeJSON(w, http.StatusOK, key)
+}
+
+func (s *Server) createTSIGKey(w http.ResponseWriter, r *http.Request) {
+	key, ok := decodeTSIGKey(w, r)
+	if !ok {
+		return
+	}
+	if key.Name == "" {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "name is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key.Name = canonicalize(key.Name)
+	if _, ok := s.tsigKeys[key.Name]; ok {
+		writeError(w, http.StatusConflict, client.Conflict, "TSIG key "+key.Name+" already exists")
+		return
+	}
+	key.ID = key.Name
+	s.tsigKeys[key.Name] = key
+	writeJSON(w, http.StatusCreated, key)
+}
+
+func (s *Server) updateTSIGKey(w http.ResponseWriter, r *http.Request) {
+	in, ok := decodeTSIGKey(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := canonicalize(mux.Vars(r)["keyID"])
+	key, ok := s.tsigKeys[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "TSIG key not found")
+		return
+	}
+	if in.Name != "" && canonicalize(in.Name) != name {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "TSIG key "+name+" can't be renamed")
+		return
+	}
+	key.Algorithm, key.Key = in.Algorithm, in.Key
+	s.tsigKeys[name] = key
+	writeJSON(w, http.StatusOK, key)
+}
+
+func (s *Server) deleteTSIGKey(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := canonicalize(mux.Vars(r)["keyID"])
+	if _, ok := s.tsigKeys[name]; !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "TSIG key not found")
+		return
+	}
+	delete(s.tsigKeys, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeTSIGKey decodes TSIG key, default algorithm and a fake secret are set like in pdns-api
+func decodeTSIGKey(w http.ResponseWriter, r *http.Request) (client.TSIGKey, bool) {
+	var key client.TSIGKey
+	if err := json.NewDecoder(r.Body).Decode(&key); err != nil {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "decoding input TSIG key: "+err.Error())
+		return key, false
+	}
+	if key.Algorithm == "" {
+		key.Algorithm = "hmac-sha256"
+	}
+	if key.Key == "" {
+		key.Key = base64.StdEncoding.EncodeToString([]byte("generated " + key.Algorithm))
+	}
+	return key, true
+}
+
+func (s *Server) listForwardZones(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fzs := make([]client.ForwardZone, 0, len(s.forwardZones))
+	for _, name := range s.forwardZoneNames() {
+		fzs = append(fzs, s.forwardZones[name])
+	}
+	writeJSON(w, http.StatusOK, fzs)
+}
+
+func (s *Server) getForwardZone(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fz, ok := s.forwardZones[canonicalize(mux.Vars(r)["zoneID"])]
+	if !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "forward-zone not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, fz)
+}
+
+func (s *Server) createForwardZones(w http.ResponseWriter, r *http.Request) {
+	fzs, ok := decodeForwardZones(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, fz := range fzs {
+		if _, ok := s.forwardZones[canonicalize(fz.Name)]; ok {
+			writeError(w, http.StatusConflict, client.Conflict, "forward-zone "+fz.Name+" already exists")
+			return
+		}
+	}
+	for _, fz := range fzs {
+		fz.Name = canonicalize(fz.Name)
+		s.forwardZones[fz.Name] = fz
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) updateForwardZone(w http.ResponseWriter, r *http.Request) {
+	var fz client.ForwardZone
+	if err := json.NewDecoder(r.Body).Decode(&fz); err != nil {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "decoding forward zone: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := canonicalize(mux.Vars(r)["zoneID"])
+	if _, ok := s.forwardZones[name]; !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "forward-zone not found")
+		return
+	}
+	fz.Name = name
+	s.forwardZones[name] = fz
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteForwardZone(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := canonicalize(mux.Vars(r)["zoneID"])
+	if _, ok := s.forwardZones[name]; !ok {
+		writeError(w, http.StatusNotFound, client.NotFound, "forward-zone not found")
+		return
+	}
+	delete(s.forwardZones, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteForwardZones(w http.ResponseWriter, r *http.Request) {
+	fzs, ok := decodeForwardZones(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, fz := range fzs {
+		if _, ok := s.forwardZones[canonicalize(fz.Name)]; !ok {
+			writeError(w, http.StatusNotFound, client.NotFound, "forward-zone not found")
+			return
+		}
+	}
+	for _, fz := range fzs {
+		delete(s.forwardZones, canonicalize(fz.Name))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeForwardZones(w http.ResponseWriter, r *http.Request) ([]client.ForwardZone, bool) {
+	var fzs []client.ForwardZone
+	if err := json.NewDecoder(r.Body).Decode(&fzs); err != nil {
+		writeError(w, http.StatusBadRequest, client.BadRequest, "decoding forward zones: "+err.Error())
+		return nil, false
+	}
+	for _, fz := range fzs {
+		if fz.Name == "" || len(fz.Nameservers) == 0 {
+			writeError(w, http.StatusBadRequest, client.BadRequest, "name and nameservers are required")
+			return nil, false
+		}
+	}
+	return fzs, true
+}
+
+func server() client.Server {
+	return client.Server{
+		ID:         ServerID,
+		Type:       "Server",
+		DaemonType: "authoritative",
+		Version:    "fake",
+		URL:        "/api/v1/servers/" + ServerID,
+		ZonesURL:   "/api/v1/servers/" + ServerID + "/zones{/zone}",
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, t client.ErrorType, msg string) {
+	writeJSON(w, status, &client.Error{Type: t, Message: msg})
+}
+
+func canonicalize(name string) string {
+	if name != "" && !strings.HasSuffix(name, ".") {
+		return name + "."
+	}
+	return name
+}
+
+// zoneNames returns sorted zone names. Must be called with mutex held.
+func (s *Server) zoneNames() []string {
+	names := make([]string, 0, len(s.zones))
+	for name := range s.zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// forwardZoneNames returns sorted forward zone names. Must be called with mutex held.
+func (s *Server) forwardZoneNames() []string {
+	names := make([]string, 0, len(s.forwardZones))
+	for name := range s.forwardZones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
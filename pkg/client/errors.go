@@ -0,0 +1,106 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorType is a kind of error returned by pdns-api, the code field of error response
+type ErrorType string
+
+// Error types, mirror error types of pdns-api
+const (
+	NoType             ErrorType = ""
+	Internal           ErrorType = "internal_error"
+	BadRequest         ErrorType = "bad_request"
+	NotFound           ErrorType = "not_found"
+	Conflict           ErrorType = "conflict"
+	Unauthorized       ErrorType = "unauthorized"
+	Forbidden          ErrorType = "forbidden"
+	Unavailable        ErrorType = "unavailable"
+	UpstreamError      ErrorType = "upstream_error"
+	PreconditionFailed ErrorType = "precondition_failed"
+)
+
+// FieldError is an error of a single request field or parameter
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error response of pdns-api
+type Error struct {
+	StatusCode int          `json:"-"`
+	Type       ErrorType    `json:"code"`
+	Message    string       `json:"message"`
+	Details    string       `json:"details,omitempty"`
+	RequestID  string       `json:"request_id,omitempty"`
+	Fields     []FieldError `json:"fields,omitempty"`
+}
+
+// Error implements error interface
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pdns-api: %d %s", e.StatusCode, e.Message)
+	if e.Details != "" {
+		fmt.Fprintf(&b, " (%s)", e.Details)
+	}
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "; %s: %s", f.Field, f.Message)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " [request_id %s]", e.RequestID)
+	}
+	return b.String()
+}
+
+// GetType returns type of error returned by pdns-api, NoType for other errors, e.g. network ones
+func GetType(err error) ErrorType {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Type
+	}
+	return NoType
+}
+
+// errorTypeFromStatus is used for responses without pdns-api error body, e.g. from proxy
+func errorTypeFromStatus(status int) ErrorType {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return BadRequest
+	case http.StatusUnauthorized:
+		return Unauthorized
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusPreconditionFailed:
+		return PreconditionFailed
+	case http.StatusBadGateway:
+		return UpstreamError
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return Unavailable
+	default:
+		return Internal
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListForwardZones returns forward zones of Recursor
+func (c *Client) ListForwardZones(ctx context.Context, serverID string) ([]ForwardZone, error) {
+	var fzs []ForwardZone
+	err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "forward-zones"), nil, nil, &fzs)
+	return fzs, err
+}
+
+// GetForwardZone returns forward zone by name
+func (c *Client) GetForwardZone(ctx context.Context, serverID, name string) (*ForwardZone, error) {
+	fz := new(ForwardZone)
+	if err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "forward-zones", name), nil, nil, fz); err != nil {
+		return nil, err
+	}
+	return fz, nil
+}
+
+// CreateForwardZones creates forward zones on all Recursor nodes
+func (c *Client) CreateForwardZones(ctx context.Context, serverID string, fzs ...ForwardZone) error {
+	return c.do(ctx, http.MethodPost, apiPath("servers", serverID, "forward-zones"), nil, fzs, nil)
+}
+
+// UpdateForwardZone replaces nameservers of forward zone on all Recursor nodes
+func (c *Client) UpdateForwardZone(ctx context.Context, serverID string, fz ForwardZone) error {
+	return c.do(ctx, http.MethodPatch, apiPath("servers", serverID, "forward-zones", fz.Name), nil, fz, nil)
+}
+
+// DeleteForwardZone deletes forward zone on all Recursor nodes
+func (c *Client) DeleteForwardZone(ctx context.Context, serverID, name string) error {
+	return c.do(ctx, http.MethodDelete, apiPath("servers", serverID, "forward-zones", name), nil, nil, nil)
+}
+
+// DeleteForwardZones deletes forward zones on all Recursor nodes
+func (c *Client) DeleteForwardZones(ctx context.Context, serverID string, fzs ...ForwardZone) error {
+	return c.do(ctx, http.MethodDelete, apiPath("servers", serverID, "forward-zones"), nil, fzs, nil)
+}
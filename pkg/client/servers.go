@@ -0,0 +1,78 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListServers returns PowerDNS servers
+func (c *Client) ListServers(ctx context.Context) ([]Server, error) {
+	var servers []Server
+	err := c.do(ctx, http.MethodGet, apiPath("servers"), nil, nil, &servers)
+	return servers, err
+}
+
+// GetServer returns PowerDNS server by ID, usually localhost
+func (c *Client) GetServer(ctx context.Context, serverID string) (*Server, error) {
+	server := new(Server)
+	if err := c.do(ctx, http.MethodGet, apiPath("servers", serverID), nil, nil, server); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// Search searches zones, records and comments by query with * and ? wildcards.
+// max limits number of results, zero means the server default.
+func (c *Client) Search(ctx context.Context, serverID, query string, max int, objectType ObjectType) ([]SearchResult, error) {
+	q := url.Values{"q": {query}}
+	if max > 0 {
+		q.Set("max", strconv.Itoa(max))
+	}
+	if objectType != "" {
+		q.Set("object_type", string(objectType))
+	}
+	var results []SearchResult
+	err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "search-data"), q, nil, &results)
+	return results, err
+}
+
+// FlushCache flushes cache of the name in Authoritative and Recursor on all nodes
+func (c *Client) FlushCache(ctx context.Context, serverID, name string) error {
+	q := url.Values{"domain": {name}}
+	return c.do(ctx, http.MethodPut, apiPath("servers", serverID, "cache", "flush"), q, nil, nil)
+}
+
+// Lookup queries PowerDNS Authoritative without recursion and Recursor with recursion on the pdns-api node
+// for the name and type over DNS. server is ServerAuth, ServerRecursor or LookupBoth, empty means both.
+func (c *Client) Lookup(ctx context.Context, name, rrType, server string) (*Lookup, error) {
+	q := url.Values{"name": {name}}
+	if rrType != "" {
+		q.Set("type", rrType)
+	}
+	if server != "" {
+		q.Set("server", server)
+	}
+	lookup := new(Lookup)
+	if err := c.do(ctx, http.MethodGet, apiPath("lookup"), q, nil, lookup); err != nil {
+		return nil, err
+	}
+	return lookup, nil
+}
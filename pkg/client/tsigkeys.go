@@ -0,0 +1,81 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListTSIGKeys returns TSIG keys without secrets
+func (c *Client) ListTSIGKeys(ctx context.Context, serverID string) ([]TSIGKey, error) {
+	var keys []TSIGKey
+	err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "tsigkeys"), nil, nil, &keys)
+	return keys, err
+}
+
+// GetTSIGKey returns TSIG key without secret
+func (c *Client) GetTSIGKey(ctx context.Context, serverID, keyID string) (*TSIGKey, error) {
+	key := new(TSIGKey)
+	if err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "tsigkeys", keyID), nil, nil, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// CreateTSIGKey creates TSIG key and returns it with secret.
+// The secret is generated for the algorithm if it is empty.
+func (c *Client) CreateTSIGKey(ctx context.Context, serverID string, key TSIGKey) (*TSIGKey, error) {
+	created := new(TSIGKey)
+	if err := c.do(ctx, http.MethodPost, apiPath("servers", serverID, "tsigkeys"), nil, key, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateTSIGKey replaces algorithm and secret of TSIG key and returns it with secret.
+// The secret is generated for the algorithm if it is empty.
+func (c *Client) UpdateTSIGKey(ctx context.Context, serverID, keyID string, key TSIGKey) (*TSIGKey, error) {
+	updated := new(TSIGKey)
+	if err := c.do(ctx, http.MethodPut, apiPath("servers", serverID, "tsigkeys", keyID), nil, key, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteTSIGKey deletes TSIG key
+func (c *Client) DeleteTSIGKey(ctx context.Context, serverID, keyID string) error {
+	return c.do(ctx, http.MethodDelete, apiPath("servers", serverID, "tsigkeys", keyID), nil, nil, nil)
+}
+
+// GetZoneTSIGKeys returns TSIG keys referenced by authoritative zone
+func (c *Client) GetZoneTSIGKeys(ctx context.Context, serverID, zone string) (*ZoneTSIGKeys, error) {
+	keys := new(ZoneTSIGKeys)
+	if err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "zones", zone, "tsigkeys"), nil, nil, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// SetZoneTSIGKeys replaces TSIG keys referenced by authoritative zone, the keys must exist
+func (c *Client) SetZoneTSIGKeys(ctx context.Context, serverID, zone string, keys ZoneTSIGKeys) (*ZoneTSIGKeys, error) {
+	updated := new(ZoneTSIGKeys)
+	if err := c.do(ctx, http.MethodPut, apiPath("servers", serverID, "zones", zone, "tsigkeys"), nil, keys, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,304 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Server is a PowerDNS server
+type Server struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	DaemonType string `json:"daemon_type"`
+	Version    string `json:"version"`
+	URL        string `json:"url,omitempty"`
+	ConfigURL  string `json:"config_url,omitempty"`
+	ZonesURL   string `json:"zones_url,omitempty"`
+}
+
+// Kinds of authoritative zones
+const (
+	ZoneKindNative = "Native"
+	ZoneKindMaster = "Master"
+	ZoneKindSlave  = "Slave"
+)
+
+// Zone is an authoritative zone
+type Zone struct {
+	ID             string   `json:"id,omitempty"`
+	Name           string   `json:"name"`
+	Kind           string   `json:"kind,omitempty"`
+	RRSets         []RRSet  `json:"rrsets,omitempty"`
+	Serial         int      `json:"serial,omitempty"`
+	NotifiedSerial int      `json:"notified_serial,omitempty"`
+	Masters        []string `json:"masters,omitempty"`
+	DNSSec         bool     `json:"dnssec,omitempty"`
+	SOAEdit        string   `json:"soa_edit,omitempty"`
+	SOAEditAPI     string   `json:"soa_edit_api,omitempty"`
+	APIRectify     bool     `json:"api_rectify,omitempty"`
+	Account        string   `json:"account,omitempty"`
+	// Nameservers are used only on zone creation
+	Nameservers []string `json:"nameservers,omitempty"`
+	// MasterTSIGKeyIDs and SlaveTSIGKeyIDs are used only on zone creation
+	MasterTSIGKeyIDs []string `json:"master_tsig_key_ids,omitempty"`
+	SlaveTSIGKeyIDs  []string `json:"slave_tsig_key_ids,omitempty"`
+}
+
+// ZoneTransfer is an authoritative zone as served over DNS, transferred by AXFR
+type ZoneTransfer struct {
+	Zone string `json:"zone"`
+	// Server is an address of DNS listener the zone was transferred from
+	Server string `json:"server"`
+	// Serial is SOA serial served over DNS
+	Serial int `json:"serial"`
+	// APISerial is the serial reported by PowerDNS API, it differs from Serial until the change is served
+	APISerial int     `json:"api_serial"`
+	RRSets    []RRSet `json:"rrsets"`
+}
+
+// ZoneConsistency is a result of comparing the zone between all healthy nodes
+type ZoneConsistency struct {
+	Zone string `json:"zone"`
+	// Consistent is true if all nodes have the same zone state
+	Consistent bool `json:"consistent"`
+	// Serial and RRSetsHash are the state most of nodes have
+	Serial     int             `json:"serial"`
+	RRSetsHash string          `json:"rrsets_hash,omitempty"`
+	Nodes      []NodeZoneState `json:"nodes"`
+}
+
+// NodeZoneState is the zone state on a single node
+type NodeZoneState struct {
+	Node       string `json:"node"`
+	Status     int    `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Serial     int    `json:"serial,omitempty"`
+	RRSetsHash string `json:"rrsets_hash,omitempty"`
+	// InSync is true if the node has the same state as most of nodes
+	InSync bool `json:"in_sync"`
+}
+
+// ExpectedRRSet is an RRset expected to be served over DNS
+type ExpectedRRSet struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Records is expected content of records in any order, empty means the RRset must not exist
+	Records []string `json:"records"`
+}
+
+// Servers of a node checked by zone verification
+const (
+	ServerAuth     = "auth"
+	ServerRecursor = "recursor"
+)
+
+// ZoneVerification is a result of checking expected RRsets on all healthy nodes
+type ZoneVerification struct {
+	Zone string `json:"zone"`
+	// Converged is true if all servers of all nodes answer as expected
+	Converged bool `json:"converged"`
+	// Attempts is a number of times the checks were done
+	Attempts int                `json:"attempts"`
+	Nodes    []NodeVerification `json:"nodes"`
+}
+
+// NodeVerification is a result of checks on a single node
+type NodeVerification struct {
+	Node      string  `json:"node"`
+	Status    int     `json:"status,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	Converged bool    `json:"converged"`
+	Checks    []Check `json:"checks"`
+}
+
+// ACMEChallenge is a state of ACME DNS-01 challenge TXT records after a change
+type ACMEChallenge struct {
+	// Name is the name of challenge records, _acme-challenge label with the domain
+	Name string `json:"name"`
+	// Zone is the closest authoritative zone of the name
+	Zone string `json:"zone"`
+	// Values are values of enabled challenge records after the change
+	Values []string `json:"values"`
+	// Visible is true if all servers of all nodes answer with the values
+	Visible bool `json:"visible"`
+	// Attempts is a number of times the checks were done
+	Attempts int                `json:"attempts"`
+	Nodes    []NodeVerification `json:"nodes"`
+}
+
+// Check is a result of checking the expected RRset on a single server
+type Check struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Server is ServerAuth or ServerRecursor
+	Server string `json:"server"`
+	// OK is true if the server answers with expected records
+	OK    bool   `json:"ok"`
+	Rcode string `json:"rcode,omitempty"`
+	// Records is content of records the server answers with
+	Records []string `json:"records"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// LookupBoth is a server of lookup to query both authoritative server and recursor
+const LookupBoth = "both"
+
+// Lookup is a result of DNS query to PowerDNS servers of pdns-api node
+type Lookup struct {
+	Name    string         `json:"name"`
+	Type    string         `json:"type"`
+	Results []LookupResult `json:"results"`
+}
+
+// LookupResult is an answer of a single server or the error of the query
+type LookupResult struct {
+	// Server is ServerAuth or ServerRecursor
+	Server string     `json:"server"`
+	Error  string     `json:"error,omitempty"`
+	Answer *DNSAnswer `json:"answer,omitempty"`
+}
+
+// DNSAnswer is a DNS response
+type DNSAnswer struct {
+	// Server is host:port the query was sent to
+	Server string   `json:"server"`
+	Rcode  string   `json:"rcode"`
+	Flags  DNSFlags `json:"flags"`
+	// RTT is round trip time in milliseconds
+	RTT        float64     `json:"rtt_ms"`
+	Question   []DNSRecord `json:"question"`
+	Answer     []DNSRecord `json:"answer"`
+	Authority  []DNSRecord `json:"authority"`
+	Additional []DNSRecord `json:"additional"`
+}
+
+// DNSFlags are header flags of DNS response
+type DNSFlags struct {
+	Authoritative      bool `json:"aa"`
+	Truncated          bool `json:"tc"`
+	RecursionDesired   bool `json:"rd"`
+	RecursionAvailable bool `json:"ra"`
+	AuthenticatedData  bool `json:"ad"`
+	CheckingDisabled   bool `json:"cd"`
+}
+
+// DNSRecord is a resource record of DNS response, TTL and content are empty for question
+type DNSRecord struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	TTL     int    `json:"ttl,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// Change types of RRSet in zone patch
+const (
+	ChangeTypeReplace = "REPLACE"
+	ChangeTypeDelete  = "DELETE"
+)
+
+// RRSet is a set of resource records with the same name and type
+type RRSet struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	TTL        int       `json:"ttl,omitempty"`
+	ChangeType string    `json:"changetype,omitempty"`
+	Records    []Record  `json:"records,omitempty"`
+	Comments   []Comment `json:"comments,omitempty"`
+}
+
+// Record is a single resource record
+type Record struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+	// SetPTR creates or updates PTR record in reverse zone
+	SetPTR bool `json:"set-ptr,omitempty"`
+}
+
+// Comment is a comment of RRSet
+type Comment struct {
+	Content    string `json:"content"`
+	Account    string `json:"account"`
+	ModifiedAt int    `json:"modified_at"`
+}
+
+// ForwardZone is a zone forwarded by Recursor to nameservers
+type ForwardZone struct {
+	Name string `json:"name"`
+	// Nameservers are IP addresses with optional port
+	Nameservers []string `json:"nameservers"`
+}
+
+// TSIGKey is a TSIG key of PowerDNS Authoritative.
+// Key is a base64 encoded secret, pdns-api returns it only on creation and update.
+type TSIGKey struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Algorithm string `json:"algorithm,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// ZoneTSIGKeys are TSIG keys referenced by authoritative zone for zone transfers
+type ZoneTSIGKeys struct {
+	// MasterTSIGKeyIDs are keys allowed to transfer the zone from the server
+	MasterTSIGKeyIDs []string `json:"master_tsig_key_ids"`
+	// SlaveTSIGKeyIDs is a key to transfer the Slave zone from masters
+	SlaveTSIGKeyIDs []string `json:"slave_tsig_key_ids"`
+}
+
+// ObjectType is a type of search result
+type ObjectType string
+
+// Object types of search
+const (
+	ObjectTypeAll     ObjectType = "all"
+	ObjectTypeZone    ObjectType = "zone"
+	ObjectTypeRecord  ObjectType = "record"
+	ObjectTypeComment ObjectType = "comment"
+)
+
+// UnmarshalJSON accepts object type as a string or as a number used by pdns-api
+func (t *ObjectType) UnmarshalJSON(b []byte) error {
+	var n int
+	if err := json.Unmarshal(b, &n); err == nil {
+		types := []ObjectType{ObjectTypeAll, ObjectTypeZone, ObjectTypeRecord, ObjectTypeComment}
+		if n < 1 || n > len(types) {
+			return fmt.Errorf("unknown object type: %d", n)
+		}
+		*t = types[n-1]
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*t = ObjectType(s)
+	return nil
+}
+
+// SearchResult is a zone, record or comment found by search
+type SearchResult struct {
+	Content    string     `json:"content"`
+	Disabled   bool       `json:"disabled"`
+	Name       string     `json:"name"`
+	ObjectType ObjectType `json:"object_type"`
+	ZoneID     string     `json:"zone_id"`
+	Zone       string     `json:"zone"`
+	Type       string     `json:"type"`
+	TTL        int        `json:"ttl"`
+}
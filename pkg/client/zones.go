@@ -0,0 +1,164 @@
+/*
+Copyright © 2021 Michael Bruskov <mixanemca@yandex.ru>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListZones returns authoritative zones without RRSets
+func (c *Client) ListZones(ctx context.Context, serverID string) ([]Zone, error) {
+	var zones []Zone
+	err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "zones"), nil, nil, &zones)
+	return zones, err
+}
+
+// GetZone returns authoritative zone with RRSets
+func (c *Client) GetZone(ctx context.Context, serverID, name string) (*Zone, error) {
+	zone := new(Zone)
+	if err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "zones", name), nil, nil, zone); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+// CreateZone creates authoritative zone and returns it
+func (c *Client) CreateZone(ctx context.Context, serverID string, zone Zone) (*Zone, error) {
+	created := new(Zone)
+	if err := c.do(ctx, http.MethodPost, apiPath("servers", serverID, "zones"), nil, zone, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// CreateZoneFromTemplate creates authoritative zone from the zone template and returns it.
+// Fields set in the zone override the template.
+func (c *Client) CreateZoneFromTemplate(ctx context.Context, serverID, template string, zone Zone) (*Zone, error) {
+	created := new(Zone)
+	query := url.Values{"template": {template}}
+	if err := c.do(ctx, http.MethodPost, apiPath("servers", serverID, "zones"), query, zone, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// TransferZone returns authoritative zone transferred by AXFR from PowerDNS DNS listener,
+// signed by TSIG key tsigKey if it is not empty
+func (c *Client) TransferZone(ctx context.Context, serverID, zone, tsigKey string) (*ZoneTransfer, error) {
+	var query url.Values
+	if tsigKey != "" {
+		query = url.Values{"tsig_key": {tsigKey}}
+	}
+	transfer := new(ZoneTransfer)
+	if err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "zones", zone, "axfr"), query, nil, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetZoneConsistency compares SOA serials of the zone on all healthy nodes, and hashes of RRsets if rrsets is true
+func (c *Client) GetZoneConsistency(ctx context.Context, serverID, zone string, rrsets bool) (*ZoneConsistency, error) {
+	query := url.Values{"rrsets": {strconv.FormatBool(rrsets)}}
+	consistency := new(ZoneConsistency)
+	if err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "zones", zone, "consistency"), query, nil, consistency); err != nil {
+		return nil, err
+	}
+	return consistency, nil
+}
+
+// VerifyZone checks that authoritative servers and recursors of all healthy nodes answer with expected RRsets.
+// If timeout is not zero, checks are repeated until all nodes answer as expected or the timeout expires,
+// ctx and HTTP client timeout (30 seconds by default) should allow for it.
+func (c *Client) VerifyZone(ctx context.Context, serverID, zone string, rrsets []ExpectedRRSet, timeout time.Duration) (*ZoneVerification, error) {
+	body := struct {
+		RRSets  []ExpectedRRSet `json:"rrsets"`
+		Timeout int             `json:"timeout"`
+	}{RRSets: rrsets, Timeout: int(timeout / time.Second)}
+	verification := new(ZoneVerification)
+	if err := c.do(ctx, http.MethodPost, apiPath("servers", serverID, "zones", zone, "verify"), nil, body, verification); err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// DeleteZone deletes authoritative zone with all RRSets
+func (c *Client) DeleteZone(ctx context.Context, serverID, name string) error {
+	return c.do(ctx, http.MethodDelete, apiPath("servers", serverID, "zones", name), nil, nil, nil)
+}
+
+// AxfrRetrieve retrieves Slave zone from its masters
+func (c *Client) AxfrRetrieve(ctx context.Context, serverID, zone string) error {
+	return c.do(ctx, http.MethodPut, apiPath("servers", serverID, "zones", zone, "axfr-retrieve"), nil, nil, nil)
+}
+
+// NotifyZone sends DNS NOTIFY for the zone to its slaves
+func (c *Client) NotifyZone(ctx context.Context, serverID, zone string) error {
+	return c.do(ctx, http.MethodPut, apiPath("servers", serverID, "zones", zone, "notify"), nil, nil, nil)
+}
+
+// RectifyZone rectifies DNSSEC data of the zone
+func (c *Client) RectifyZone(ctx context.Context, serverID, zone string) error {
+	return c.do(ctx, http.MethodPut, apiPath("servers", serverID, "zones", zone, "rectify"), nil, nil, nil)
+}
+
+// PatchRRSets replaces or deletes RRSets of the zone, depending on their ChangeType
+func (c *Client) PatchRRSets(ctx context.Context, serverID, zone string, rrsets ...RRSet) error {
+	body := struct {
+		RRSets []RRSet `json:"rrsets"`
+	}{RRSets: rrsets}
+	return c.do(ctx, http.MethodPatch, apiPath("servers", serverID, "zones", zone), nil, body, nil)
+}
+
+// ReplaceRRSet creates or replaces RRSet in the zone
+func (c *Client) ReplaceRRSet(ctx context.Context, serverID, zone string, rrset RRSet) error {
+	rrset.ChangeType = ChangeTypeReplace
+	return c.PatchRRSets(ctx, serverID, zone, rrset)
+}
+
+// DeleteRRSet deletes RRSet from the zone
+func (c *Client) DeleteRRSet(ctx context.Context, serverID, zone, name, rrType string) error {
+	return c.PatchRRSets(ctx, serverID, zone, RRSet{Name: name, Type: rrType, ChangeType: ChangeTypeDelete})
+}
+
+// GetRRSet returns RRSet of the zone by name and type, name @ means the zone apex
+func (c *Client) GetRRSet(ctx context.Context, serverID, zone, name, rrType string) (*RRSet, error) {
+	rrset := new(RRSet)
+	if err := c.do(ctx, http.MethodGet, apiPath("servers", serverID, "zones", zone, "rrsets", name, rrType), nil, nil, rrset); err != nil {
+		return nil, err
+	}
+	return rrset, nil
+}
+
+// PutRRSet creates or replaces a single RRSet in the zone and returns the result.
+// Unlike ReplaceRRSet, repeating the request with the same RRSet is safe.
+func (c *Client) PutRRSet(ctx context.Context, serverID, zone string, rrset RRSet) (*RRSet, error) {
+	// Name and type are passed in the path
+	body := struct {
+		TTL      int       `json:"ttl,omitempty"`
+		Records  []Record  `json:"records"`
+		Comments []Comment `json:"comments,omitempty"`
+	}{TTL: rrset.TTL, Records: rrset.Records, Comments: rrset.Comments}
+	result := new(RRSet)
+	if err := c.do(ctx, http.MethodPut, apiPath("servers", serverID, "zones", zone, "rrsets", rrset.Name, rrset.Type), nil, body, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}